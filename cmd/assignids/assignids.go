@@ -0,0 +1,173 @@
+// Package assignids contains the assign-ids command
+package assignids
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/squadracorsepolito/jsondbc/pkg"
+	"github.com/squadracorsepolito/jsondbc/pkg/idassign"
+)
+
+var (
+	modelFileName string
+	outFileName   string
+	defaultRange  string
+	nodeRanges    []string
+	classRanges   []string
+	step          uint32
+	dryRun        bool
+)
+
+// parseNamedRanges parses a list of name=from-to ranges.
+func parseNamedRanges(strs []string) (map[string]idassign.Range, error) {
+	ranges := make(map[string]idassign.Range, len(strs))
+	for _, str := range strs {
+		name, rangeStr, ok := strings.Cut(str, "=")
+		if !ok || len(name) == 0 {
+			return nil, fmt.Errorf("invalid range %q, expected name=from-to", str)
+		}
+
+		r, err := idassign.ParseRange(rangeStr)
+		if err != nil {
+			return nil, err
+		}
+		ranges[name] = r
+	}
+	return ranges, nil
+}
+
+func getConfig() (*idassign.Config, error) {
+	cfg := idassign.NewConfig()
+	cfg.Step = step
+
+	r, err := idassign.ParseRange(defaultRange)
+	if err != nil {
+		return nil, err
+	}
+	cfg.Default = r
+
+	cfg.Nodes, err = parseNamedRanges(nodeRanges)
+	if err != nil {
+		return nil, err
+	}
+	cfg.Classes, err = parseNamedRanges(classRanges)
+	if err != nil {
+		return nil, err
+	}
+
+	return cfg, nil
+}
+
+// assignIDs is the handler for the assign-ids command.
+// It assigns the ids of the messages that omit them and writes them into the JSON model,
+// without changing the rest of the file.
+func assignIDs() error {
+	if ext := filepath.Ext(modelFileName); ext != ".json" {
+		return fmt.Errorf("%s extension is not supported, the ids can be assigned only in json models", ext)
+	}
+
+	cfg, err := getConfig()
+	if err != nil {
+		return err
+	}
+
+	input, err := os.ReadFile(modelFileName)
+	if err != nil {
+		return err
+	}
+
+	inFile, err := os.Open(modelFileName)
+	if err != nil {
+		return err
+	}
+	defer inFile.Close()
+
+	canModel, err := pkg.NewJsonReader().Read(inFile)
+	if err != nil {
+		return err
+	}
+
+	for nodeName := range cfg.Nodes {
+		if _, ok := canModel.Nodes[nodeName]; !ok {
+			log.Printf("WARNING: node [%s] of the id ranges is not defined -> IGNORED", nodeName)
+		}
+	}
+
+	canModel.Init()
+	assignments, err := idassign.Assign(canModel, cfg)
+	if err != nil {
+		return err
+	}
+
+	if len(assignments) == 0 {
+		log.Print("all the messages have an id")
+		return nil
+	}
+
+	ids := make(map[string]uint32, len(assignments))
+	for _, a := range assignments {
+		deadline := "-"
+		if a.Deadline > 0 {
+			deadline = fmt.Sprintf("%d ms", a.Deadline)
+		}
+		fmt.Printf("%s: 0x%X (%d), deadline %s, range %s\n", a.Message, a.ID, a.ID, deadline, a.Range)
+		ids[a.Message] = a.ID
+	}
+
+	if dryRun {
+		return nil
+	}
+
+	output, err := pkg.InsertJSONMessageIDs(input, ids)
+	if err != nil {
+		return err
+	}
+
+	if outFileName == "" {
+		outFileName = modelFileName
+	}
+	if err := os.WriteFile(outFileName, output, 0644); err != nil {
+		return err
+	}
+
+	log.Printf("ASSIGNED %d ids into %s", len(assignments), outFileName)
+
+	return nil
+}
+
+// AssignIDsCmd represents the assign-ids command
+var AssignIDsCmd = &cobra.Command{
+	Use:   "assign-ids",
+	Short: "Assigns the ids of the messages of a JSON model that omit them",
+	Long: `Assigns the ids of the messages of a JSON model that omit them, keeping the existing ones.
+The messages are sorted by deadline (deadline_ms, cycle_time or period_ms), so the shorter deadlines get the lower ids.
+The ids are taken from the range of the message priority class, then from the range of its sender node,
+otherwise from the default range.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return assignIDs()
+	},
+}
+
+// init initializes the flags for the assign-ids command.
+func init() {
+	AssignIDsCmd.Flags().StringVarP(&modelFileName, "model", "m", "", "Sets the CAN model file (.json)")
+	if err := AssignIDsCmd.MarkFlagRequired("model"); err != nil {
+		log.Fatal(err)
+	}
+	if err := AssignIDsCmd.MarkFlagFilename("model", ".json"); err != nil {
+		log.Fatal(err)
+	}
+
+	AssignIDsCmd.Flags().StringVar(&outFileName, "out", "", "Sets the output file, if not set the model file is updated")
+	AssignIDsCmd.Flags().StringVar(&defaultRange, "range", idassign.DefaultRange.String(), "Sets the default range of the ids (from-to)")
+	AssignIDsCmd.Flags().StringSliceVar(&nodeRanges, "node-range", nil, "Sets the range of the ids of a sender node (node=from-to), the flag can be repeated")
+	AssignIDsCmd.Flags().StringSliceVar(&classRanges, "class-range", nil, "Sets the range of the ids of a priority class (class=from-to), the flag can be repeated")
+	AssignIDsCmd.Flags().Uint32Var(&step, "step", 1, "Sets the distance between the assigned ids, to leave room for future messages")
+	AssignIDsCmd.Flags().BoolVar(&dryRun, "dry-run", false, "Prints the assigned ids without writing them")
+}
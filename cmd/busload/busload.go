@@ -0,0 +1,142 @@
+// Package busload contains the busload command
+package busload
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+	"github.com/squadracorsepolito/jsondbc/pkg"
+	"github.com/squadracorsepolito/jsondbc/pkg/busload"
+	"github.com/squadracorsepolito/jsondbc/pkg/cantime"
+	"github.com/squadracorsepolito/jsondbc/pkg/codec"
+)
+
+var (
+	modelFileName string
+	bitrate       uint32
+	dataBitrate   uint32
+	threshold     float64
+)
+
+func formatPercent(load float64) string {
+	return fmt.Sprintf("%.2f%%", 100*load)
+}
+
+func formatBits(bits cantime.Bits) string {
+	if bits.Data == 0 {
+		return fmt.Sprint(bits.Nominal)
+	}
+	return fmt.Sprintf("%d+%d", bits.Nominal, bits.Data)
+}
+
+func formatNode(node string) string {
+	if len(node) == 0 {
+		return "-"
+	}
+	return node
+}
+
+// printReport prints the load of the messages, of the nodes and the total one.
+func printReport(r *busload.Report) error {
+	fmt.Printf("bitrate: %d bit/s", r.Bitrate.Nominal)
+	if r.Bitrate.Data != r.Bitrate.Nominal {
+		fmt.Printf(", data bitrate: %d bit/s", r.Bitrate.Data)
+	}
+	fmt.Print("\n\n")
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+
+	fmt.Fprintln(tw, "ID\tMESSAGE\tNODE\tLENGTH\tCYCLE (ms)\tBITS\tWORST-CASE BITS\tLOAD\tWORST-CASE LOAD")
+	for _, ml := range r.Messages {
+		msg := ml.Message
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%d\t%g\t%s\t%s\t%s\t%s\n",
+			msg.FormatID(), msg.Name, formatNode(msg.Model.Sender), msg.Length, float64(ml.CycleTime.Microseconds())/1000,
+			formatBits(ml.NominalBits), formatBits(ml.WorstCaseBits), formatPercent(ml.Load.Nominal), formatPercent(ml.Load.WorstCase))
+	}
+	fmt.Fprintln(tw)
+
+	fmt.Fprintln(tw, "NODE\tMESSAGES\tLOAD\tWORST-CASE LOAD")
+	for _, nl := range r.Nodes {
+		fmt.Fprintf(tw, "%s\t%d\t%s\t%s\n", formatNode(nl.Node), len(nl.Messages), formatPercent(nl.Load.Nominal), formatPercent(nl.Load.WorstCase))
+	}
+	fmt.Fprintf(tw, "TOTAL\t%d\t%s\t%s\n", len(r.Messages), formatPercent(r.Total.Nominal), formatPercent(r.Total.WorstCase))
+
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	for _, msg := range r.NonCyclic {
+		log.Printf("WARNING: message [%s] is not cyclic -> EXCLUDED from the bus load", msg.Name)
+	}
+
+	return nil
+}
+
+// computeBusload is the handler for the busload command.
+// It computes the load of the cyclic messages of the model and returns an error if the
+// worst-case total load exceeds the threshold.
+func computeBusload() error {
+	if threshold < 0 || threshold > 100 {
+		return fmt.Errorf("threshold %g%% must be between 0 and 100", threshold)
+	}
+
+	canModel, err := pkg.ReadCanModel(modelFileName)
+	if err != nil {
+		return err
+	}
+
+	c, err := codec.New(canModel)
+	if err != nil {
+		return err
+	}
+
+	nominal := canModel.Baudrate
+	if bitrate > 0 {
+		nominal = bitrate
+	}
+	if nominal == 0 {
+		log.Printf("WARNING: the model has no baudrate, using %d bit/s", cantime.DefaultBitrate)
+	}
+
+	report := busload.Compute(c, cantime.NewBitrate(nominal, dataBitrate))
+	if err := printReport(report); err != nil {
+		return err
+	}
+
+	if threshold > 0 && 100*report.Total.WorstCase > threshold {
+		return fmt.Errorf("worst-case bus load %s exceeds the threshold of %g%%", formatPercent(report.Total.WorstCase), threshold)
+	}
+
+	return nil
+}
+
+// BusloadCmd represents the busload command
+var BusloadCmd = &cobra.Command{
+	Use:   "busload",
+	Short: "Computes the bus load of the cyclic messages of a CAN model",
+	Long:  ``,
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		// the usage is not useful when the threshold is exceeded
+		cmd.SilenceUsage = true
+		return computeBusload()
+	},
+}
+
+// init initializes the flags for the busload command.
+func init() {
+	BusloadCmd.Flags().StringVarP(&modelFileName, "model", "m", "", "Sets the CAN model file (.json or .dbc)")
+	if err := BusloadCmd.MarkFlagRequired("model"); err != nil {
+		log.Fatal(err)
+	}
+	if err := BusloadCmd.MarkFlagFilename("model", ".json", ".dbc"); err != nil {
+		log.Fatal(err)
+	}
+
+	BusloadCmd.Flags().Uint32Var(&bitrate, "bitrate", 0, "Sets the nominal bitrate in bit/s, if not set it is taken from the baudrate of the model")
+	BusloadCmd.Flags().Uint32Var(&dataBitrate, "data-bitrate", 0, "Sets the data bitrate in bit/s of the CAN FD frames, if not set the frames do not switch the bitrate")
+	BusloadCmd.Flags().Float64Var(&threshold, "threshold", 0, "Sets the maximum worst-case bus load in percent, the command fails above it (0 disables the check)")
+}
@@ -15,6 +15,8 @@ var (
 	extension   string
 	inFileName  string
 	outFileName string
+	emitLayout  bool
+	strict      bool
 )
 
 const (
@@ -34,7 +36,9 @@ func convert() error {
 	inExt := filepath.Ext(inFileName)
 	switch inExt {
 	case jsonExt:
-		reader = pkg.NewJsonReader()
+		jsonReader := pkg.NewJsonReader()
+		jsonReader.Strict = strict
+		reader = jsonReader
 	case dbcExt:
 		reader = pkg.NewDBCReader()
 
@@ -42,6 +46,10 @@ func convert() error {
 		return fmt.Errorf("%s extension is not supported as input file", inExt)
 	}
 
+	if emitLayout && inExt != jsonExt {
+		return fmt.Errorf("the layout can be emitted only for json models")
+	}
+
 	if outFileName == "" {
 		outFileName = inFileName[:len(inFileName)-len(inExt)] + extension
 	}
@@ -68,8 +76,12 @@ func convert() error {
 	}
 
 	canModel.Init()
-	if err := canModel.Validate(); err != nil {
-		return err
+	diags := canModel.Diagnose()
+	for _, diag := range diags {
+		log.Print(diag)
+	}
+	if errs := diags.Errors(); len(errs) > 0 {
+		return fmt.Errorf("the model is not valid, errors found: %d", len(errs))
 	}
 
 	outFile, err := os.Create(outFileName)
@@ -84,6 +96,36 @@ func convert() error {
 
 	log.Print("CONVERTION COMPLETED")
 
+	if emitLayout {
+		return writeLayout(canModel)
+	}
+
+	return nil
+}
+
+// writeLayout writes the start bits of the signals placed by the layout into the input file.
+func writeLayout(canModel *pkg.CanModel) error {
+	if canModel.PlacedSignals() == 0 {
+		log.Print("all the signals have a start bit")
+		return nil
+	}
+
+	input, err := os.ReadFile(inFileName)
+	if err != nil {
+		return err
+	}
+
+	output, err := pkg.InsertJSONStartBits(input, canModel)
+	if err != nil {
+		return err
+	}
+
+	if err := os.WriteFile(inFileName, output, 0644); err != nil {
+		return err
+	}
+
+	log.Printf("EMITTED %d start bits into %s", canModel.PlacedSignals(), inFileName)
+
 	return nil
 }
 
@@ -93,6 +135,8 @@ var ConvertCmd = &cobra.Command{
 	Short: "Converts the CAM model defined in the input file to the specified extension",
 	Long:  ``,
 	RunE: func(cmd *cobra.Command, args []string) error {
+		// the usage is not useful when the model is not valid
+		cmd.SilenceUsage = true
 		return convert()
 	},
 }
@@ -113,4 +157,7 @@ func init() {
 	if err := ConvertCmd.MarkFlagFilename("out", validOutExt...); err != nil {
 		log.Fatal(err)
 	}
+
+	ConvertCmd.Flags().BoolVar(&strict, "strict", true, "Rejects the unknown fields of the json model, it can be disabled with --strict=false")
+	ConvertCmd.Flags().BoolVar(&emitLayout, "emit-layout", false, "Writes the start bits of the signals placed automatically into the input json model")
 }
@@ -0,0 +1,181 @@
+// Package decode contains the decode command
+package decode
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/squadracorsepolito/jsondbc/pkg"
+	"github.com/squadracorsepolito/jsondbc/pkg/canlog"
+	"github.com/squadracorsepolito/jsondbc/pkg/codec"
+)
+
+var (
+	modelFileName string
+	inFileName    string
+	inFormat      string
+	outFileName   string
+	outFormat     string
+
+	csvColumns   string
+	csvSeparator string
+	csvSkipLines int
+	csvTimeUnit  string
+)
+
+const (
+	formatText  = "text"
+	formatJSONL = "jsonl"
+	formatCSV   = "csv"
+	formatASC   = "asc"
+	formatMF4   = "mf4"
+)
+
+var validOutFormats = []string{formatText, formatJSONL, formatCSV, formatASC, formatMF4}
+
+// decode is the handler for the decode command.
+// It reads the model and the trace, then it writes the decoded frames into the output.
+func decode() error {
+	canModel, err := pkg.ReadCanModel(modelFileName)
+	if err != nil {
+		return err
+	}
+
+	c, err := codec.New(canModel)
+	if err != nil {
+		return err
+	}
+
+	format := canlog.Format(inFormat)
+	if inFormat == "" {
+		format, err = canlog.FormatFromFileName(inFileName)
+		if err != nil {
+			return err
+		}
+	}
+
+	inFile, err := os.Open(inFileName)
+	if err != nil {
+		return err
+	}
+	defer inFile.Close()
+
+	var reader canlog.Reader
+	if format == canlog.FormatCSV {
+		config, err := canlog.ParseCSVConfig(csvColumns, csvSeparator, csvSkipLines, csvTimeUnit)
+		if err != nil {
+			return err
+		}
+		reader = canlog.NewCSVReader(inFile, config)
+	} else {
+		reader, err = canlog.NewReader(format, inFile)
+		if err != nil {
+			return err
+		}
+	}
+
+	out := os.Stdout
+	if outFileName != "" {
+		outFile, err := os.Create(outFileName)
+		if err != nil {
+			return err
+		}
+		defer outFile.Close()
+		out = outFile
+	}
+	bufOut := bufio.NewWriter(out)
+	defer bufOut.Flush()
+
+	var writer frameWriter
+	switch outFormat {
+	case formatText:
+		writer = newTextWriter(bufOut)
+	case formatJSONL:
+		writer = newJSONLWriter(bufOut)
+	case formatCSV:
+		writer = newCSVWriter(bufOut)
+	case formatASC:
+		writer = newASCWriter(bufOut)
+	case formatMF4:
+		// the MDF header is completed at the end, so the output must be a file
+		if outFileName == "" {
+			return fmt.Errorf("%s output format requires an output file", outFormat)
+		}
+		writer = newMF4Writer(out, c)
+	default:
+		return fmt.Errorf("%s output format is not supported, valid are %v", outFormat, validOutFormats)
+	}
+
+	for {
+		frame, err := reader.Read()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return err
+		}
+
+		if frame.Error || frame.Remote {
+			continue
+		}
+
+		msg, ok := c.MessageByFrame(frame.ID, frame.Extended)
+		if !ok {
+			continue
+		}
+
+		values, err := msg.Decode(frame.Data)
+		if err != nil {
+			log.Printf("WARNING: %v; frame %s at %s -> SKIPPED", err, frame.FormatID(), formatTimestamp(frame))
+			continue
+		}
+
+		if err := writer.writeFrame(frame, msg, values); err != nil {
+			return err
+		}
+	}
+
+	return writer.flush()
+}
+
+// DecodeCmd represents the decode command
+var DecodeCmd = &cobra.Command{
+	Use:   "decode",
+	Short: "Decodes the frames of a CAN trace with the CAN model",
+	Long:  ``,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return decode()
+	},
+}
+
+// init initializes the flags for the decode command.
+func init() {
+	DecodeCmd.Flags().StringVarP(&modelFileName, "model", "m", "", "Sets the CAN model file (.json or .dbc)")
+	if err := DecodeCmd.MarkFlagFilename("model", ".json", ".dbc"); err != nil {
+		log.Fatal(err)
+	}
+	if err := DecodeCmd.MarkFlagRequired("model"); err != nil {
+		log.Fatal(err)
+	}
+
+	DecodeCmd.Flags().StringVar(&inFileName, "in", "", "Sets the trace file")
+	if err := DecodeCmd.MarkFlagRequired("in"); err != nil {
+		log.Fatal(err)
+	}
+
+	DecodeCmd.Flags().StringVar(&inFormat, "in-format", "", "Sets the trace format, if not set it is taken from the trace extension")
+
+	DecodeCmd.Flags().StringVar(&csvColumns, "csv-columns", "time,channel,id,dir,dlc,data", "Sets the columns of csv traces (time, channel, id, dir, dlc, data, name or - to skip a column)")
+	DecodeCmd.Flags().StringVar(&csvSeparator, "csv-separator", ",", "Sets the field separator of csv traces, a space splits the fields by any white space")
+	DecodeCmd.Flags().IntVar(&csvSkipLines, "csv-skip-lines", 1, "Sets the number of header lines to skip in csv traces")
+	DecodeCmd.Flags().StringVar(&csvTimeUnit, "csv-time-unit", "s", "Sets the timestamp unit of csv traces (s, ms, us or ns)")
+
+	DecodeCmd.Flags().StringVar(&outFileName, "out", "", "Sets the output file, if not set the standard output is used")
+
+	DecodeCmd.Flags().StringVarP(&outFormat, "format", "f", formatText, fmt.Sprintf("Sets the output format %v", validOutFormats))
+}
@@ -0,0 +1,189 @@
+package decode
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/squadracorsepolito/jsondbc/pkg/canlog"
+	"github.com/squadracorsepolito/jsondbc/pkg/codec"
+	"github.com/squadracorsepolito/jsondbc/pkg/mdf"
+)
+
+type frameWriter interface {
+	writeFrame(frame *canlog.Frame, msg *codec.Message, values []*codec.SignalValue) error
+	flush() error
+}
+
+// formatTimestamp returns the timestamp of the frame in seconds with microsecond precision.
+func formatTimestamp(frame *canlog.Frame) string {
+	return fmt.Sprintf("%d.%06d", frame.Timestamp.Unix(), frame.Timestamp.Nanosecond()/1000)
+}
+
+type textWriter struct {
+	w io.Writer
+}
+
+func newTextWriter(w io.Writer) *textWriter {
+	return &textWriter{w: w}
+}
+
+func (tw *textWriter) writeFrame(frame *canlog.Frame, msg *codec.Message, values []*codec.SignalValue) error {
+	if _, err := fmt.Fprintf(tw.w, "(%s) %s %s (0x%s)\n", formatTimestamp(frame), frame.InterfaceName(), msg.Name, frame.FormatID()); err != nil {
+		return err
+	}
+
+	for _, val := range values {
+		str := val.FormatValue()
+		if unit := val.Signal.Model.Unit; len(unit) > 0 {
+			str += " " + unit
+		}
+		if val.HasLabel() {
+			str = fmt.Sprintf("%s (%s)", val.Label, str)
+		}
+
+		if _, err := fmt.Fprintf(tw.w, "\t%s: %s\n", val.Signal.Name, str); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (tw *textWriter) flush() error {
+	return nil
+}
+
+type jsonlSignal struct {
+	Raw   uint64      `json:"raw"`
+	Value json.Number `json:"value"`
+	Label string      `json:"label,omitempty"`
+	Unit  string      `json:"unit,omitempty"`
+}
+
+type jsonlFrame struct {
+	Timestamp json.Number             `json:"timestamp"`
+	Channel   string                  `json:"channel"`
+	ID        string                  `json:"id"`
+	Message   string                  `json:"message"`
+	Signals   map[string]*jsonlSignal `json:"signals"`
+}
+
+type jsonlWriter struct {
+	enc *json.Encoder
+}
+
+func newJSONLWriter(w io.Writer) *jsonlWriter {
+	return &jsonlWriter{enc: json.NewEncoder(w)}
+}
+
+func (jw *jsonlWriter) writeFrame(frame *canlog.Frame, msg *codec.Message, values []*codec.SignalValue) error {
+	jFrame := &jsonlFrame{
+		Timestamp: json.Number(formatTimestamp(frame)),
+		Channel:   frame.InterfaceName(),
+		ID:        frame.FormatID(),
+		Message:   msg.Name,
+		Signals:   make(map[string]*jsonlSignal, len(values)),
+	}
+
+	for _, val := range values {
+		jFrame.Signals[val.Signal.Name] = &jsonlSignal{
+			Raw:   val.Raw,
+			Value: json.Number(val.FormatValue()),
+			Label: val.Label,
+			Unit:  val.Signal.Model.Unit,
+		}
+	}
+
+	return jw.enc.Encode(jFrame)
+}
+
+func (jw *jsonlWriter) flush() error {
+	return nil
+}
+
+var csvHeader = []string{"timestamp", "channel", "id", "message", "signal", "raw", "value", "label", "unit"}
+
+type csvWriter struct {
+	w           *csv.Writer
+	writeHeader bool
+}
+
+func newCSVWriter(w io.Writer) *csvWriter {
+	return &csvWriter{
+		w:           csv.NewWriter(w),
+		writeHeader: true,
+	}
+}
+
+func (cw *csvWriter) writeFrame(frame *canlog.Frame, msg *codec.Message, values []*codec.SignalValue) error {
+	if cw.writeHeader {
+		if err := cw.w.Write(csvHeader); err != nil {
+			return err
+		}
+		cw.writeHeader = false
+	}
+
+	timestamp := formatTimestamp(frame)
+	for _, val := range values {
+		record := []string{
+			timestamp,
+			frame.InterfaceName(),
+			frame.FormatID(),
+			msg.Name,
+			val.Signal.Name,
+			strconv.FormatUint(val.Raw, 10),
+			val.FormatValue(),
+			val.Label,
+			val.Signal.Model.Unit,
+		}
+		if err := cw.w.Write(record); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (cw *csvWriter) flush() error {
+	cw.w.Flush()
+	return cw.w.Error()
+}
+
+// ascWriter writes the decoded frames as a raw ASC trace,
+// so that the frames of the model can be opened in CANalyzer/CANoe.
+type ascWriter struct {
+	w *canlog.ASCWriter
+}
+
+func newASCWriter(w io.Writer) *ascWriter {
+	return &ascWriter{w: canlog.NewASCWriter(w)}
+}
+
+func (aw *ascWriter) writeFrame(frame *canlog.Frame, _ *codec.Message, _ []*codec.SignalValue) error {
+	return aw.w.Write(frame)
+}
+
+func (aw *ascWriter) flush() error {
+	return aw.w.Close()
+}
+
+// mf4Writer writes the decoded signals into an MDF4 file,
+// with a channel group for each message.
+type mf4Writer struct {
+	w *mdf.Writer
+}
+
+func newMF4Writer(w io.WriteSeeker, c *codec.Codec) *mf4Writer {
+	return &mf4Writer{w: mdf.NewWriter(w, c)}
+}
+
+func (mw *mf4Writer) writeFrame(frame *canlog.Frame, msg *codec.Message, values []*codec.SignalValue) error {
+	return mw.w.Write(frame.Timestamp, msg, values)
+}
+
+func (mw *mf4Writer) flush() error {
+	return mw.w.Close()
+}
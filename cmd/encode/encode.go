@@ -0,0 +1,157 @@
+// Package encode contains the encode command
+package encode
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/squadracorsepolito/jsondbc/pkg"
+	"github.com/squadracorsepolito/jsondbc/pkg/canlog"
+	"github.com/squadracorsepolito/jsondbc/pkg/codec"
+)
+
+var (
+	modelFileName string
+	outFormat     string
+)
+
+const (
+	formatAll     = "all"
+	formatHex     = "hex"
+	formatCansend = "cansend"
+	formatC       = "c"
+)
+
+var validOutFormats = []string{formatAll, formatHex, formatCansend, formatC}
+
+// parseSignalValue parses a signal=value pair, where value is a physical value or an enum label.
+func parseSignalValue(msg *codec.Message, pair string) (string, float64, error) {
+	sigName, strValue, ok := strings.Cut(pair, "=")
+	if !ok {
+		return "", 0, fmt.Errorf("invalid signal value %q, expected signal=value", pair)
+	}
+
+	sig, ok := msg.Signal(sigName)
+	if !ok {
+		return "", 0, fmt.Errorf("message [%s] has no signal [%s]", msg.Name, sigName)
+	}
+
+	if value, err := strconv.ParseFloat(strValue, 64); err == nil {
+		return sigName, value, nil
+	}
+
+	value, ok := sig.LabelValue(strValue)
+	if !ok {
+		labels := make([]string, 0, len(sig.Model.Enum))
+		for label := range sig.Model.Enum {
+			labels = append(labels, label)
+		}
+		sort.Strings(labels)
+		return "", 0, fmt.Errorf("signal [%s] value %q is neither a number nor an enum label, valid labels are %v", sigName, strValue, labels)
+	}
+
+	return sigName, value, nil
+}
+
+func formatHexPayload(data []byte) string {
+	bytes := make([]string, len(data))
+	for i, b := range data {
+		bytes[i] = fmt.Sprintf("%02X", b)
+	}
+	return strings.Join(bytes, " ")
+}
+
+func formatCArray(data []byte) string {
+	bytes := make([]string, len(data))
+	for i, b := range data {
+		bytes[i] = fmt.Sprintf("0x%02X", b)
+	}
+	return "{ " + strings.Join(bytes, ", ") + " }"
+}
+
+// encode is the handler for the encode command.
+// It packs the given signal values into the payload of the message and prints it.
+func encode(msgName string, pairs []string) error {
+	canModel, err := pkg.ReadCanModel(modelFileName)
+	if err != nil {
+		return err
+	}
+
+	c, err := codec.New(canModel)
+	if err != nil {
+		return err
+	}
+
+	msg, ok := c.MessageByName(msgName)
+	if !ok {
+		return fmt.Errorf("message [%s] is not defined", msgName)
+	}
+
+	values := make(map[string]float64, len(pairs))
+	for _, pair := range pairs {
+		sigName, value, err := parseSignalValue(msg, pair)
+		if err != nil {
+			return err
+		}
+		values[sigName] = value
+	}
+
+	data, err := msg.Encode(values)
+	if err != nil {
+		return err
+	}
+
+	frame := &canlog.Frame{
+		ID:       msg.FrameID(),
+		Extended: msg.IsExtended(),
+		FD:       len(data) > canlog.MaxClassicLength,
+		DLC:      canlog.LengthToDLC(len(data)),
+		Data:     data,
+	}
+
+	switch outFormat {
+	case formatAll:
+		fmt.Printf("hex:     %s\n", formatHexPayload(data))
+		fmt.Printf("cansend: %s\n", canlog.FormatCandumpFrame(frame))
+		fmt.Printf("c:       %s\n", formatCArray(data))
+	case formatHex:
+		fmt.Println(formatHexPayload(data))
+	case formatCansend:
+		fmt.Println(canlog.FormatCandumpFrame(frame))
+	case formatC:
+		fmt.Println(formatCArray(data))
+	default:
+		return fmt.Errorf("%s output format is not supported, valid are %v", outFormat, validOutFormats)
+	}
+
+	return nil
+}
+
+// EncodeCmd represents the encode command
+var EncodeCmd = &cobra.Command{
+	Use:   "encode MESSAGE [SIGNAL=VALUE...]",
+	Short: "Encodes the signal values into the payload of a message",
+	Long: `Encodes the signal values into the payload of a message.
+Values are physical values or enum labels, missing signals are set to their start value.`,
+	Args: cobra.MinimumNArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return encode(args[0], args[1:])
+	},
+}
+
+// init initializes the flags for the encode command.
+func init() {
+	EncodeCmd.Flags().StringVarP(&modelFileName, "model", "m", "", "Sets the CAN model file (.json or .dbc)")
+	if err := EncodeCmd.MarkFlagFilename("model", ".json", ".dbc"); err != nil {
+		log.Fatal(err)
+	}
+	if err := EncodeCmd.MarkFlagRequired("model"); err != nil {
+		log.Fatal(err)
+	}
+
+	EncodeCmd.Flags().StringVarP(&outFormat, "format", "f", formatAll, fmt.Sprintf("Sets the output format %v", validOutFormats))
+}
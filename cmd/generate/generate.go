@@ -0,0 +1,101 @@
+// Package generate contains the generate command
+package generate
+
+import (
+	"log"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"github.com/squadracorsepolito/jsondbc/pkg"
+	"github.com/squadracorsepolito/jsondbc/pkg/codegen"
+)
+
+var (
+	modelFileName string
+	outDir        string
+	name          string
+)
+
+type generatorFunc func(m *codegen.Model) ([]*codegen.File, error)
+
+// generate is the handler of the generate subcommands.
+// It reads the CAN model, generates the source files and writes them into the output directory.
+func generate(generator generatorFunc) error {
+	canModel, err := pkg.ReadCanModel(modelFileName)
+	if err != nil {
+		return err
+	}
+
+	if name == "" {
+		baseName := filepath.Base(modelFileName)
+		name = strings.TrimSuffix(baseName, filepath.Ext(baseName))
+	}
+
+	m, err := codegen.NewModel(name, canModel)
+	if err != nil {
+		return err
+	}
+
+	files, err := generator(m)
+	if err != nil {
+		return err
+	}
+
+	if err := os.MkdirAll(outDir, 0755); err != nil {
+		return err
+	}
+
+	for _, file := range files {
+		fileName := filepath.Join(outDir, file.Name)
+		if err := os.WriteFile(fileName, file.Content, 0644); err != nil {
+			return err
+		}
+		log.Printf("GENERATED %s", fileName)
+	}
+
+	return nil
+}
+
+func newGenerateSubCmd(use, short string, generator generatorFunc) *cobra.Command {
+	return &cobra.Command{
+		Use:   use,
+		Short: short,
+		Long:  ``,
+		Args:  cobra.NoArgs,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return generate(generator)
+		},
+	}
+}
+
+// GenerateCmd represents the generate command
+var GenerateCmd = &cobra.Command{
+	Use:   "generate",
+	Short: "Generates the source code to pack and unpack the messages of the CAN model",
+	Long:  ``,
+}
+
+// init initializes the flags and the subcommands of the generate command.
+func init() {
+	GenerateCmd.PersistentFlags().StringVarP(&modelFileName, "model", "m", "", "Sets the CAN model file (.json or .dbc)")
+	if err := GenerateCmd.MarkPersistentFlagFilename("model", ".json", ".dbc"); err != nil {
+		log.Fatal(err)
+	}
+	if err := GenerateCmd.MarkPersistentFlagRequired("model"); err != nil {
+		log.Fatal(err)
+	}
+
+	GenerateCmd.PersistentFlags().StringVar(&outDir, "out-dir", ".", "Sets the output directory")
+	if err := GenerateCmd.MarkPersistentFlagDirname("out-dir"); err != nil {
+		log.Fatal(err)
+	}
+
+	GenerateCmd.PersistentFlags().StringVar(&name, "name", "", "Sets the name used as prefix of the generated code (default the model file name)")
+
+	GenerateCmd.AddCommand(newGenerateSubCmd("c", "Generates a C header and source file", codegen.GenerateC))
+	GenerateCmd.AddCommand(newGenerateSubCmd("go", "Generates a Go package with a struct for each message", codegen.GenerateGo))
+	GenerateCmd.AddCommand(newGenerateSubCmd("rust", "Generates a no_std Rust module with a struct for each message", codegen.GenerateRust))
+	GenerateCmd.AddCommand(newGenerateSubCmd("python", "Generates a dependency-free Python module with a dataclass for each message", codegen.GeneratePython))
+}
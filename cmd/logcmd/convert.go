@@ -0,0 +1,189 @@
+package logcmd
+
+import (
+	"errors"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/spf13/cobra"
+	"github.com/squadracorsepolito/jsondbc/pkg"
+	"github.com/squadracorsepolito/jsondbc/pkg/canlog"
+	"github.com/squadracorsepolito/jsondbc/pkg/codec"
+)
+
+var (
+	modelFileName string
+	inFileName    string
+	inFormat      string
+	outFileName   string
+	outFormat     string
+
+	csvColumns   string
+	csvSeparator string
+	csvSkipLines int
+	csvTimeUnit  string
+)
+
+// namedFormats are the output formats able to store the frame names.
+var namedFormats = []canlog.Format{canlog.FormatASC, canlog.FormatCSV}
+
+func getFormat(format, fileName string) (canlog.Format, error) {
+	if format != "" {
+		return canlog.Format(format), nil
+	}
+	return canlog.FormatFromFileName(fileName)
+}
+
+// convertLog is the handler for the log convert command.
+// It reads the frames of the input trace, optionally names them with the messages of the model,
+// then it writes them into the output trace.
+func convertLog() error {
+	var c *codec.Codec
+	if modelFileName != "" {
+		canModel, err := pkg.ReadCanModel(modelFileName)
+		if err != nil {
+			return err
+		}
+
+		c, err = codec.New(canModel)
+		if err != nil {
+			return err
+		}
+	}
+
+	inFmt, err := getFormat(inFormat, inFileName)
+	if err != nil {
+		return err
+	}
+	outFmt, err := getFormat(outFormat, outFileName)
+	if err != nil {
+		return err
+	}
+
+	if filepath.Clean(inFileName) == filepath.Clean(outFileName) {
+		return errors.New("input and output traces cannot be the same file")
+	}
+
+	var csvConfig *canlog.CSVConfig
+	if inFmt == canlog.FormatCSV || outFmt == canlog.FormatCSV {
+		csvConfig, err = canlog.ParseCSVConfig(csvColumns, csvSeparator, csvSkipLines, csvTimeUnit)
+		if err != nil {
+			return err
+		}
+	}
+
+	if c != nil && !slices.Contains(namedFormats, outFmt) {
+		log.Printf("WARNING: %s format cannot store the message names -> IGNORED", outFmt)
+	}
+
+	inFile, err := os.Open(inFileName)
+	if err != nil {
+		return err
+	}
+	defer inFile.Close()
+
+	var reader canlog.Reader
+	if inFmt == canlog.FormatCSV {
+		reader = canlog.NewCSVReader(inFile, csvConfig)
+	} else {
+		reader, err = canlog.NewReader(inFmt, inFile)
+		if err != nil {
+			return err
+		}
+	}
+
+	outFile, err := os.Create(outFileName)
+	if err != nil {
+		return err
+	}
+	defer outFile.Close()
+
+	var writer canlog.Writer
+	if outFmt == canlog.FormatCSV {
+		writer = canlog.NewCSVWriter(outFile, csvConfig)
+	} else {
+		writer, err = canlog.NewWriter(outFmt, outFile)
+		if err != nil {
+			return err
+		}
+	}
+
+	count, skipped := 0, 0
+	for {
+		frame, err := reader.Read()
+		if err != nil {
+			if errors.Is(err, io.EOF) {
+				break
+			}
+			return err
+		}
+
+		if c != nil && !frame.Error {
+			if msg, ok := c.MessageByFrame(frame.ID, frame.Extended); ok {
+				frame.Name = msg.Name
+			}
+		}
+
+		if err := writer.Write(frame); err != nil {
+			// the frames that the output format cannot represent (e.g. remote frames in csv) are skipped
+			if errors.Is(err, canlog.ErrUnsupportedFrame) {
+				skipped++
+				continue
+			}
+			return err
+		}
+		count++
+	}
+
+	if err := writer.Close(); err != nil {
+		return err
+	}
+
+	log.Printf("CONVERTED %d frames from %s to %s", count, inFileName, outFileName)
+	if skipped > 0 {
+		log.Printf("WARNING: %d frames are not supported by the %s format -> SKIPPED", skipped, outFmt)
+	}
+
+	return nil
+}
+
+// convertCmd represents the log convert command
+var convertCmd = &cobra.Command{
+	Use:   "convert",
+	Short: "Converts a CAN trace into another format, optionally naming the frames with a CAN model",
+	Long:  ``,
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return convertLog()
+	},
+}
+
+// init initializes the flags for the log convert command.
+func init() {
+	convertCmd.Flags().StringVarP(&modelFileName, "model", "m", "", "Sets the CAN model file (.json or .dbc) used to name the frames")
+	if err := convertCmd.MarkFlagFilename("model", ".json", ".dbc"); err != nil {
+		log.Fatal(err)
+	}
+
+	convertCmd.Flags().StringVar(&inFileName, "in", "", "Sets the input trace file")
+	if err := convertCmd.MarkFlagRequired("in"); err != nil {
+		log.Fatal(err)
+	}
+
+	convertCmd.Flags().StringVar(&inFormat, "in-format", "", "Sets the input trace format, if not set it is taken from the file extension")
+
+	convertCmd.Flags().StringVar(&outFileName, "out", "", "Sets the output trace file")
+	if err := convertCmd.MarkFlagRequired("out"); err != nil {
+		log.Fatal(err)
+	}
+
+	convertCmd.Flags().StringVar(&outFormat, "out-format", "", "Sets the output trace format, if not set it is taken from the file extension")
+
+	convertCmd.Flags().StringVar(&csvColumns, "csv-columns", "time,channel,id,dir,dlc,data", "Sets the columns of csv traces (time, channel, id, dir, dlc, data, name or - to skip a column)")
+	convertCmd.Flags().StringVar(&csvSeparator, "csv-separator", ",", "Sets the field separator of csv traces, a space splits the fields by any white space")
+	convertCmd.Flags().IntVar(&csvSkipLines, "csv-skip-lines", 1, "Sets the number of header lines of csv traces")
+	convertCmd.Flags().StringVar(&csvTimeUnit, "csv-time-unit", "s", "Sets the timestamp unit of csv traces (s, ms, us or ns)")
+}
@@ -0,0 +1,18 @@
+// Package logcmd contains the log command
+package logcmd
+
+import (
+	"github.com/spf13/cobra"
+)
+
+// LogCmd represents the log command
+var LogCmd = &cobra.Command{
+	Use:   "log",
+	Short: "Handles the CAN trace files",
+	Long:  ``,
+}
+
+// init initializes the subcommands of the log command.
+func init() {
+	LogCmd.AddCommand(convertCmd)
+}
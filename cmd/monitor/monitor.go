@@ -0,0 +1,132 @@
+// Package monitor contains the monitor command
+package monitor
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/squadracorsepolito/jsondbc/pkg"
+	"github.com/squadracorsepolito/jsondbc/pkg/canlog"
+	"github.com/squadracorsepolito/jsondbc/pkg/codec"
+	"github.com/squadracorsepolito/jsondbc/pkg/socketcan"
+)
+
+var (
+	ifaceName     string
+	modelFileName string
+	refreshPeriod time.Duration
+)
+
+// clearScreen moves the cursor to the top left corner and clears the terminal.
+const clearScreen = "\033[H\033[2J"
+
+// monitorBus is the handler for the monitor command.
+// It receives the frames of a SocketCAN interface and shows a table, refreshed periodically,
+// with the last decoded values, the rate and the cycle time deviation of each message.
+func monitorBus() error {
+	if refreshPeriod <= 0 {
+		return fmt.Errorf("refresh period must be positive")
+	}
+
+	canModel, err := pkg.ReadCanModel(modelFileName)
+	if err != nil {
+		return err
+	}
+
+	c, err := codec.New(canModel)
+	if err != nil {
+		return err
+	}
+
+	conn, err := socketcan.Dial(ifaceName)
+	if err != nil {
+		return err
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	frames := make(chan *canlog.Frame, 1024)
+	readErr := make(chan error, 1)
+	go func() {
+		for {
+			frame, err := conn.Read()
+			if err != nil {
+				readErr <- err
+				return
+			}
+			frames <- frame
+		}
+	}()
+
+	t := newTable(c, ifaceName)
+	ticker := time.NewTicker(refreshPeriod)
+	defer ticker.Stop()
+
+	var screen bytes.Buffer
+	for {
+		select {
+		case frame := <-frames:
+			t.update(frame)
+
+		case now := <-ticker.C:
+			t.refresh(now)
+
+			screen.Reset()
+			screen.WriteString(clearScreen)
+			if err := t.render(&screen); err != nil {
+				return err
+			}
+			if _, err := screen.WriteTo(os.Stdout); err != nil {
+				return err
+			}
+
+		case err := <-readErr:
+			conn.Close()
+			return fmt.Errorf("interface %s: %w", ifaceName, err)
+
+		case <-ctx.Done():
+			// closing the socket unblocks the reading goroutine
+			if err := conn.Close(); err != nil && !errors.Is(err, os.ErrClosed) {
+				return err
+			}
+			return nil
+		}
+	}
+}
+
+// MonitorCmd represents the monitor command
+var MonitorCmd = &cobra.Command{
+	Use:   "monitor",
+	Short: "Monitors a SocketCAN interface decoding the frames with a CAN model",
+	Long:  ``,
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return monitorBus()
+	},
+}
+
+// init initializes the flags for the monitor command.
+func init() {
+	MonitorCmd.Flags().StringVar(&ifaceName, "iface", "", "Sets the SocketCAN interface to monitor (e.g. can0 or vcan0)")
+	if err := MonitorCmd.MarkFlagRequired("iface"); err != nil {
+		log.Fatal(err)
+	}
+
+	MonitorCmd.Flags().StringVarP(&modelFileName, "model", "m", "", "Sets the CAN model file (.json or .dbc) used to decode the frames")
+	if err := MonitorCmd.MarkFlagRequired("model"); err != nil {
+		log.Fatal(err)
+	}
+	if err := MonitorCmd.MarkFlagFilename("model", ".json", ".dbc"); err != nil {
+		log.Fatal(err)
+	}
+
+	MonitorCmd.Flags().DurationVar(&refreshPeriod, "refresh", 500*time.Millisecond, "Sets the refresh period of the table")
+}
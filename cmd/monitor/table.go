@@ -0,0 +1,187 @@
+package monitor
+
+import (
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/squadracorsepolito/jsondbc/pkg/canlog"
+	"github.com/squadracorsepolito/jsondbc/pkg/codec"
+)
+
+// cycleSmoothing is the weight of the last interval in the average cycle time.
+const cycleSmoothing = 0.2
+
+// messageStats contains the statistics and the last values of a received message.
+type messageStats struct {
+	msg *codec.Message
+
+	count     uint64
+	prevCount uint64
+	rate      float64
+
+	lastTimestamp time.Time
+	avgCycle      time.Duration
+
+	values []*codec.SignalValue
+	err    error
+}
+
+// update updates the statistics with a received frame.
+func (s *messageStats) update(frame *canlog.Frame) {
+	if s.count > 0 {
+		interval := frame.Timestamp.Sub(s.lastTimestamp)
+		if s.avgCycle == 0 {
+			s.avgCycle = interval
+		} else {
+			s.avgCycle += time.Duration(cycleSmoothing * float64(interval-s.avgCycle))
+		}
+	}
+
+	s.count++
+	s.lastTimestamp = frame.Timestamp
+
+	// remote frames have no payload, the last values are kept
+	if !frame.Remote {
+		s.values, s.err = s.msg.Decode(frame.Data)
+	}
+}
+
+// deviation returns the deviation of the average cycle time from the one of the model in percent.
+func (s *messageStats) deviation() (float64, bool) {
+	expected := s.msg.CycleTime()
+	if expected == 0 || s.avgCycle == 0 {
+		return 0, false
+	}
+	return 100 * float64(s.avgCycle-expected) / float64(expected), true
+}
+
+// table contains the statistics of the messages received on the bus.
+type table struct {
+	c     *codec.Codec
+	iface string
+
+	stats      map[*codec.Message]*messageStats
+	order      []*messageStats
+	unknownIDs map[string]uint64
+
+	totalFrames uint64
+	prevFrames  uint64
+	totalRate   float64
+	errorFrames uint64
+	lastRefresh time.Time
+}
+
+func newTable(c *codec.Codec, iface string) *table {
+	t := &table{
+		c:     c,
+		iface: iface,
+
+		stats:       make(map[*codec.Message]*messageStats),
+		order:       []*messageStats{},
+		unknownIDs:  make(map[string]uint64),
+		lastRefresh: time.Now(),
+	}
+
+	// the messages are shown in the order of their ids
+	for _, msg := range c.Messages() {
+		s := &messageStats{msg: msg}
+		t.stats[msg] = s
+		t.order = append(t.order, s)
+	}
+
+	return t
+}
+
+// update updates the table with a received frame.
+func (t *table) update(frame *canlog.Frame) {
+	t.totalFrames++
+
+	if frame.Error {
+		t.errorFrames++
+		return
+	}
+
+	msg, ok := t.c.MessageByFrame(frame.ID, frame.Extended)
+	if !ok {
+		t.unknownIDs[frame.FormatID()]++
+		return
+	}
+
+	t.stats[msg].update(frame)
+}
+
+// refresh updates the rates with the frames received since the last refresh.
+func (t *table) refresh(now time.Time) {
+	elapsed := now.Sub(t.lastRefresh).Seconds()
+	t.lastRefresh = now
+	if elapsed <= 0 {
+		return
+	}
+
+	for _, s := range t.order {
+		s.rate = float64(s.count-s.prevCount) / elapsed
+		s.prevCount = s.count
+	}
+	t.totalRate = float64(t.totalFrames-t.prevFrames) / elapsed
+	t.prevFrames = t.totalFrames
+}
+
+func formatMs(d time.Duration) string {
+	return fmt.Sprintf("%.1f", float64(d)/float64(time.Millisecond))
+}
+
+func formatSignalValue(val *codec.SignalValue) string {
+	str := val.FormatValue()
+	if unit := val.Signal.Model.Unit; len(unit) > 0 {
+		str += " " + unit
+	}
+	if val.HasLabel() {
+		str = fmt.Sprintf("%s (%s)", val.Label, str)
+	}
+	return fmt.Sprintf("%s=%s", val.Signal.Name, str)
+}
+
+// render writes the table, only the received messages are shown.
+func (t *table) render(w io.Writer) error {
+	fmt.Fprintf(w, "%s  %s  frames: %d  rate: %.0f fr/s  errors: %d  unknown ids: %d\n\n",
+		time.Now().Format("15:04:05"), t.iface, t.totalFrames, t.totalRate, t.errorFrames, len(t.unknownIDs))
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tMESSAGE\tCOUNT\tRATE (Hz)\tCYCLE (ms)\tEXPECTED (ms)\tDEVIATION\tSIGNALS")
+
+	for _, s := range t.order {
+		if s.count == 0 {
+			continue
+		}
+
+		cycle, expected, deviation := "-", "-", "-"
+		if s.avgCycle > 0 {
+			cycle = formatMs(s.avgCycle)
+		}
+		if cycleTime := s.msg.CycleTime(); cycleTime > 0 {
+			expected = formatMs(cycleTime)
+		}
+		if dev, ok := s.deviation(); ok {
+			deviation = fmt.Sprintf("%+.1f%%", dev)
+		}
+
+		signals := ""
+		if s.err != nil {
+			signals = "ERROR: " + s.err.Error()
+		} else {
+			strs := make([]string, len(s.values))
+			for i, val := range s.values {
+				strs[i] = formatSignalValue(val)
+			}
+			signals = strings.Join(strs, "  ")
+		}
+
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%.1f\t%s\t%s\t%s\t%s\n",
+			s.msg.FormatID(), s.msg.Name, s.count, s.rate, cycle, expected, deviation, signals)
+	}
+
+	return tw.Flush()
+}
@@ -5,7 +5,18 @@ import (
 	"os"
 
 	"github.com/spf13/cobra"
+	"github.com/squadracorsepolito/jsondbc/cmd/assignids"
+	"github.com/squadracorsepolito/jsondbc/cmd/busload"
 	"github.com/squadracorsepolito/jsondbc/cmd/convert"
+	"github.com/squadracorsepolito/jsondbc/cmd/decode"
+	"github.com/squadracorsepolito/jsondbc/cmd/encode"
+	"github.com/squadracorsepolito/jsondbc/cmd/generate"
+	"github.com/squadracorsepolito/jsondbc/cmd/logcmd"
+	"github.com/squadracorsepolito/jsondbc/cmd/monitor"
+	"github.com/squadracorsepolito/jsondbc/cmd/roundtrip"
+	"github.com/squadracorsepolito/jsondbc/cmd/rta"
+	"github.com/squadracorsepolito/jsondbc/cmd/schema"
+	"github.com/squadracorsepolito/jsondbc/cmd/simulate"
 )
 
 var rootCmd = &cobra.Command{
@@ -23,6 +34,17 @@ func Execute() {
 }
 
 func init() {
+	rootCmd.AddCommand(assignids.AssignIDsCmd)
+	rootCmd.AddCommand(busload.BusloadCmd)
 	rootCmd.AddCommand(convert.ConvertCmd)
+	rootCmd.AddCommand(decode.DecodeCmd)
+	rootCmd.AddCommand(encode.EncodeCmd)
+	rootCmd.AddCommand(generate.GenerateCmd)
+	rootCmd.AddCommand(logcmd.LogCmd)
+	rootCmd.AddCommand(monitor.MonitorCmd)
+	rootCmd.AddCommand(roundtrip.RoundtripCmd)
+	rootCmd.AddCommand(rta.RtaCmd)
+	rootCmd.AddCommand(schema.SchemaCmd)
+	rootCmd.AddCommand(simulate.SimulateCmd)
 	rootCmd.AddCommand(versionCmd)
 }
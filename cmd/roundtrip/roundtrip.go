@@ -0,0 +1,61 @@
+// Package roundtrip contains the roundtrip command
+package roundtrip
+
+import (
+	"fmt"
+	"log"
+
+	"github.com/spf13/cobra"
+	"github.com/squadracorsepolito/jsondbc/pkg/roundtrip"
+)
+
+var inFileName string
+
+// checkRoundTrip is the handler for the roundtrip command.
+// It converts the dbc file to json and back to dbc, then it prints the semantic differences
+// and returns an error if there are any.
+func checkRoundTrip() error {
+	diffs, err := roundtrip.Check(inFileName)
+	if err != nil {
+		return err
+	}
+
+	for _, diff := range diffs {
+		fmt.Println(diff)
+	}
+
+	if len(diffs) > 0 {
+		return fmt.Errorf("the round trip changed the dbc file, differences found: %d", len(diffs))
+	}
+
+	log.Print("ROUND TRIP COMPLETED, no differences found")
+
+	return nil
+}
+
+// RoundtripCmd represents the roundtrip command
+var RoundtripCmd = &cobra.Command{
+	Use:   "roundtrip",
+	Short: "Checks that a dbc file is not changed by the conversion to json and back to dbc",
+	Long: `Converts the dbc file to the json model and back to dbc, then compares the original file
+with the round-tripped one and prints the differences of the elements, ignoring their order.
+The elements are referenced by their DBC keyword, for example SG_ 256 Rpm is the signal Rpm
+of the message with id 256. The command fails if any difference is found.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		// the usage is not useful when the round trip changes the file
+		cmd.SilenceUsage = true
+		return checkRoundTrip()
+	},
+}
+
+// init initializes the flags for the roundtrip command.
+func init() {
+	RoundtripCmd.Flags().StringVar(&inFileName, "in", "", "Sets the input dbc file")
+	if err := RoundtripCmd.MarkFlagRequired("in"); err != nil {
+		log.Fatal(err)
+	}
+	if err := RoundtripCmd.MarkFlagFilename("in", ".dbc"); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,127 @@
+// Package rta contains the rta command
+package rta
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"os"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/squadracorsepolito/jsondbc/pkg"
+	"github.com/squadracorsepolito/jsondbc/pkg/cantime"
+	"github.com/squadracorsepolito/jsondbc/pkg/codec"
+	"github.com/squadracorsepolito/jsondbc/pkg/rta"
+)
+
+var (
+	modelFileName string
+	bitrate       uint32
+	dataBitrate   uint32
+)
+
+func formatMs(d time.Duration) string {
+	if d == time.Duration(math.MaxInt64) {
+		return "inf"
+	}
+	return fmt.Sprintf("%.3f", float64(d)/float64(time.Millisecond))
+}
+
+// printReport prints the response time of each message, sorted by priority.
+func printReport(r *rta.Report) error {
+	fmt.Printf("bitrate: %d bit/s", r.Bitrate.Nominal)
+	if r.Bitrate.Data != r.Bitrate.Nominal {
+		fmt.Printf(", data bitrate: %d bit/s", r.Bitrate.Data)
+	}
+	fmt.Printf(", worst-case utilization: %.2f%%\n\n", 100*r.Utilization)
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "PRIO\tID\tMESSAGE\tTX (ms)\tBLOCKING (ms)\tPERIOD (ms)\tDEADLINE (ms)\tRESPONSE (ms)\tSLACK (ms)\tRESULT")
+
+	for _, res := range r.Results {
+		result, slack := "OK", formatMs(res.Slack())
+		if !res.Schedulable {
+			result, slack = "MISS", "-"
+		}
+
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\t%s\n",
+			res.Priority, res.Message.FormatID(), res.Message.Name, formatMs(res.TransmissionTime), formatMs(res.Blocking),
+			formatMs(res.Period), formatMs(res.Deadline), formatMs(res.ResponseTime), slack, result)
+	}
+
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	for _, msg := range r.NonCyclic {
+		log.Printf("WARNING: message [%s] is not cyclic, its interference is not considered -> EXCLUDED from the analysis", msg.Name)
+	}
+
+	return nil
+}
+
+// analyzeResponseTimes is the handler for the rta command.
+// It computes the worst-case response time of the cyclic messages of the model and
+// returns an error if any of them can miss its deadline.
+func analyzeResponseTimes() error {
+	canModel, err := pkg.ReadCanModel(modelFileName)
+	if err != nil {
+		return err
+	}
+
+	c, err := codec.New(canModel)
+	if err != nil {
+		return err
+	}
+
+	nominal := canModel.Baudrate
+	if bitrate > 0 {
+		nominal = bitrate
+	}
+	if nominal == 0 {
+		log.Printf("WARNING: the model has no baudrate, using %d bit/s", cantime.DefaultBitrate)
+	}
+
+	report := rta.Analyze(c, cantime.NewBitrate(nominal, dataBitrate))
+	if err := printReport(report); err != nil {
+		return err
+	}
+
+	if missed := report.Unschedulable(); len(missed) > 0 {
+		return fmt.Errorf("%d of %d messages can miss their deadline", len(missed), len(report.Results))
+	}
+
+	return nil
+}
+
+// RtaCmd represents the rta command
+var RtaCmd = &cobra.Command{
+	Use:   "rta",
+	Short: "Computes the worst-case response time of the cyclic messages of a CAN model",
+	Long: `Computes the worst-case response time of the cyclic messages of a CAN model under the CAN arbitration
+(response time analysis), using the ids as priorities, the worst-case frame lengths, the cycle times
+as periods and the deadline_ms of the messages (or their cycle times) as deadlines.
+The command fails if any message can miss its deadline.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		// the usage is not useful when a deadline is missed
+		cmd.SilenceUsage = true
+		return analyzeResponseTimes()
+	},
+}
+
+// init initializes the flags for the rta command.
+func init() {
+	RtaCmd.Flags().StringVarP(&modelFileName, "model", "m", "", "Sets the CAN model file (.json or .dbc)")
+	if err := RtaCmd.MarkFlagRequired("model"); err != nil {
+		log.Fatal(err)
+	}
+	if err := RtaCmd.MarkFlagFilename("model", ".json", ".dbc"); err != nil {
+		log.Fatal(err)
+	}
+
+	RtaCmd.Flags().Uint32Var(&bitrate, "bitrate", 0, "Sets the nominal bitrate in bit/s, if not set it is taken from the baudrate of the model")
+	RtaCmd.Flags().Uint32Var(&dataBitrate, "data-bitrate", 0, "Sets the data bitrate in bit/s of the CAN FD frames, if not set the frames do not switch the bitrate")
+}
@@ -0,0 +1,56 @@
+// Package schema contains the schema command
+package schema
+
+import (
+	"log"
+	"os"
+
+	"github.com/spf13/cobra"
+	"github.com/squadracorsepolito/jsondbc/pkg/schema"
+)
+
+var outFileName string
+
+// writeSchema is the handler for the schema command.
+// It generates the JSON Schema of the CAN model and writes it into the output file or to the standard output.
+func writeSchema() error {
+	data, err := schema.Generate()
+	if err != nil {
+		return err
+	}
+	data = append(data, '\n')
+
+	if outFileName == "" {
+		_, err := os.Stdout.Write(data)
+		return err
+	}
+
+	if err := os.WriteFile(outFileName, data, 0644); err != nil {
+		return err
+	}
+
+	log.Printf("SCHEMA WRITTEN into %s", outFileName)
+
+	return nil
+}
+
+// SchemaCmd represents the schema command
+var SchemaCmd = &cobra.Command{
+	Use:   "schema",
+	Short: "Generates the JSON Schema of the CAN model",
+	Long: `Generates the JSON Schema of the CAN model, describing the fields of the model and their types.
+A json model referencing it with the $schema field can be autocompleted and checked by the editors
+and validated by any JSON Schema validator.`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return writeSchema()
+	},
+}
+
+// init initializes the flags for the schema command.
+func init() {
+	SchemaCmd.Flags().StringVar(&outFileName, "out", "", "Sets the output file, if not set the schema is printed")
+	if err := SchemaCmd.MarkFlagFilename("out", ".json"); err != nil {
+		log.Fatal(err)
+	}
+}
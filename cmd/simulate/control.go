@@ -0,0 +1,119 @@
+package simulate
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"sort"
+
+	"github.com/squadracorsepolito/jsondbc/pkg/codec"
+	"github.com/squadracorsepolito/jsondbc/pkg/restbus"
+)
+
+// command is a line of the control channel, for example:
+//
+//	{"message": "EEC1", "signals": {"Engine_Speed": 1000, "Engine_Status": "Running"}}
+//
+// The signal values are physical values or enum labels. Reset restores the start values
+// before setting the signals and Send requests a transmission regardless of the send type.
+type command struct {
+	Message string         `json:"message"`
+	Signals map[string]any `json:"signals"`
+	Reset   bool           `json:"reset"`
+	Send    bool           `json:"send"`
+}
+
+// readCommands reads the commands of the control channel, one JSON object per line.
+// The invalid lines are reported and ignored.
+func readCommands(r io.Reader, commands chan<- *command) {
+	scanner := bufio.NewScanner(r)
+
+	line := 0
+	for scanner.Scan() {
+		line++
+
+		if len(scanner.Bytes()) == 0 {
+			continue
+		}
+
+		cmd := &command{}
+		if err := json.Unmarshal(scanner.Bytes(), cmd); err != nil {
+			log.Printf("WARNING: control line %d: %v -> IGNORED", line, err)
+			continue
+		}
+		commands <- cmd
+	}
+
+	if err := scanner.Err(); err != nil {
+		log.Printf("WARNING: control channel: %v", err)
+	}
+}
+
+// signalValue returns the physical value of a signal from a number or an enum label.
+func signalValue(sig *codec.Signal, value any) (float64, error) {
+	switch val := value.(type) {
+	case float64:
+		return val, nil
+
+	case bool:
+		if val {
+			return 1, nil
+		}
+		return 0, nil
+
+	case string:
+		if phys, ok := sig.LabelValue(val); ok {
+			return phys, nil
+		}
+
+		labels := make([]string, 0, len(sig.Model.Enum))
+		for label := range sig.Model.Enum {
+			labels = append(labels, label)
+		}
+		sort.Strings(labels)
+		return 0, fmt.Errorf("signal [%s] value %q is not an enum label, valid labels are %v", sig.Name, val, labels)
+	}
+
+	return 0, fmt.Errorf("signal [%s] value %v is neither a number nor an enum label", sig.Name, value)
+}
+
+// apply executes the command on the simulation.
+func (cmd *command) apply(c *codec.Codec, sim *restbus.Simulation) error {
+	msg, ok := c.MessageByName(cmd.Message)
+	if !ok {
+		return fmt.Errorf("message [%s] is not defined", cmd.Message)
+	}
+
+	values := make(map[string]float64, len(cmd.Signals))
+	for sigName, value := range cmd.Signals {
+		sig, ok := msg.Signal(sigName)
+		if !ok {
+			return fmt.Errorf("message [%s] has no signal [%s]", msg.Name, sigName)
+		}
+
+		phys, err := signalValue(sig, value)
+		if err != nil {
+			return err
+		}
+		values[sigName] = phys
+	}
+
+	if cmd.Reset {
+		if err := sim.Reset(msg.Name); err != nil {
+			return err
+		}
+	}
+
+	if len(values) > 0 {
+		if err := sim.Set(msg.Name, values); err != nil {
+			return err
+		}
+	}
+
+	if cmd.Send {
+		return sim.Send(msg.Name)
+	}
+	return nil
+}
@@ -0,0 +1,165 @@
+// Package simulate contains the simulate command
+package simulate
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"os/signal"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/squadracorsepolito/jsondbc/pkg"
+	"github.com/squadracorsepolito/jsondbc/pkg/canlog"
+	"github.com/squadracorsepolito/jsondbc/pkg/codec"
+	"github.com/squadracorsepolito/jsondbc/pkg/restbus"
+	"github.com/squadracorsepolito/jsondbc/pkg/socketcan"
+)
+
+var (
+	ifaceName       string
+	modelFileName   string
+	nodeNames       []string
+	controlFileName string
+	verbose         bool
+)
+
+// openControl opens the control channel, - is the standard input.
+func openControl() (io.ReadCloser, error) {
+	switch controlFileName {
+	case "":
+		return nil, nil
+	case "-":
+		return os.Stdin, nil
+	}
+	return os.Open(controlFileName)
+}
+
+// simulate is the handler for the simulate command.
+// It transmits on a SocketCAN interface the messages sent by the given nodes of the model,
+// while the signal values are overridden by the commands of the control channel.
+func simulate() error {
+	canModel, err := pkg.ReadCanModel(modelFileName)
+	if err != nil {
+		return err
+	}
+
+	for _, nodeName := range nodeNames {
+		if _, ok := canModel.Nodes[nodeName]; !ok {
+			return fmt.Errorf("node [%s] is not defined", nodeName)
+		}
+	}
+
+	c, err := codec.New(canModel)
+	if err != nil {
+		return err
+	}
+
+	sim, err := restbus.New(c, nodeNames)
+	if err != nil {
+		return err
+	}
+
+	control, err := openControl()
+	if err != nil {
+		return err
+	}
+
+	conn, err := socketcan.Dial(ifaceName)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	for _, msg := range sim.Messages() {
+		log.Printf("SIMULATING [%s] (%s, %s)", msg.Name, msg.Model.Sender, sim.SendMode(msg.Name))
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	commands := make(chan *command)
+	if control != nil {
+		defer control.Close()
+		go readCommands(control, commands)
+	}
+
+	timer := time.NewTimer(0)
+	defer timer.Stop()
+
+	sim.Start(time.Now())
+	for {
+		select {
+		case <-timer.C:
+			// a message that cannot be encoded is skipped, the other ones keep the bus running
+			frames, err := sim.Due(time.Now())
+			if err != nil {
+				log.Printf("WARNING: %v -> SKIPPED", err)
+			}
+
+			for _, frame := range frames {
+				if err := conn.Write(frame); err != nil {
+					// a full transmit queue drops the frame, as a node losing the bus
+					if errors.Is(err, socketcan.ErrQueueFull) {
+						log.Printf("WARNING: transmit queue of %s is full, frame [%s] -> DROPPED", ifaceName, frame.Name)
+						continue
+					}
+					return fmt.Errorf("interface %s: %w", ifaceName, err)
+				}
+				if verbose {
+					log.Printf("SENT [%s] %s", frame.Name, canlog.FormatCandumpFrame(frame))
+				}
+			}
+
+		case cmd := <-commands:
+			if err := cmd.apply(c, sim); err != nil {
+				log.Printf("WARNING: %v -> IGNORED", err)
+			}
+
+		case <-ctx.Done():
+			return nil
+		}
+
+		if next, ok := sim.NextDue(); ok {
+			timer.Reset(time.Until(next))
+		}
+	}
+}
+
+// SimulateCmd represents the simulate command
+var SimulateCmd = &cobra.Command{
+	Use:   "simulate",
+	Short: "Simulates the messages sent by the given nodes on a SocketCAN interface (restbus simulation)",
+	Long:  ``,
+	Args:  cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return simulate()
+	},
+}
+
+// init initializes the flags for the simulate command.
+func init() {
+	SimulateCmd.Flags().StringVar(&ifaceName, "iface", "", "Sets the SocketCAN interface to transmit on (e.g. can0 or vcan0)")
+	if err := SimulateCmd.MarkFlagRequired("iface"); err != nil {
+		log.Fatal(err)
+	}
+
+	SimulateCmd.Flags().StringVarP(&modelFileName, "model", "m", "", "Sets the CAN model file (.json or .dbc)")
+	if err := SimulateCmd.MarkFlagRequired("model"); err != nil {
+		log.Fatal(err)
+	}
+	if err := SimulateCmd.MarkFlagFilename("model", ".json", ".dbc"); err != nil {
+		log.Fatal(err)
+	}
+
+	SimulateCmd.Flags().StringSliceVarP(&nodeNames, "node", "n", nil, "Sets the nodes to simulate, the flag can be repeated or comma separated")
+	if err := SimulateCmd.MarkFlagRequired("node"); err != nil {
+		log.Fatal(err)
+	}
+
+	SimulateCmd.Flags().StringVar(&controlFileName, "control", "-", "Sets the control channel (a file or a named pipe) with a JSON command per line, - is stdin and an empty value disables it")
+	SimulateCmd.Flags().BoolVarP(&verbose, "verbose", "v", false, "Logs every transmitted frame")
+}
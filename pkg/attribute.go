@@ -1,9 +1,5 @@
 package pkg
 
-import (
-	"github.com/squadracorsepolito/jsondbc/pkg/sym"
-)
-
 type attributeKind uint8
 
 const (
@@ -204,15 +200,15 @@ func (aa *AttributeAssignments) getAttributeValue(attName string, attType attrib
 
 	switch attType {
 	case attributeTypeInt:
-		if attName == sym.MsgPeriodAttribute {
-			return formatInt(int(att.(uint32)))
-		}
-		return formatInt(int(att.(float64)))
+		return formatInt(attributeIntValue(att))
 
 	case attributeTypeString:
 		return formatString(att.(string))
 
 	case attributeTypeFloat:
+		if val, ok := att.(int); ok {
+			return formatFloat(float64(val))
+		}
 		return formatFloat(att.(float64))
 
 	case attributeTypeEnum:
@@ -0,0 +1,117 @@
+// Package busload computes the bus load of the cyclic messages of a CAN model.
+package busload
+
+import (
+	"sort"
+	"time"
+
+	"github.com/squadracorsepolito/jsondbc/pkg/canlog"
+	"github.com/squadracorsepolito/jsondbc/pkg/cantime"
+	"github.com/squadracorsepolito/jsondbc/pkg/codec"
+)
+
+// Load is the bus load of a message, a node or the whole bus,
+// as the fraction of the bus time spent transmitting (0.5 is 50%).
+// The loads are computed both with the nominal frame lengths and the worst-case stuffing.
+type Load struct {
+	Nominal   float64
+	WorstCase float64
+}
+
+func (l *Load) add(other Load) {
+	l.Nominal += other.Nominal
+	l.WorstCase += other.WorstCase
+}
+
+// MessageLoad is the bus load of a cyclic message.
+type MessageLoad struct {
+	Message *codec.Message
+	// CycleTime is the period of the message, from cycle_time or period_ms
+	CycleTime time.Duration
+	// Bits are the frame lengths without stuff bits (nominal) and with the worst-case stuffing
+	NominalBits   cantime.Bits
+	WorstCaseBits cantime.Bits
+	Load          Load
+}
+
+// NodeLoad is the bus load of the cyclic messages sent by a node.
+type NodeLoad struct {
+	// Node is empty for the messages without a sender
+	Node     string
+	Messages []*MessageLoad
+	Load     Load
+}
+
+// Report contains the bus load of the cyclic messages, by message, by node and in total.
+// The messages without a cycle time are event driven, so they are listed without a load
+// together with the ones that are not used.
+type Report struct {
+	Bitrate  cantime.Bitrate
+	Messages []*MessageLoad
+	Nodes    []*NodeLoad
+	Total    Load
+	// NonCyclic are the messages without a cycle time or not used, excluded from the load
+	NonCyclic []*codec.Message
+}
+
+// IsFD returns true if the message is sent with CAN FD frames, i.e. it is longer than 8 bytes.
+func IsFD(msg *codec.Message) bool {
+	return msg.Length > canlog.MaxClassicLength
+}
+
+// MessageBits returns the length of the frames of the message with the given stuffing.
+// The CAN FD frames switch the bitrate if the data bitrate differs from the nominal one.
+func MessageBits(msg *codec.Message, bitrate cantime.Bitrate, stuffing cantime.Stuffing) cantime.Bits {
+	fd := IsFD(msg)
+	brs := fd && bitrate.Data != bitrate.Nominal
+	return cantime.MessageBits(int(msg.Length), msg.IsExtended(), fd, brs, stuffing)
+}
+
+// Compute returns the bus load of the messages of the codec at the given bitrate.
+func Compute(c *codec.Codec, bitrate cantime.Bitrate) *Report {
+	r := &Report{
+		Bitrate:   bitrate,
+		Messages:  []*MessageLoad{},
+		Nodes:     []*NodeLoad{},
+		NonCyclic: []*codec.Message{},
+	}
+
+	nodes := make(map[string]*NodeLoad)
+	for _, msg := range c.Messages() {
+		cycleTime := msg.CycleTime()
+		if cycleTime <= 0 || msg.Model.SendType == "NotUsed" {
+			r.NonCyclic = append(r.NonCyclic, msg)
+			continue
+		}
+
+		ml := &MessageLoad{
+			Message:       msg,
+			CycleTime:     cycleTime,
+			NominalBits:   MessageBits(msg, bitrate, cantime.StuffingNone),
+			WorstCaseBits: MessageBits(msg, bitrate, cantime.StuffingWorstCase),
+		}
+		ml.Load = Load{
+			Nominal:   float64(bitrate.Duration(ml.NominalBits)) / float64(cycleTime),
+			WorstCase: float64(bitrate.Duration(ml.WorstCaseBits)) / float64(cycleTime),
+		}
+		r.Messages = append(r.Messages, ml)
+		r.Total.add(ml.Load)
+
+		sender := msg.Model.Sender
+		nl, ok := nodes[sender]
+		if !ok {
+			nl = &NodeLoad{Node: sender, Messages: []*MessageLoad{}}
+			nodes[sender] = nl
+			r.Nodes = append(r.Nodes, nl)
+		}
+		nl.Messages = append(nl.Messages, ml)
+		nl.Load.add(ml.Load)
+	}
+
+	// the nodes are sorted by decreasing load
+	sort.SliceStable(r.Nodes, func(i, j int) bool {
+		return r.Nodes[i].Load.WorstCase > r.Nodes[j].Load.WorstCase
+	})
+
+	return r
+}
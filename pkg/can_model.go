@@ -1,9 +1,9 @@
 package pkg
 
 import (
-	"fmt"
 	"os"
 	"sort"
+	"strings"
 
 	"github.com/squadracorsepolito/jsondbc/pkg/sym"
 )
@@ -25,6 +25,7 @@ type Writer interface {
 
 // CanModel represents the CAN model.
 type CanModel struct {
+	Schema            string                       `json:"$schema,omitempty"`
 	Version           string                       `json:"version"`
 	Baudrate          uint32                       `json:"baudrate,omitempty"`
 	Nodes             map[string]*Node             `json:"nodes"`
@@ -35,10 +36,26 @@ type CanModel struct {
 	Messages          map[string]*Message          `json:"messages"`
 	SignalEnums       map[string]map[string]uint32 `json:"signal_enums"`
 
+	// Layout is the layout policy of the signals without start bit (tight, nibble or byte)
+	Layout string `json:"layout,omitempty"`
+
+	// PassThrough contains the elements of the DBC file that are not part of the model
+	PassThrough *DBCPassThrough `json:"pass_through,omitempty"`
+
 	source sourceType
+	// diagnostics are the warnings found by Init
+	diagnostics Diagnostics
+	// readDiagnostics are the warnings found by the reader of the source file
+	readDiagnostics Diagnostics
+	// positions are the positions of the elements in the source file, by path
+	positions map[string]Position
+	// placedSignals are the start bits assigned by the layout, by path of the signal in the JSON model
+	placedSignals map[string]uint32
 }
 
 func (c *CanModel) Init() {
+	c.diagnostics = Diagnostics{}
+
 	baudrateAtt, hasBaudrateAtt := c.GeneralAttributes[sym.BaudrateAttribute]
 	if hasBaudrateAtt {
 		c.Baudrate = uint32(baudrateAtt.Int.Default)
@@ -105,8 +122,12 @@ func (c *CanModel) Init() {
 		node.initNode(nodeName)
 	}
 
+	if c.PassThrough != nil {
+		c.PassThrough.initPassThrough()
+	}
+
 	for msgName, msg := range c.Messages {
-		msg.initMessage(msgName, c.source)
+		msg.initMessage(msgName, c.source, &c.diagnostics)
 	}
 
 	for _, node := range c.Nodes {
@@ -137,7 +158,7 @@ func (c *CanModel) Init() {
 				if enum, ok := c.SignalEnums[sig.EnumRef]; ok {
 					sig.Enum = enum
 				} else {
-					fmt.Printf("WARNING: signal '%s' -> enum_ref '%s' is not defined in global signal enums -> SKIPPED\n", sig.signalName, sig.EnumRef)
+					c.diagnostics.warnf(sig.loc.child("enum_ref"), "signal [%s] enum_ref [%s] is not defined in the signal enums -> SKIPPED", sig.signalName, sig.EnumRef)
 				}
 			}
 		}
@@ -192,23 +213,94 @@ func (c *CanModel) handleCustomAttributes() {
 	}
 }
 
-// Validate validates the CAN model.
+// Validate validates the CAN model, the returned error contains all the errors found.
 func (c *CanModel) Validate() error {
+	return c.Diagnose().Err()
+}
+
+// Diagnose validates the CAN model and returns all the errors found,
+// together with the warnings found by Init, sorted by position.
+func (c *CanModel) Diagnose() Diagnostics {
+	diags := append(append(Diagnostics{}, c.readDiagnostics...), c.diagnostics...)
+
+	msgNames := make([]string, 0, len(c.Messages))
+	for msgName := range c.Messages {
+		msgNames = append(msgNames, msgName)
+	}
+	sort.Strings(msgNames)
+
 	msgIDMap := make(map[uint32]string)
-	for _, msg := range c.Messages {
-		if msgName, ok := msgIDMap[msg.ID]; ok {
-			return fmt.Errorf("[%s] message id [%d] is already taken by [%s]", msg.messageName, msg.ID, msgName)
+	for _, msgName := range msgNames {
+		msg := c.Messages[msgName]
+
+		if !msg.HasID() {
+			diags.errorf(msg.loc, "message [%s] has no id, it can be assigned with the assign-ids command", msgName)
+		} else if takenBy, ok := msgIDMap[msg.ID]; ok {
+			diags.errorf(msg.loc.child("id"), "message [%s] id [%d] is already taken by [%s]", msgName, msg.ID, takenBy)
+		} else {
+			msgIDMap[msg.ID] = msgName
 		}
-		msgIDMap[msg.ID] = msg.messageName
+
+		msg.validate(&diags)
+		c.validateNodeNames(msg, &diags)
 	}
 
-	for _, msg := range c.Messages {
-		if err := msg.validate(); err != nil {
-			return err
+	c.validatePassThrough(&diags)
+
+	for _, diag := range diags {
+		if !diag.Position.IsValid() {
+			diag.Position = c.positionOf(diag.Path)
 		}
 	}
+	diags.sort()
+
+	return diags
+}
 
-	return nil
+// validateNodeNames warns about the sender and the receivers of the message that are not defined.
+func (c *CanModel) validateNodeNames(msg *Message, diags *Diagnostics) {
+	if len(msg.Sender) > 0 && msg.Sender != dbcDefNode {
+		if _, ok := c.Nodes[msg.Sender]; !ok {
+			diags.warnf(msg.loc.child("sender"), "message [%s] sender [%s] is not defined in the nodes", msg.messageName, msg.Sender)
+		}
+	}
+
+	for _, ms := range muxedSignals(msg.Signals, nil, nil) {
+		for _, receiver := range ms.signal.Receivers {
+			if _, ok := c.Nodes[receiver]; !ok {
+				diags.warnf(ms.signal.loc.child("receivers"), "signal [%s] receiver [%s] is not defined in the nodes", ms.signal.signalName, receiver)
+			}
+		}
+	}
+}
+
+// positionOf returns the position of the element of the model at the given path,
+// or the one of its closest parent with a known position.
+func (c *CanModel) positionOf(path string) Position {
+	for len(path) > 0 {
+		if pos, ok := c.positions[path]; ok {
+			return pos
+		}
+
+		idx := strings.LastIndex(path, ".")
+		if idx < 0 {
+			break
+		}
+		path = path[:idx]
+	}
+	return Position{}
+}
+
+// UnassignedMessages returns the sorted names of the messages without an id.
+func (c *CanModel) UnassignedMessages() []string {
+	msgNames := []string{}
+	for msgName, msg := range c.Messages {
+		if !msg.HasID() {
+			msgNames = append(msgNames, msgName)
+		}
+	}
+	sort.Strings(msgNames)
+	return msgNames
 }
 
 func (c *CanModel) getAttributes() []*Attribute {
@@ -562,7 +562,7 @@ func (c *CAN) ToDBC(dbcFilename string) error {
 			}
 			for id, val := range sig.MapValues {
 				dbcEnc.Values = append(dbcEnc.Values, &dbc.ValueDescription{
-					ID:   uint32(id),
+					ID:   int64(id),
 					Name: val,
 				})
 			}
@@ -10,6 +10,12 @@ func GetNewSymbols() []string {
 	return newSymbolsValues
 }
 
+// Position is the position of the first token of an element in the DBC file, the line and the column start from 1.
+type Position struct {
+	Line   int
+	Column int
+}
+
 type DBC struct {
 	Version             string
 	NewSymbols          *NewSymbols
@@ -30,6 +36,10 @@ type DBC struct {
 	SignalGroups        []*SignalGroup
 	SignalExtValueTypes []*SignalExtValueType
 	ExtendedMuxes       []*ExtendedMux
+
+	RelationAttributes        []*Attribute
+	RelationAttributeDefaults []*AttributeDefault
+	RelationAttributeValues   []*AttributeValue
 }
 
 type NewSymbols struct {
@@ -52,8 +62,11 @@ type ValueTable struct {
 }
 
 type ValueDescription struct {
-	ID   uint32
+	// ID is signed, since the value descriptions of the signed signals can be negative
+	ID   int64
 	Name string
+
+	Pos Position
 }
 
 type ValueEncodingKind uint
@@ -77,6 +90,8 @@ type Message struct {
 	Size        uint32
 	Transmitter string
 	Signals     []*Signal
+
+	Pos Position
 }
 
 type SignalByteOrder uint
@@ -108,6 +123,8 @@ type Signal struct {
 	Max            float64
 	Unit           string
 	Receivers      []string
+
+	Pos Position
 }
 
 type SignalExtValueTypeType uint
@@ -221,6 +238,10 @@ const (
 	AttributeMessage
 	AttributeSignal
 	AttributeEnvVar
+	// the relation attributes are defined with BA_DEF_REL_ and assigned with BA_REL_
+	AttributeNodeMessageRelation
+	AttributeNodeSignalRelation
+	AttributeNodeEnvVarRelation
 )
 
 type AttributeType uint
@@ -262,6 +283,8 @@ type AttributeDefault struct {
 	ValueInt      int
 	ValueHex      int
 	ValueFloat    float64
+
+	Pos Position
 }
 
 type AttributeValueType uint
@@ -285,6 +308,8 @@ type AttributeValue struct {
 	ValueInt      int
 	ValueHex      int
 	ValueFloat    float64
+
+	Pos Position
 }
 
 type ExtendedMuxRange struct {
@@ -297,4 +322,6 @@ type ExtendedMux struct {
 	MultiplexorName string
 	MultiplexedName string
 	Ranges          []*ExtendedMuxRange
+
+	Pos Position
 }
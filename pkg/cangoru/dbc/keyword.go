@@ -26,6 +26,7 @@ const (
 	keywordEnvVarData
 
 	keywordSignalType
+	keywordSignalTypeRef
 	keywordSignalGroup
 
 	keywordComment
@@ -39,6 +40,13 @@ const (
 	keywordAttributeString
 	keywordAttributeEnum
 
+	keywordRelationAttribute
+	keywordRelationAttributeDefault
+	keywordRelationAttributeValue
+	keywordNodeMessageRelation
+	keywordNodeSignalRelation
+	keywordNodeEnvVarRelation
+
 	keywordExtendedMux
 )
 
@@ -63,8 +71,9 @@ var keywords = map[string]keywordKind{
 	"EV_":          keywordEnvVar,
 	"ENVVAR_DATA_": keywordEnvVarData,
 
-	"SGTYPE_":    keywordSignalType,
-	"SIG_GROUP_": keywordSignalGroup,
+	"SGTYPE_":       keywordSignalType,
+	"SIG_TYPE_REF_": keywordSignalTypeRef,
+	"SIG_GROUP_":    keywordSignalGroup,
 
 	"CM_": keywordComment,
 
@@ -77,6 +86,13 @@ var keywords = map[string]keywordKind{
 	"STRING":      keywordAttributeString,
 	"ENUM":        keywordAttributeEnum,
 
+	"BA_DEF_REL_":     keywordRelationAttribute,
+	"BA_DEF_DEF_REL_": keywordRelationAttributeDefault,
+	"BA_REL_":         keywordRelationAttributeValue,
+	"BU_BO_REL_":      keywordNodeMessageRelation,
+	"BU_SG_REL_":      keywordNodeSignalRelation,
+	"BU_EV_REL_":      keywordNodeEnvVarRelation,
+
 	"SG_MUL_VAL_": keywordExtendedMux,
 }
 
@@ -1,7 +1,6 @@
 package dbc
 
 import (
-	"bytes"
 	"errors"
 	"fmt"
 	"strconv"
@@ -30,7 +29,12 @@ func (p *Parser) parseUint(val string) (uint32, error) {
 
 func (p *Parser) parseHexInt(val string) (int, error) {
 	if !strings.HasPrefix(val, "0x") && !strings.HasPrefix(val, "0X") {
-		return 0, errors.New("invalid hex number")
+		// the values of the hex attributes are often written as decimal numbers
+		res, err := strconv.ParseUint(val, 10, 32)
+		if err != nil {
+			return 0, errors.New("invalid hex number")
+		}
+		return int(res), nil
 	}
 	res, err := strconv.ParseUint(val[2:], 16, 32)
 	if err != nil {
@@ -53,7 +57,7 @@ func (p *Parser) parseDouble(val string) (float64, error) {
 
 func NewParser(file []byte) *Parser {
 	return &Parser{
-		s: newScanner(bytes.NewReader(file), string(file)),
+		s: newScanner(string(file)),
 
 		usePrev: false,
 
@@ -83,8 +87,24 @@ func (p *Parser) unscan() {
 	p.usePrev = true
 }
 
+// Error is a syntax error found by the parser at the position of the unexpected token.
+type Error struct {
+	Msg   string
+	Token string
+	Pos   Position
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf(`%s; got "%s" at line %d, column %d`, e.Msg, e.Token, e.Pos.Line, e.Pos.Column)
+}
+
+// position returns the position of the current token.
+func (p *Parser) position() Position {
+	return Position{Line: p.curr.line, Column: p.curr.col}
+}
+
 func (p *Parser) errorf(msg string) error {
-	return fmt.Errorf(`%s; got "%s" at line %d, column %d`, msg, p.curr.value, p.curr.line, p.curr.col)
+	return &Error{Msg: msg, Token: p.curr.value, Pos: p.position()}
 }
 
 func (p *Parser) expectSyntax(kind syntaxKind) error {
@@ -235,6 +255,34 @@ func (p *Parser) Parse() (*DBC, error) {
 					return nil, err
 				}
 				ast.ExtendedMuxes = append(ast.ExtendedMuxes, extMux)
+
+			case keywordSignalTypeRef:
+				sigTypeRef, err := p.parseSignalTypeRef()
+				if err != nil {
+					return nil, err
+				}
+				ast.SignalTypeRefs = append(ast.SignalTypeRefs, sigTypeRef)
+
+			case keywordRelationAttribute:
+				att, err := p.parseRelationAttribute()
+				if err != nil {
+					return nil, err
+				}
+				ast.RelationAttributes = append(ast.RelationAttributes, att)
+
+			case keywordRelationAttributeDefault:
+				attDef, err := p.parseAttributeDefault()
+				if err != nil {
+					return nil, err
+				}
+				ast.RelationAttributeDefaults = append(ast.RelationAttributeDefaults, attDef)
+
+			case keywordRelationAttributeValue:
+				attVal, err := p.parseRelationAttributeValue()
+				if err != nil {
+					return nil, err
+				}
+				ast.RelationAttributeValues = append(ast.RelationAttributeValues, attVal)
 			}
 
 		default:
@@ -397,9 +445,11 @@ func (p *Parser) parseValueDescription() (*ValueDescription, error) {
 		return valDesc, nil
 	}
 
-	valID, err := p.parseUint(t.value)
+	valDesc.Pos = p.position()
+
+	valID, err := strconv.ParseInt(t.value, 10, 64)
 	if err != nil {
-		return nil, p.errorf("cannot parse value description id as uint")
+		return nil, p.errorf("cannot parse value description id as int")
 	}
 	valDesc.ID = valID
 
@@ -457,6 +507,7 @@ func (p *Parser) parseMessageID() (uint32, error) {
 
 func (p *Parser) parseMessage() (*Message, error) {
 	msg := new(Message)
+	msg.Pos = p.position()
 
 	id, err := p.parseMessageID()
 	if err != nil {
@@ -515,6 +566,7 @@ func (p *Parser) parseSignalName() (string, error) {
 
 func (p *Parser) parseSignal() (*Signal, error) {
 	sig := new(Signal)
+	sig.Pos = p.position()
 
 	name, err := p.parseSignalName()
 	if err != nil {
@@ -703,8 +755,18 @@ func (p *Parser) parseMessageTransmitter() (*MessageTransmitter, error) {
 	}
 	mt.MessageID = msgID
 
+	if err := p.expectSyntax(syntaxColon); err != nil {
+		return nil, err
+	}
+
 	for {
+		// the transmitters can be separated by commas
 		t := p.scan()
+		if !t.isSyntax(syntaxComma) {
+			p.unscan()
+		}
+
+		t = p.scan()
 		p.unscan()
 		if !t.isIdent() {
 			break
@@ -791,7 +853,7 @@ func (p *Parser) parseEnvVar() (*EnvVar, error) {
 	}
 	envVar.Max = max
 
-	if err := p.expectSyntax(syntaxLeftSquareBrace); err != nil {
+	if err := p.expectSyntax(syntaxRightSquareBrace); err != nil {
 		return nil, err
 	}
 
@@ -890,7 +952,6 @@ func (p *Parser) parseEnvVarData() (*EnvVarData, error) {
 
 func (p *Parser) parseSignalType() (*SignalType, *SignalTypeRef, error) {
 	sigType := new(SignalType)
-	sigTypeRef := new(SignalTypeRef)
 
 	t := p.scan()
 	p.unscan()
@@ -906,19 +967,19 @@ func (p *Parser) parseSignalType() (*SignalType, *SignalTypeRef, error) {
 			return nil, nil, err
 		}
 
-		t = p.scan()
-		if !t.isNumber() {
-			return nil, nil, p.errorf("expected signal start bit")
-		}
-
-		if err := p.expectSyntax(syntaxPipe); err != nil {
-			return nil, nil, err
-		}
-
 		t = p.scan()
 		if !t.isNumber() {
 			return nil, nil, p.errorf("expected signal size")
 		}
+		// the specification has no start bit, but some files have it before the size
+		if p.scan().isSyntax(syntaxPipe) {
+			t = p.scan()
+			if !t.isNumber() {
+				return nil, nil, p.errorf("expected signal size")
+			}
+		} else {
+			p.unscan()
+		}
 		size, err := p.parseUint(t.value)
 		if err != nil {
 			return nil, nil, p.errorf("cannot parse signal size as uint")
@@ -1051,28 +1112,10 @@ func (p *Parser) parseSignalType() (*SignalType, *SignalTypeRef, error) {
 		}
 
 	case tokenNumber:
-		msgID, err := p.parseMessageID()
-		if err != nil {
-			return nil, nil, err
-		}
-		sigTypeRef.MessageID = msgID
-
-		sigName, err := p.parseSignalName()
+		sigTypeRef, err := p.parseSignalTypeRef()
 		if err != nil {
 			return nil, nil, err
 		}
-		sigTypeRef.SignalName = sigName
-
-		t = p.scan()
-		if !t.isIdent() {
-			return nil, nil, p.errorf("expected signal type name")
-		}
-		sigType.TypeName = t.value
-
-		if err := p.expectSyntax(syntaxSemicolon); err != nil {
-			return nil, nil, err
-		}
-
 		return nil, sigTypeRef, nil
 
 	default:
@@ -1082,6 +1125,39 @@ func (p *Parser) parseSignalType() (*SignalType, *SignalTypeRef, error) {
 	return sigType, nil, nil
 }
 
+// parseSignalTypeRef parses the reference of a signal to a signal type, defined with SIG_TYPE_REF_
+// or with SGTYPE_ followed by the message id.
+func (p *Parser) parseSignalTypeRef() (*SignalTypeRef, error) {
+	sigTypeRef := new(SignalTypeRef)
+
+	msgID, err := p.parseMessageID()
+	if err != nil {
+		return nil, err
+	}
+	sigTypeRef.MessageID = msgID
+
+	sigName, err := p.parseSignalName()
+	if err != nil {
+		return nil, err
+	}
+	sigTypeRef.SignalName = sigName
+
+	t := p.scan()
+	if t.isSyntax(syntaxColon) {
+		t = p.scan()
+	}
+	if !t.isIdent() {
+		return nil, p.errorf("expected signal type name")
+	}
+	sigTypeRef.TypeName = t.value
+
+	if err := p.expectSyntax(syntaxSemicolon); err != nil {
+		return nil, err
+	}
+
+	return sigTypeRef, nil
+}
+
 func (p *Parser) parseComment() (*Comment, error) {
 	com := new(Comment)
 
@@ -1198,15 +1274,46 @@ func (p *Parser) parseAttribute() (*Attribute, error) {
 		return nil, p.errorf("expected string or keyword")
 	}
 
+	if err := p.parseAttributeDefinition(att); err != nil {
+		return nil, err
+	}
+
+	return att, nil
+}
+
+func (p *Parser) parseRelationAttribute() (*Attribute, error) {
+	att := new(Attribute)
+
+	t := p.scan()
+	switch {
+	case t.isKeyword(keywordNodeMessageRelation):
+		att.Kind = AttributeNodeMessageRelation
+	case t.isKeyword(keywordNodeSignalRelation):
+		att.Kind = AttributeNodeSignalRelation
+	case t.isKeyword(keywordNodeEnvVarRelation):
+		att.Kind = AttributeNodeEnvVarRelation
+	default:
+		return nil, p.errorf("expected node message, node signal or node envvar relation keyword")
+	}
+
+	if err := p.parseAttributeDefinition(att); err != nil {
+		return nil, err
+	}
+
+	return att, nil
+}
+
+// parseAttributeDefinition parses the name, the type and the values of an attribute definition.
+func (p *Parser) parseAttributeDefinition(att *Attribute) error {
 	attName, err := p.parseAttributeName()
 	if err != nil {
-		return nil, err
+		return err
 	}
 	att.Name = attName
 
-	t = p.scan()
+	t := p.scan()
 	if t.kind != tokenKeyword {
-		return nil, p.errorf("expected attribute type keyword")
+		return p.errorf("expected attribute type keyword")
 	}
 	keywordKind := getKeywordKind(t.value)
 	switch keywordKind {
@@ -1214,20 +1321,20 @@ func (p *Parser) parseAttribute() (*Attribute, error) {
 		att.Type = AttributeInt
 		t = p.scan()
 		if !t.isNumber() {
-			return nil, p.errorf("expected int attribute min value")
+			return p.errorf("expected int attribute min value")
 		}
 		minInt, err := p.parseInt(t.value)
 		if err != nil {
-			return nil, p.errorf("cannot parse int attribute min value as int")
+			return p.errorf("cannot parse int attribute min value as int")
 		}
 		att.MinInt = minInt
 		t = p.scan()
 		if !t.isNumber() {
-			return nil, p.errorf("expected int attribute max value")
+			return p.errorf("expected int attribute max value")
 		}
 		maxInt, err := p.parseInt(t.value)
 		if err != nil {
-			return nil, p.errorf("cannot parse int attribute max value as int")
+			return p.errorf("cannot parse int attribute max value as int")
 		}
 		att.MaxInt = maxInt
 
@@ -1235,20 +1342,20 @@ func (p *Parser) parseAttribute() (*Attribute, error) {
 		att.Type = AttributeHex
 		t = p.scan()
 		if !t.isNumber() {
-			return nil, p.errorf("expected hex attribute min value")
+			return p.errorf("expected hex attribute min value")
 		}
 		minHex, err := p.parseHexInt(t.value)
 		if err != nil {
-			return nil, p.errorf("cannot parse hex attribute min value as int")
+			return p.errorf("cannot parse hex attribute min value as int")
 		}
 		att.MinHex = minHex
 		t = p.scan()
 		if !t.isNumber() {
-			return nil, p.errorf("expected hex attribute max value")
+			return p.errorf("expected hex attribute max value")
 		}
 		maxHex, err := p.parseHexInt(t.value)
 		if err != nil {
-			return nil, p.errorf("cannot parse hex attribute max value as int")
+			return p.errorf("cannot parse hex attribute max value as int")
 		}
 		att.MaxHex = maxHex
 
@@ -1256,20 +1363,20 @@ func (p *Parser) parseAttribute() (*Attribute, error) {
 		att.Type = AttributeFloat
 		t = p.scan()
 		if !t.isNumber() {
-			return nil, p.errorf("expected float attribute min value")
+			return p.errorf("expected float attribute min value")
 		}
 		minFloat, err := p.parseDouble(t.value)
 		if err != nil {
-			return nil, p.errorf("cannot parse float attribute min value as double")
+			return p.errorf("cannot parse float attribute min value as double")
 		}
 		att.MinFloat = minFloat
 		t = p.scan()
 		if !t.isNumber() {
-			return nil, p.errorf("expected float attribute max value")
+			return p.errorf("expected float attribute max value")
 		}
 		maxFloat, err := p.parseDouble(t.value)
 		if err != nil {
-			return nil, p.errorf("cannot parse float attribute max value as double")
+			return p.errorf("cannot parse float attribute max value as double")
 		}
 		att.MaxFloat = maxFloat
 
@@ -1280,7 +1387,7 @@ func (p *Parser) parseAttribute() (*Attribute, error) {
 		att.Type = AttributeEnum
 		t = p.scan()
 		if !t.isString() {
-			return nil, p.errorf("expected enum attribute values")
+			return p.errorf("expected enum attribute values")
 		}
 		att.EnumValues = append(att.EnumValues, t.value)
 		for {
@@ -1290,24 +1397,21 @@ func (p *Parser) parseAttribute() (*Attribute, error) {
 			}
 			t = p.scan()
 			if !t.isString() {
-				return nil, p.errorf("expected enum attribute values")
+				return p.errorf("expected enum attribute values")
 			}
 			att.EnumValues = append(att.EnumValues, t.value)
 		}
 
 	default:
-		return nil, p.errorf("expected attribute type keyword to be INT, HEX, FLOAT, STRING or ENUM")
+		return p.errorf("expected attribute type keyword to be INT, HEX, FLOAT, STRING or ENUM")
 	}
 
-	if err := p.expectSyntax(syntaxSemicolon); err != nil {
-		return nil, err
-	}
-
-	return att, nil
+	return p.expectSyntax(syntaxSemicolon)
 }
 
 func (p *Parser) parseAttributeDefault() (*AttributeDefault, error) {
 	attDef := new(AttributeDefault)
+	attDef.Pos = p.position()
 
 	attName, err := p.parseAttributeName()
 	if err != nil {
@@ -1359,6 +1463,7 @@ func (p *Parser) parseAttributeDefault() (*AttributeDefault, error) {
 
 func (p *Parser) parseAttributeValue() (*AttributeValue, error) {
 	attVal := new(AttributeValue)
+	attVal.Pos = p.position()
 
 	t := p.scan()
 	if !t.isString() {
@@ -1419,7 +1524,82 @@ func (p *Parser) parseAttributeValue() (*AttributeValue, error) {
 		return nil, p.errorf("expected string, number or keyword")
 	}
 
-	t = p.scan()
+	if err := p.parseAttributeValueData(attVal); err != nil {
+		return nil, err
+	}
+
+	return attVal, nil
+}
+
+func (p *Parser) parseRelationAttributeValue() (*AttributeValue, error) {
+	attVal := new(AttributeValue)
+	attVal.Pos = p.position()
+
+	attName, err := p.parseAttributeName()
+	if err != nil {
+		return nil, err
+	}
+	attVal.AttributeName = attName
+
+	t := p.scan()
+	switch {
+	case t.isKeyword(keywordNodeMessageRelation):
+		attVal.AttributeKind = AttributeNodeMessageRelation
+	case t.isKeyword(keywordNodeSignalRelation):
+		attVal.AttributeKind = AttributeNodeSignalRelation
+	case t.isKeyword(keywordNodeEnvVarRelation):
+		attVal.AttributeKind = AttributeNodeEnvVarRelation
+	default:
+		return nil, p.errorf("expected node message, node signal or node envvar relation keyword")
+	}
+
+	nodeName, err := p.parseNodeName()
+	if err != nil {
+		return nil, err
+	}
+	attVal.NodeName = nodeName
+
+	switch attVal.AttributeKind {
+	case AttributeNodeMessageRelation:
+		msgID, err := p.parseMessageID()
+		if err != nil {
+			return nil, err
+		}
+		attVal.MessageID = msgID
+
+	case AttributeNodeSignalRelation:
+		if !p.scan().isKeyword(keywordSignal) {
+			return nil, p.errorf("expected signal keyword")
+		}
+		msgID, err := p.parseMessageID()
+		if err != nil {
+			return nil, err
+		}
+		attVal.MessageID = msgID
+		sigName, err := p.parseSignalName()
+		if err != nil {
+			return nil, err
+		}
+		attVal.SignalName = sigName
+
+	case AttributeNodeEnvVarRelation:
+		envVarName, err := p.parseEnvVarName()
+		if err != nil {
+			return nil, err
+		}
+		attVal.EnvVarName = envVarName
+	}
+
+	if err := p.parseAttributeValueData(attVal); err != nil {
+		return nil, err
+	}
+
+	return attVal, nil
+}
+
+// parseAttributeValueData parses the value of an attribute assignment.
+func (p *Parser) parseAttributeValueData(attVal *AttributeValue) error {
+	t := p.scan()
 	if t.isString() {
 		attVal.ValueString = t.value
 		attVal.Type = AttributeValueString
@@ -1428,7 +1608,7 @@ func (p *Parser) parseAttributeValue() (*AttributeValue, error) {
 		if strings.HasPrefix(t.value, "0x") || strings.HasPrefix(t.value, "0X") {
 			hexVal, err := p.parseHexInt(t.value)
 			if err != nil {
-				return nil, p.errorf("cannot parse hex attribute value as int")
+				return p.errorf("cannot parse hex attribute value as int")
 			}
 			attVal.ValueHex = hexVal
 			attVal.Type = AttributeValueHex
@@ -1436,7 +1616,7 @@ func (p *Parser) parseAttributeValue() (*AttributeValue, error) {
 		} else if strings.Contains(t.value, ".") {
 			floatVal, err := p.parseDouble(t.value)
 			if err != nil {
-				return nil, p.errorf("cannot parse float attribute value as double")
+				return p.errorf("cannot parse float attribute value as double")
 			}
 			attVal.ValueFloat = floatVal
 			attVal.Type = AttributeValueFloat
@@ -1444,21 +1624,17 @@ func (p *Parser) parseAttributeValue() (*AttributeValue, error) {
 		} else {
 			invVal, err := p.parseInt(t.value)
 			if err != nil {
-				return nil, p.errorf("cannot parse int attribute value as int")
+				return p.errorf("cannot parse int attribute value as int")
 			}
 			attVal.ValueInt = invVal
 			attVal.Type = AttributeValueInt
 		}
 
 	} else {
-		return nil, p.errorf("expected attribute value")
+		return p.errorf("expected attribute value")
 	}
 
-	if err := p.expectSyntax(syntaxSemicolon); err != nil {
-		return nil, err
-	}
-
-	return attVal, nil
+	return p.expectSyntax(syntaxSemicolon)
 }
 
 func (p *Parser) parseValueEncoding() (*ValueEncoding, error) {
@@ -1546,6 +1722,7 @@ func (p *Parser) parseSignalGroup() (*SignalGroup, error) {
 	for {
 		t = p.scan()
 		if !t.isIdent() {
+			p.unscan()
 			break
 		}
 		sigGroup.SignalNames = append(sigGroup.SignalNames, t.value)
@@ -1573,7 +1750,11 @@ func (p *Parser) parseSignalExtValueType() (*SignalExtValueType, error) {
 	}
 	valType.SignalName = sigName
 
+	// the colon is not in the specification, but it is written by the most common tools
 	t := p.scan()
+	if t.isSyntax(syntaxColon) {
+		t = p.scan()
+	}
 	if !t.isNumber() {
 		return nil, p.errorf("expected signal extended value type")
 	}
@@ -1623,6 +1804,7 @@ func (p *Parser) parseExtendedMuxRange() (*ExtendedMuxRange, error) {
 
 func (p *Parser) parseExtendedMux() (*ExtendedMux, error) {
 	extMux := new(ExtendedMux)
+	extMux.Pos = p.position()
 
 	msgID, err := p.parseMessageID()
 	if err != nil {
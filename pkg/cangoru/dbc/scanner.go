@@ -1,11 +1,10 @@
 package dbc
 
 import (
-	"bufio"
 	"fmt"
-	"io"
 	"strings"
 	"unicode"
+	"unicode/utf8"
 )
 
 const eof = rune(0)
@@ -15,7 +14,7 @@ func isEOF(ch rune) bool {
 }
 
 func isSpace(ch rune) bool {
-	return ch == ' ' || ch == '\t' || ch == '\n'
+	return ch == ' ' || ch == '\t' || ch == '\n' || ch == '\r'
 }
 
 func isLetter(ch rune) bool {
@@ -35,72 +34,100 @@ func isAlphaNumeric(ch rune) bool {
 }
 
 type scanner struct {
-	r     *bufio.Reader
 	input string
 
+	// pos is the byte offset of the next rune to read
 	pos   int
 	value string
+	// lastSize is the size of the last rune read, 0 if the end of the input was reached
+	lastSize int
+
+	// line and col are the position of the byte offset lineOffset,
+	// they are moved forward token by token
+	lineOffset int
+	line       int
+	col        int
 }
 
-func newScanner(r io.Reader, str string) *scanner {
+func newScanner(str string) *scanner {
 	return &scanner{
-		r:     bufio.NewReader(r),
 		input: str,
 
 		pos:   0,
 		value: "",
+
+		lineOffset: 0,
+		line:       1,
+		col:        1,
 	}
 }
 
 func (s *scanner) read() rune {
-	ch, _, err := s.r.ReadRune()
-	if err != nil {
+	if s.pos >= len(s.input) {
+		s.lastSize = 0
 		return eof
 	}
+	ch, size := utf8.DecodeRuneInString(s.input[s.pos:])
 
-	s.pos++
+	s.value += s.input[s.pos : s.pos+size]
 
-	s.value += string(ch)
+	s.pos += size
+	s.lastSize = size
 
 	return ch
 }
 
 func (s *scanner) unread() {
-	_ = s.r.UnreadRune()
+	if s.lastSize == 0 {
+		// nothing to unread at the end of the input
+		return
+	}
 
-	s.pos--
+	s.pos -= s.lastSize
 
-	s.value = s.value[:len(s.value)-1]
+	s.value = s.value[:len(s.value)-s.lastSize]
+	s.lastSize = 0
 }
 
-func (s *scanner) getPosition() (int, int) {
-	col := 1
-	line := 1
+// peekNumber returns true if the next rune is a number, without reading it.
+func (s *scanner) peekNumber() bool {
+	next, _ := utf8.DecodeRuneInString(s.input[s.pos:])
+	return isNumber(next)
+}
 
-	for _, ch := range s.input[:s.pos-len(s.value)] {
+// getPosition returns the byte offset, the column and the line of the current token.
+func (s *scanner) getPosition() (int, int, int) {
+	start := s.pos - len(s.value)
+
+	for _, ch := range s.input[s.lineOffset:start] {
 		if ch == '\n' {
-			col = 1
-			line++
+			s.col = 1
+			s.line++
 			continue
 		}
-		col++
+		s.col++
 	}
+	s.lineOffset = start
 
-	return col, line
+	return start, s.col, s.line
 }
 
+// stringUnescaper replaces the escaped quotes and backslashes of a string.
+var stringUnescaper = strings.NewReplacer(`\\`, `\`, `\"`, `"`)
+
 func (s *scanner) emitToken(kind tokenKind) *token {
 	val := s.value
 	if kind == tokenString {
-		val = s.value[1 : len(s.value)-1]
+		val = stringUnescaper.Replace(strings.ReplaceAll(s.value[1:len(s.value)-1], "\r\n", "\n"))
 	}
 
-	col, line := s.getPosition()
+	start, col, line := s.getPosition()
 
 	t := &token{
 		kind:     kind,
 		kindName: tokenNames[kind],
 		value:    val,
+		start:    start,
 		col:      col,
 		line:     line,
 	}
@@ -120,12 +147,13 @@ func (s *scanner) emitErrorToken(msg string) *token {
 		val = fmt.Sprintf("%s : %s", msg, s.value)
 	}
 
-	col, line := s.getPosition()
+	start, col, line := s.getPosition()
 
 	t := &token{
 		kind:     tokenError,
 		kindName: tokenNames[tokenError],
 		value:    val,
+		start:    start,
 		col:      col,
 		line:     line,
 	}
@@ -219,6 +247,7 @@ func (s *scanner) scanNumber() *token {
 	firstCh := s.read()
 	hasMore := false
 	isRange := false
+	hasExp := false
 
 loop:
 	for {
@@ -229,8 +258,17 @@ loop:
 		case firstCh == '0' && (ch == 'x' || ch == 'X'):
 			return s.scanHexNumber()
 
+		case (ch == 'e' || ch == 'E') && !isRange && !hasExp:
+			// exponent of a number in scientific notation, like 1E-005
+			hasExp = true
+			hasMore = true
+			if sign := s.read(); sign != '-' && sign != '+' {
+				s.unread()
+			}
+
 		case !isNumber(ch) && ch != '.':
-			if ch == '-' && isNumber(firstCh) && !isRange {
+			// a range like 2-5, not a number followed by the minus sign like the value type of a signal
+			if ch == '-' && isNumber(firstCh) && !isRange && s.peekNumber() {
 				isRange = true
 				continue
 			}
@@ -280,6 +318,12 @@ func (s *scanner) scanString() *token {
 
 		case ch == '"':
 			return s.emitToken(tokenString)
+
+		case ch == '\\':
+			// an escaped quote or backslash does not close the string
+			if isEOF(s.read()) {
+				return s.emitErrorToken(`unclosed string, missing closing "`)
+			}
 		}
 	}
 }
@@ -63,8 +63,9 @@ func (t *token) isMuxIndicator() bool {
 	return t.kind == tokenMuxIndicator
 }
 
+// isIdent returns true also for the mux indicators, since names like M or m1 are valid identifiers.
 func (t *token) isIdent() bool {
-	return t.kind == tokenIdent
+	return t.kind == tokenIdent || t.kind == tokenMuxIndicator
 }
 
 func (t *token) isString() bool {
@@ -42,8 +42,11 @@ func (w *Writer) formatDouble(val float64) string {
 	return strconv.FormatFloat(val, 'f', -1, 64)
 }
 
+// stringEscaper escapes the quotes and backslashes of a string.
+var stringEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+
 func (w *Writer) formatString(val string) string {
-	return "\"" + val + "\""
+	return "\"" + stringEscaper.Replace(val) + "\""
 }
 
 func (w *Writer) formatInt(val int) string {
@@ -83,8 +86,11 @@ func (w *Writer) Write(ast *DBC) string {
 	writeSlice(ast.SignalTypes, w.writeSignalType, w.newLine)
 	writeSlice(ast.Comments, w.writeComment, w.newLine)
 	writeSlice(ast.Attributes, w.writeAttribute, w.newLine)
+	writeSlice(ast.RelationAttributes, w.writeAttribute, w.newLine)
 	writeSlice(ast.AttributeDefaults, w.writeAttributeDefault, w.newLine)
+	writeSlice(ast.RelationAttributeDefaults, w.writeRelationAttributeDefault, w.newLine)
 	writeSlice(ast.AttributeValues, w.writeAttributeValue, w.newLine)
+	writeSlice(ast.RelationAttributeValues, w.writeAttributeValue, w.newLine)
 	writeSlice(ast.ValueEncodings, w.writeValueEncoding, w.newLine)
 	writeSlice(ast.SignalTypeRefs, w.writeSignalTypeRef, w.newLine)
 	writeSlice(ast.SignalGroups, w.writeSignalGroup, w.newLine)
@@ -133,7 +139,7 @@ func (w *Writer) writeNodes(nodes *Nodes) {
 }
 
 func (w *Writer) writeValueDescription(valDesc *ValueDescription) {
-	w.print(" %s %s", w.formatUint(valDesc.ID), w.formatString(valDesc.Name))
+	w.print(" %s %s", strconv.FormatInt(valDesc.ID, 10), w.formatString(valDesc.Name))
 }
 
 func (w *Writer) writeValueTable(valTable *ValueTable) {
@@ -202,7 +208,10 @@ func (w *Writer) writeSignal(sig *Signal) {
 
 func (w *Writer) writeMessageTransmitter(msgTx *MessageTransmitter) {
 	w.print("%s %s :", getKeyword(keywordMessageTransmitter), w.formatUint(msgTx.MessageID))
-	for _, tx := range msgTx.Transmitters {
+	for idx, tx := range msgTx.Transmitters {
+		if idx != 0 {
+			w.print(",")
+		}
 		w.print(" %s", tx)
 	}
 	w.println(";")
@@ -233,8 +242,11 @@ func (w *Writer) writeEnvVar(envVar *EnvVar) {
 		}
 	}
 
-	for _, node := range envVar.AccessNodes {
-		w.print(" , %s", node)
+	for idx, node := range envVar.AccessNodes {
+		if idx != 0 {
+			w.print(",")
+		}
+		w.print(" %s", node)
 	}
 
 	w.println(";")
@@ -253,9 +265,9 @@ func (w *Writer) writeSignalType(sigTyp *SignalType) {
 
 	switch sigTyp.ByteOrder {
 	case SignalLittleEndian:
-		w.print("1 ")
+		w.print("1")
 	case SignalBigEndian:
-		w.print("0 ")
+		w.print("0")
 	}
 
 	switch sigTyp.ValueType {
@@ -289,7 +301,12 @@ func (w *Writer) writeComment(com *Comment) {
 }
 
 func (w *Writer) writeAttribute(att *Attribute) {
-	w.print("%s ", getKeyword(keywordAttribute))
+	switch att.Kind {
+	case AttributeNodeMessageRelation, AttributeNodeSignalRelation, AttributeNodeEnvVarRelation:
+		w.print("%s ", getKeyword(keywordRelationAttribute))
+	default:
+		w.print("%s ", getKeyword(keywordAttribute))
+	}
 
 	switch att.Kind {
 	case AttributeNode:
@@ -300,6 +317,12 @@ func (w *Writer) writeAttribute(att *Attribute) {
 		w.print("%s ", getKeyword(keywordSignal))
 	case AttributeEnvVar:
 		w.print("%s ", getKeyword(keywordEnvVar))
+	case AttributeNodeMessageRelation:
+		w.print("%s ", getKeyword(keywordNodeMessageRelation))
+	case AttributeNodeSignalRelation:
+		w.print("%s ", getKeyword(keywordNodeSignalRelation))
+	case AttributeNodeEnvVarRelation:
+		w.print("%s ", getKeyword(keywordNodeEnvVarRelation))
 	}
 
 	w.print(`"%s" `, att.Name)
@@ -328,7 +351,15 @@ func (w *Writer) writeAttribute(att *Attribute) {
 
 func (w *Writer) writeAttributeDefault(attDef *AttributeDefault) {
 	w.print(`%s "%s" `, getKeyword(keywordAttributeDefault), attDef.AttributeName)
+	w.writeAttributeDefaultValue(attDef)
+}
 
+func (w *Writer) writeRelationAttributeDefault(attDef *AttributeDefault) {
+	w.print(`%s "%s" `, getKeyword(keywordRelationAttributeDefault), attDef.AttributeName)
+	w.writeAttributeDefaultValue(attDef)
+}
+
+func (w *Writer) writeAttributeDefaultValue(attDef *AttributeDefault) {
 	switch attDef.Type {
 	case AttributeDefaultInt:
 		w.print(w.formatInt(attDef.ValueInt))
@@ -344,7 +375,12 @@ func (w *Writer) writeAttributeDefault(attDef *AttributeDefault) {
 }
 
 func (w *Writer) writeAttributeValue(attVal *AttributeValue) {
-	w.print(`%s "%s "`, getKeyword(keywordAttributeValue), attVal.AttributeName)
+	switch attVal.AttributeKind {
+	case AttributeNodeMessageRelation, AttributeNodeSignalRelation, AttributeNodeEnvVarRelation:
+		w.print(`%s "%s" `, getKeyword(keywordRelationAttributeValue), attVal.AttributeName)
+	default:
+		w.print(`%s "%s" `, getKeyword(keywordAttributeValue), attVal.AttributeName)
+	}
 
 	switch attVal.AttributeKind {
 	case AttributeNode:
@@ -355,6 +391,13 @@ func (w *Writer) writeAttributeValue(attVal *AttributeValue) {
 		w.print("%s %s %s ", getKeyword(keywordSignal), w.formatUint(attVal.MessageID), attVal.SignalName)
 	case AttributeEnvVar:
 		w.print("%s %s ", getKeyword(keywordEnvVar), attVal.EnvVarName)
+	case AttributeNodeMessageRelation:
+		w.print("%s %s %s ", getKeyword(keywordNodeMessageRelation), attVal.NodeName, w.formatUint(attVal.MessageID))
+	case AttributeNodeSignalRelation:
+		w.print("%s %s %s %s %s ", getKeyword(keywordNodeSignalRelation), attVal.NodeName,
+			getKeyword(keywordSignal), w.formatUint(attVal.MessageID), attVal.SignalName)
+	case AttributeNodeEnvVarRelation:
+		w.print("%s %s %s ", getKeyword(keywordNodeEnvVarRelation), attVal.NodeName, attVal.EnvVarName)
 	}
 
 	switch attVal.Type {
@@ -389,7 +432,7 @@ func (w *Writer) writeValueEncoding(valEnc *ValueEncoding) {
 
 func (w *Writer) writeSignalTypeRef(sigTypRef *SignalTypeRef) {
 	w.println("%s %s %s : %s;",
-		getKeyword(keywordSignalType),
+		getKeyword(keywordSignalTypeRef),
 		w.formatUint(sigTypRef.MessageID),
 		sigTypRef.SignalName,
 		sigTypRef.TypeName,
@@ -411,7 +454,7 @@ func (w *Writer) writeSignalGroup(sigGroup *SignalGroup) {
 }
 
 func (w *Writer) writeSignalExtValueType(sigExtValTyp *SignalExtValueType) {
-	w.print("%s %s %s ",
+	w.print("%s %s %s : ",
 		getKeyword(keywordSignalValueType),
 		w.formatUint(sigExtValTyp.MessageID),
 		sigExtValTyp.SignalName,
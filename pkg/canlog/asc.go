@@ -0,0 +1,525 @@
+package canlog
+
+import (
+	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Flags of the CANFD lines of ASC files.
+const (
+	ascFDRemoteFlag = 0x0010
+	ascFDEDLFlag    = 0x1000
+	ascFDBRSFlag    = 0x2000
+	ascFDESIFlag    = 0x4000
+)
+
+// ascWriteDateLayout is the layout of the dates written in the header of ASC files.
+const ascWriteDateLayout = "Mon Jan 02 03:04:05.000 pm 2006"
+
+// ascDateLayouts are the layouts of the dates accepted in the header of ASC files.
+var ascDateLayouts = []string{
+	"Mon Jan _2 03:04:05.000 pm 2006",
+	"Mon Jan _2 03:04:05 pm 2006",
+	"Mon Jan _2 15:04:05.000 2006",
+	"Mon Jan _2 15:04:05 2006",
+}
+
+func parseASCDate(str string) (time.Time, bool) {
+	str = strings.Join(strings.Fields(str), " ")
+	for _, layout := range ascDateLayouts {
+		if date, err := time.ParseInLocation(layout, str, time.Local); err == nil {
+			return date, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// ASCReader reads the ASCII trace files (.asc) of Vector CANalyzer/CANoe.
+// Lines that are not CAN frames (e.g. events and statistics) are skipped.
+type ASCReader struct {
+	scanner *bufio.Scanner
+	line    int
+
+	start    time.Time
+	base     int
+	relative bool
+	lastTime time.Duration
+}
+
+// NewASCReader returns a new ASC trace reader.
+func NewASCReader(r io.Reader) *ASCReader {
+	return &ASCReader{
+		scanner: bufio.NewScanner(r),
+		line:    0,
+
+		start:    time.Unix(0, 0),
+		base:     16,
+		relative: false,
+		lastTime: 0,
+	}
+}
+
+func (r *ASCReader) getError(err error) error {
+	return fmt.Errorf("line %d: %v", r.line, err)
+}
+
+// Read reads the next frame of the trace.
+func (r *ASCReader) Read() (*Frame, error) {
+	for r.scanner.Scan() {
+		r.line++
+
+		fields := strings.Fields(r.scanner.Text())
+		if len(fields) == 0 || strings.HasPrefix(fields[0], "//") {
+			continue
+		}
+
+		switch strings.ToLower(fields[0]) {
+		case "date":
+			if date, ok := parseASCDate(strings.Join(fields[1:], " ")); ok {
+				r.start = date
+			}
+			continue
+
+		case "base":
+			if err := r.parseBase(fields); err != nil {
+				return nil, r.getError(err)
+			}
+			continue
+		}
+
+		frame, err := r.parseLine(fields)
+		if err != nil {
+			return nil, r.getError(err)
+		}
+		if frame != nil {
+			return frame, nil
+		}
+	}
+
+	if err := r.scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return nil, io.EOF
+}
+
+// parseBase parses the "base hex|dec timestamps absolute|relative" line.
+func (r *ASCReader) parseBase(fields []string) error {
+	if len(fields) < 2 {
+		return errors.New("invalid base line")
+	}
+
+	switch strings.ToLower(fields[1]) {
+	case "hex":
+		r.base = 16
+	case "dec":
+		r.base = 10
+	default:
+		return fmt.Errorf("invalid base %q", fields[1])
+	}
+
+	if len(fields) >= 4 && strings.ToLower(fields[2]) == "timestamps" {
+		r.relative = strings.ToLower(fields[3]) == "relative"
+	}
+
+	return nil
+}
+
+// parseLine parses a line starting with a timestamp.
+// It returns a nil frame if the line is not a CAN frame.
+func (r *ASCReader) parseLine(fields []string) (*Frame, error) {
+	if len(fields) < 3 {
+		return nil, nil
+	}
+
+	ts, err := parseSeconds(fields[0])
+	if err != nil {
+		// not a timestamped line (e.g. Begin/End Triggerblock)
+		return nil, nil
+	}
+
+	var frame *Frame
+	if strings.EqualFold(fields[1], "CANFD") {
+		frame, err = r.parseFDLine(fields[2:])
+	} else {
+		frame, err = r.parseClassicLine(fields[1:])
+	}
+	if err != nil || frame == nil {
+		return nil, err
+	}
+
+	if r.relative {
+		ts += r.lastTime
+	}
+	r.lastTime = ts
+	frame.Timestamp = r.start.Add(ts)
+
+	return frame, nil
+}
+
+func (r *ASCReader) parseChannel(str string) (int, bool) {
+	channel, err := strconv.Atoi(str)
+	if err != nil || channel < 0 {
+		return 0, false
+	}
+	return channel, true
+}
+
+// parseID parses an id, extended ids have the x suffix.
+func (r *ASCReader) parseID(str string) (uint32, bool, bool) {
+	extended := false
+	if strings.HasSuffix(str, "x") || strings.HasSuffix(str, "X") {
+		extended = true
+		str = str[:len(str)-1]
+	}
+
+	id, err := strconv.ParseUint(str, r.base, 32)
+	if err != nil {
+		return 0, false, false
+	}
+
+	if extended {
+		return uint32(id) & canEFFMask, true, true
+	}
+	return uint32(id), false, id <= canSFFMask
+}
+
+func parseASCDirection(str string) (Direction, error) {
+	switch strings.ToLower(str) {
+	case "rx":
+		return DirectionRx, nil
+	case "tx", "txrq":
+		return DirectionTx, nil
+	}
+	return DirectionRx, fmt.Errorf("invalid direction %q", str)
+}
+
+func (r *ASCReader) parseBytes(fields []string, count int) ([]byte, error) {
+	if len(fields) < count {
+		return nil, fmt.Errorf("expected %d data bytes, found %d", count, len(fields))
+	}
+
+	data := make([]byte, count)
+	for i := 0; i < count; i++ {
+		b, err := strconv.ParseUint(fields[i], r.base, 8)
+		if err != nil {
+			return nil, fmt.Errorf("invalid data byte %q", fields[i])
+		}
+		data[i] = byte(b)
+	}
+
+	return data, nil
+}
+
+// parseClassicLine parses the fields after the timestamp of a CAN line:
+// <channel> <id> <dir> d <dlc> <data...> or <channel> <id> <dir> r [dlc] or <channel> ErrorFrame.
+func (r *ASCReader) parseClassicLine(fields []string) (*Frame, error) {
+	channel, ok := r.parseChannel(fields[0])
+	if !ok {
+		return nil, nil
+	}
+
+	if strings.EqualFold(fields[1], "ErrorFrame") {
+		return &Frame{Channel: channel, Error: true, Data: []byte{}}, nil
+	}
+
+	name := ""
+	id, extended, ok := r.parseID(fields[1])
+	if !ok {
+		// symbolic names replace the id, which is written at the end of the line
+		id, extended, ok = parseASCSymbolicID(fields)
+		if !ok {
+			// other events of the channel (e.g. statistics)
+			return nil, nil
+		}
+		name = fields[1]
+	}
+
+	if len(fields) < 4 {
+		return nil, errors.New("invalid CAN line")
+	}
+
+	dir, err := parseASCDirection(fields[2])
+	if err != nil {
+		return nil, err
+	}
+
+	frame := &Frame{
+		Channel:   channel,
+		Direction: dir,
+		ID:        id,
+		Extended:  extended,
+		Name:      name,
+	}
+
+	switch strings.ToLower(fields[3]) {
+	case "r":
+		frame.Remote = true
+		frame.Data = []byte{}
+		if len(fields) > 4 {
+			if dlc, err := strconv.ParseUint(fields[4], 16, 8); err == nil && dlc <= 15 {
+				frame.DLC = uint8(dlc)
+			}
+		}
+		return frame, nil
+
+	case "d":
+		if len(fields) < 5 {
+			return nil, errors.New("missing data length code")
+		}
+		dlc, err := strconv.ParseUint(fields[4], 16, 8)
+		if err != nil || dlc > 15 {
+			return nil, fmt.Errorf("invalid data length code %q", fields[4])
+		}
+		frame.DLC = uint8(dlc)
+
+		frame.Data, err = r.parseBytes(fields[5:], DLCToLength(frame.DLC, false))
+		if err != nil {
+			return nil, err
+		}
+		return frame, nil
+	}
+
+	return nil, fmt.Errorf("invalid frame type %q", fields[3])
+}
+
+// parseASCSymbolicID parses the decimal id written as "ID = <id>[x]" at the end of the lines with a symbolic name.
+func parseASCSymbolicID(fields []string) (uint32, bool, bool) {
+	for i := len(fields) - 3; i >= 0; i-- {
+		if fields[i] != "ID" || fields[i+1] != "=" {
+			continue
+		}
+
+		idStr := fields[i+2]
+		extended := strings.HasSuffix(idStr, "x")
+		id, err := strconv.ParseUint(strings.TrimSuffix(idStr, "x"), 10, 32)
+		if err != nil {
+			return 0, false, false
+		}
+		if extended {
+			return uint32(id) & canEFFMask, true, true
+		}
+		return uint32(id), false, id <= canSFFMask
+	}
+
+	return 0, false, false
+}
+
+// parseFDLine parses the fields after CANFD of a CAN FD line:
+// <channel> <dir> <id> [symbolic name] <brs> <esi> <dlc> <data length> <data...> <duration> <length> <flags> ...
+// or <channel> <dir> ErrorFrame ...
+func (r *ASCReader) parseFDLine(fields []string) (*Frame, error) {
+	if len(fields) < 3 {
+		return nil, errors.New("invalid CANFD line")
+	}
+
+	channel, ok := r.parseChannel(fields[0])
+	if !ok {
+		return nil, fmt.Errorf("invalid channel %q", fields[0])
+	}
+
+	dir, err := parseASCDirection(fields[1])
+	if err != nil {
+		return nil, err
+	}
+
+	if strings.EqualFold(fields[2], "ErrorFrame") {
+		return &Frame{Channel: channel, Direction: dir, Error: true, FD: true, Data: []byte{}}, nil
+	}
+
+	id, extended, ok := r.parseID(fields[2])
+	if !ok {
+		return nil, fmt.Errorf("invalid frame id %q", fields[2])
+	}
+
+	fields = fields[3:]
+	name := ""
+	// optional symbolic name of the message
+	if len(fields) > 0 && fields[0] != "0" && fields[0] != "1" {
+		name = fields[0]
+		fields = fields[1:]
+	}
+	if len(fields) < 4 {
+		return nil, errors.New("invalid CANFD line")
+	}
+
+	frame := &Frame{
+		Channel:   channel,
+		Direction: dir,
+		ID:        id,
+		Extended:  extended,
+		Name:      name,
+		BRS:       fields[0] == "1",
+		ESI:       fields[1] == "1",
+	}
+
+	dlc, err := strconv.ParseUint(fields[2], 16, 8)
+	if err != nil || dlc > 15 {
+		return nil, fmt.Errorf("invalid data length code %q", fields[2])
+	}
+	frame.DLC = uint8(dlc)
+
+	length, err := strconv.Atoi(fields[3])
+	if err != nil || length < 0 || length > MaxFDLength {
+		return nil, fmt.Errorf("invalid data length %q", fields[3])
+	}
+
+	frame.Data, err = r.parseBytes(fields[4:], length)
+	if err != nil {
+		return nil, err
+	}
+
+	// the flags tell if the frame is a CAN FD one, missing flags default to CAN FD
+	frame.FD = true
+	if rest := fields[4+length:]; len(rest) >= 3 {
+		if flags, err := strconv.ParseUint(rest[2], 16, 32); err == nil {
+			frame.FD = flags&ascFDEDLFlag != 0
+			frame.Remote = flags&ascFDRemoteFlag != 0
+		}
+	}
+	if frame.Remote {
+		frame.Data = []byte{}
+	}
+
+	return frame, nil
+}
+
+// ASCWriter writes the ASCII trace files (.asc) of Vector CANalyzer/CANoe.
+// Timestamps are written relative to the first frame, whose time is used as date of the trace.
+// The frame names are written as symbolic names, followed by the id at the end of the classic CAN lines.
+type ASCWriter struct {
+	w *bufio.Writer
+
+	start   time.Time
+	started bool
+}
+
+// NewASCWriter returns a new ASC trace writer.
+func NewASCWriter(w io.Writer) *ASCWriter {
+	return &ASCWriter{
+		w: bufio.NewWriter(w),
+
+		started: false,
+	}
+}
+
+func (w *ASCWriter) writeHeader(start time.Time) {
+	// the date has a millisecond resolution, the timestamps are relative to it
+	w.start = start.Truncate(time.Millisecond)
+	w.started = true
+
+	date := w.start.In(time.Local).Format(ascWriteDateLayout)
+	fmt.Fprintf(w.w, "date %s\n", date)
+	fmt.Fprintln(w.w, "base hex  timestamps absolute")
+	fmt.Fprintln(w.w, "internal events logged")
+	fmt.Fprintln(w.w, "// version 9.0.0")
+	fmt.Fprintf(w.w, "Begin Triggerblock %s\n", date)
+	fmt.Fprintf(w.w, "%11.6f Start of measurement\n", 0.0)
+}
+
+func formatASCBytes(data []byte) string {
+	bytes := make([]string, len(data))
+	for i, b := range data {
+		bytes[i] = fmt.Sprintf("%02X", b)
+	}
+	return strings.Join(bytes, " ")
+}
+
+// Write writes a frame into the trace.
+func (w *ASCWriter) Write(frame *Frame) error {
+	if !w.started {
+		w.writeHeader(frame.Timestamp)
+	}
+
+	ts := frame.Timestamp.Sub(w.start)
+	tsStr := fmt.Sprintf("%4d.%06d", ts/time.Second, (ts%time.Second)/time.Microsecond)
+	if ts < 0 {
+		tsStr = fmt.Sprintf("%11.6f", ts.Seconds())
+	}
+
+	channel := frame.Channel
+	if channel <= 0 {
+		channel = 1
+	}
+
+	id := fmt.Sprintf("%X", frame.ID)
+	if frame.Extended {
+		id += "x"
+	}
+
+	var line string
+	switch {
+	case frame.Error && frame.FD:
+		line = fmt.Sprintf("CANFD %3d %-4s ErrorFrame", channel, frame.Direction)
+	case frame.Error:
+		line = fmt.Sprintf("%d  ErrorFrame", channel)
+
+	case frame.FD || len(frame.Data) > MaxClassicLength:
+		flags := ascFDEDLFlag
+		brs, esi := 0, 0
+		if frame.BRS {
+			flags |= ascFDBRSFlag
+			brs = 1
+		}
+		if frame.ESI {
+			flags |= ascFDESIFlag
+			esi = 1
+		}
+		data := formatASCBytes(frame.Data)
+		if len(data) > 0 {
+			data += " "
+		}
+		line = fmt.Sprintf("CANFD %3d %-4s %8s %32s %d %d %x %2d %s%8d %4d %8X %8d %8d %8d %8d %8d",
+			channel, frame.Direction, id, frame.Name, brs, esi, LengthToDLC(len(frame.Data)), len(frame.Data), data, 0, 0, flags, 0, 0, 0, 0, 0)
+
+	case frame.Remote:
+		line = fmt.Sprintf("%d  %-15s %-4s r %X", channel, w.classicID(frame, id), frame.Direction, frame.DLC)
+		line += w.symbolicID(frame)
+
+	default:
+		dlc := frame.DLC
+		if int(dlc) < len(frame.Data) {
+			dlc = uint8(len(frame.Data))
+		}
+		line = fmt.Sprintf("%d  %-15s %-4s d %X %s", channel, w.classicID(frame, id), frame.Direction, dlc, formatASCBytes(frame.Data))
+		line += w.symbolicID(frame)
+	}
+
+	_, err := fmt.Fprintf(w.w, "%s %s\n", tsStr, strings.TrimRight(line, " "))
+	return err
+}
+
+// classicID returns the id column of the classic CAN lines, which is replaced by the symbolic name.
+func (w *ASCWriter) classicID(frame *Frame, id string) string {
+	if len(frame.Name) > 0 {
+		return frame.Name
+	}
+	return id
+}
+
+// symbolicID returns the end of the classic CAN lines with a symbolic name, containing the decimal id.
+func (w *ASCWriter) symbolicID(frame *Frame) string {
+	if len(frame.Name) == 0 {
+		return ""
+	}
+
+	id := strconv.FormatUint(uint64(frame.ID), 10)
+	if frame.Extended {
+		id += "x"
+	}
+	return "  Length = 0 BitCount = 0 ID = " + id
+}
+
+// Close writes the end of the trace and flushes it, the underlying writer is not closed.
+func (w *ASCWriter) Close() error {
+	if !w.started {
+		w.writeHeader(time.Now())
+	}
+	fmt.Fprintln(w.w, "End TriggerBlock")
+	return w.w.Flush()
+}
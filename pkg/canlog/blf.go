@@ -0,0 +1,590 @@
+package canlog
+
+import (
+	"bufio"
+	"bytes"
+	"compress/zlib"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"time"
+)
+
+// Sizes of the BLF structures.
+const (
+	blfFileHeaderSize    = 144
+	blfObjHeaderBaseSize = 16
+	blfObjHeaderV1Size   = 16
+	blfContainerSize     = 16
+	blfMaxContainerSize  = 128 * 1024
+	blfTimestampOffset   = 24
+	blfObjHeaderFlagsPos = 16
+)
+
+// Types of the BLF objects.
+const (
+	blfCANMessage     = 1
+	blfCANError       = 2
+	blfLogContainer   = 10
+	blfCANErrorExt    = 73
+	blfCANMessage2    = 86
+	blfCANFDMessage   = 100
+	blfCANFDMessage64 = 101
+)
+
+// Compression methods of the BLF log containers.
+const (
+	blfNoCompression = 0
+	blfZlibDeflate   = 2
+)
+
+// Flags of the BLF objects.
+const (
+	blfTimeTenMicros = 0x1
+	blfTimeOneNanos  = 0x2
+
+	blfCANMsgExtFlag = 0x80000000
+	blfDirTxFlag     = 0x1
+	blfRemoteFlag    = 0x80
+
+	blfFDEDLFlag = 0x1
+	blfFDBRSFlag = 0x2
+	blfFDESIFlag = 0x4
+
+	blfFD64RemoteFlag = 0x0010
+	blfFD64EDLFlag    = 0x1000
+	blfFD64BRSFlag    = 0x2000
+	blfFD64ESIFlag    = 0x4000
+)
+
+var (
+	blfFileSignature = []byte("LOGG")
+	blfObjSignature  = []byte("LOBJ")
+)
+
+// readSystemTime reads a Windows SYSTEMTIME structure.
+func readSystemTime(b []byte) time.Time {
+	u16 := func(i int) int {
+		return int(binary.LittleEndian.Uint16(b[i*2:]))
+	}
+	year, month, day := u16(0), u16(1), u16(3)
+	if year == 0 {
+		return time.Unix(0, 0)
+	}
+	return time.Date(year, time.Month(month), day, u16(4), u16(5), u16(6), u16(7)*int(time.Millisecond), time.Local)
+}
+
+// putSystemTime writes a Windows SYSTEMTIME structure.
+func putSystemTime(b []byte, t time.Time) {
+	t = t.In(time.Local)
+	fields := []int{t.Year(), int(t.Month()), int(t.Weekday()), t.Day(), t.Hour(), t.Minute(), t.Second(), t.Nanosecond() / int(time.Millisecond)}
+	for i, f := range fields {
+		binary.LittleEndian.PutUint16(b[i*2:], uint16(f))
+	}
+}
+
+// BLFReader reads the Binary Logging Format files (.blf) of Vector tools.
+// Objects that are not CAN frames are skipped.
+type BLFReader struct {
+	r *bufio.Reader
+
+	headerRead bool
+	start      time.Time
+	// buf contains the uncompressed objects not parsed yet
+	buf []byte
+}
+
+// NewBLFReader returns a new BLF reader.
+func NewBLFReader(r io.Reader) *BLFReader {
+	return &BLFReader{
+		r: bufio.NewReader(r),
+
+		headerRead: false,
+		start:      time.Unix(0, 0),
+		buf:        []byte{},
+	}
+}
+
+func (r *BLFReader) readHeader() error {
+	header := make([]byte, blfObjHeaderBaseSize)
+	if _, err := io.ReadFull(r.r, header[:8]); err != nil {
+		return fmt.Errorf("invalid BLF file header: %w", err)
+	}
+	if !bytes.Equal(header[:4], blfFileSignature) {
+		return errors.New("invalid BLF file signature")
+	}
+
+	headerSize := binary.LittleEndian.Uint32(header[4:])
+	if headerSize < 72 {
+		return fmt.Errorf("invalid BLF file header size %d", headerSize)
+	}
+
+	rest := make([]byte, headerSize-8)
+	if _, err := io.ReadFull(r.r, rest); err != nil {
+		return fmt.Errorf("invalid BLF file header: %w", err)
+	}
+	// the start time follows the versions, the sizes and the object counts
+	r.start = readSystemTime(rest[40-8:])
+
+	r.headerRead = true
+	return nil
+}
+
+// readObject reads the next top level object and appends its uncompressed content to the buffer.
+func (r *BLFReader) readObject() error {
+	header := make([]byte, blfObjHeaderBaseSize)
+	if _, err := io.ReadFull(r.r, header); err != nil {
+		if errors.Is(err, io.ErrUnexpectedEOF) {
+			return fmt.Errorf("truncated BLF object header: %w", err)
+		}
+		return err
+	}
+	if !bytes.Equal(header[:4], blfObjSignature) {
+		return errors.New("invalid BLF object signature")
+	}
+
+	objSize := binary.LittleEndian.Uint32(header[8:])
+	objType := binary.LittleEndian.Uint32(header[12:])
+	if objSize < blfObjHeaderBaseSize {
+		return fmt.Errorf("invalid BLF object size %d", objSize)
+	}
+
+	data := make([]byte, objSize-blfObjHeaderBaseSize)
+	if _, err := io.ReadFull(r.r, data); err != nil {
+		return fmt.Errorf("truncated BLF object: %w", err)
+	}
+	if _, err := r.r.Discard(int(objSize % 4)); err != nil && !errors.Is(err, io.EOF) {
+		return err
+	}
+
+	if objType != blfLogContainer {
+		r.buf = append(r.buf, header...)
+		r.buf = append(r.buf, data...)
+		return nil
+	}
+
+	if len(data) < blfContainerSize {
+		return errors.New("invalid BLF log container")
+	}
+	method := binary.LittleEndian.Uint16(data)
+	data = data[blfContainerSize:]
+
+	switch method {
+	case blfNoCompression:
+		r.buf = append(r.buf, data...)
+
+	case blfZlibDeflate:
+		zr, err := zlib.NewReader(bytes.NewReader(data))
+		if err != nil {
+			return fmt.Errorf("invalid BLF log container: %w", err)
+		}
+		uncompressed, err := io.ReadAll(zr)
+		if err != nil {
+			return fmt.Errorf("invalid BLF log container: %w", err)
+		}
+		r.buf = append(r.buf, uncompressed...)
+
+	default:
+		return fmt.Errorf("unsupported BLF compression method %d", method)
+	}
+
+	return nil
+}
+
+// nextObject returns the next object of the buffer, or false if it is not complete.
+// Objects can span multiple log containers and are followed by optional padding.
+func (r *BLFReader) nextObject() ([]byte, bool, error) {
+	if len(r.buf) < blfObjHeaderBaseSize {
+		return nil, false, nil
+	}
+
+	pos := bytes.Index(r.buf[:min(len(r.buf), 8)], blfObjSignature)
+	if pos < 0 {
+		pos = bytes.Index(r.buf, blfObjSignature)
+		if pos < 0 || pos > 8 {
+			return nil, false, errors.New("cannot find the next BLF object")
+		}
+	}
+	if len(r.buf)-pos < blfObjHeaderBaseSize {
+		return nil, false, nil
+	}
+
+	objSize := int(binary.LittleEndian.Uint32(r.buf[pos+8:]))
+	if objSize < blfObjHeaderBaseSize {
+		return nil, false, fmt.Errorf("invalid BLF object size %d", objSize)
+	}
+	if len(r.buf)-pos < objSize {
+		return nil, false, nil
+	}
+
+	obj := r.buf[pos : pos+objSize]
+	r.buf = r.buf[pos+objSize:]
+
+	return obj, true, nil
+}
+
+// Read reads the next frame of the file.
+func (r *BLFReader) Read() (*Frame, error) {
+	if !r.headerRead {
+		if err := r.readHeader(); err != nil {
+			return nil, err
+		}
+	}
+
+	for {
+		obj, ok, err := r.nextObject()
+		if err != nil {
+			return nil, err
+		}
+
+		if !ok {
+			// compact the buffer before appending the next object
+			r.buf = append([]byte{}, r.buf...)
+			if err := r.readObject(); err != nil {
+				return nil, err
+			}
+			continue
+		}
+
+		frame, err := r.parseObject(obj)
+		if err != nil {
+			return nil, err
+		}
+		if frame != nil {
+			return frame, nil
+		}
+	}
+}
+
+// parseObject parses a CAN object, it returns a nil frame for the other objects.
+func (r *BLFReader) parseObject(obj []byte) (*Frame, error) {
+	headerSize := int(binary.LittleEndian.Uint16(obj[4:]))
+	objType := binary.LittleEndian.Uint32(obj[12:])
+	if headerSize < blfTimestampOffset+8 || headerSize > len(obj) {
+		return nil, nil
+	}
+
+	flags := binary.LittleEndian.Uint32(obj[blfObjHeaderFlagsPos:])
+	ts := time.Duration(binary.LittleEndian.Uint64(obj[blfTimestampOffset:]))
+	if flags&blfTimeTenMicros != 0 {
+		ts *= 10 * time.Microsecond
+	}
+	timestamp := r.start.Add(ts)
+
+	data := obj[headerSize:]
+	u16 := func(i int) uint16 { return binary.LittleEndian.Uint16(data[i:]) }
+	u32 := func(i int) uint32 { return binary.LittleEndian.Uint32(data[i:]) }
+
+	var frame *Frame
+
+	switch objType {
+	case blfCANMessage, blfCANMessage2:
+		if len(data) < 16 {
+			return nil, errors.New("truncated BLF CAN message")
+		}
+		msgFlags := data[2]
+		frame = &Frame{
+			Channel: int(u16(0)),
+			DLC:     data[3],
+			Remote:  msgFlags&blfRemoteFlag != 0,
+		}
+		if msgFlags&blfDirTxFlag != 0 {
+			frame.Direction = DirectionTx
+		}
+		frame.ID, frame.Extended = blfID(u32(4))
+		if frame.Remote {
+			frame.Data = []byte{}
+		} else {
+			frame.Data = append([]byte{}, data[8:8+DLCToLength(frame.DLC, false)]...)
+		}
+
+	case blfCANFDMessage:
+		if len(data) < 84 {
+			return nil, errors.New("truncated BLF CAN FD message")
+		}
+		msgFlags, fdFlags := data[2], data[13]
+		validBytes := min(int(data[14]), MaxFDLength)
+		frame = &Frame{
+			Channel: int(u16(0)),
+			DLC:     data[3],
+			Remote:  msgFlags&blfRemoteFlag != 0,
+			FD:      fdFlags&blfFDEDLFlag != 0,
+			BRS:     fdFlags&blfFDBRSFlag != 0,
+			ESI:     fdFlags&blfFDESIFlag != 0,
+		}
+		if msgFlags&blfDirTxFlag != 0 {
+			frame.Direction = DirectionTx
+		}
+		frame.ID, frame.Extended = blfID(u32(4))
+		if frame.Remote {
+			frame.Data = []byte{}
+		} else {
+			frame.Data = append([]byte{}, data[20:20+validBytes]...)
+		}
+
+	case blfCANFDMessage64:
+		if len(data) < 40 {
+			return nil, errors.New("truncated BLF CAN FD message")
+		}
+		validBytes := int(data[2])
+		fdFlags := u32(12)
+		frame = &Frame{
+			Channel: int(data[0]),
+			DLC:     data[1],
+			Remote:  fdFlags&blfFD64RemoteFlag != 0,
+			FD:      fdFlags&blfFD64EDLFlag != 0,
+			BRS:     fdFlags&blfFD64BRSFlag != 0,
+			ESI:     fdFlags&blfFD64ESIFlag != 0,
+		}
+		if data[34] != 0 {
+			frame.Direction = DirectionTx
+		}
+		frame.ID, frame.Extended = blfID(u32(4))
+		if len(data) < 40+validBytes || validBytes > MaxFDLength {
+			return nil, errors.New("truncated BLF CAN FD message")
+		}
+		if frame.Remote {
+			frame.Data = []byte{}
+		} else {
+			frame.Data = append([]byte{}, data[40:40+validBytes]...)
+		}
+
+	case blfCANError, blfCANErrorExt:
+		if len(data) < 2 {
+			return nil, errors.New("truncated BLF CAN error")
+		}
+		frame = &Frame{
+			Channel: int(u16(0)),
+			Error:   true,
+			Data:    []byte{},
+		}
+
+	default:
+		return nil, nil
+	}
+
+	frame.Timestamp = timestamp
+	return frame, nil
+}
+
+// blfID returns the frame id of a BLF id, where the MSB flags the extended ones.
+func blfID(id uint32) (uint32, bool) {
+	return id & canEFFMask, id&blfCANMsgExtFlag != 0
+}
+
+// BLFWriter writes the Binary Logging Format files (.blf) of Vector tools.
+// The objects are stored in zlib compressed log containers.
+// The file header is rewritten on Close, so the underlying writer must be seekable.
+type BLFWriter struct {
+	w io.WriteSeeker
+
+	started bool
+	start   time.Time
+	stop    time.Time
+
+	container        bytes.Buffer
+	objectCount      uint32
+	fileSize         uint64
+	uncompressedSize uint64
+}
+
+// NewBLFWriter returns a new BLF writer.
+func NewBLFWriter(w io.WriteSeeker) *BLFWriter {
+	return &BLFWriter{
+		w: w,
+
+		started: false,
+
+		objectCount:      0,
+		fileSize:         0,
+		uncompressedSize: blfFileHeaderSize,
+	}
+}
+
+func (w *BLFWriter) fileHeader() []byte {
+	header := make([]byte, blfFileHeaderSize)
+	copy(header, blfFileSignature)
+	binary.LittleEndian.PutUint32(header[4:], blfFileHeaderSize)
+	// application id and version, binary log version
+	copy(header[8:], []byte{5, 0, 0, 0, 2, 6, 8, 1})
+	binary.LittleEndian.PutUint64(header[16:], w.fileSize)
+	binary.LittleEndian.PutUint64(header[24:], w.uncompressedSize)
+	binary.LittleEndian.PutUint32(header[32:], w.objectCount)
+	binary.LittleEndian.PutUint32(header[36:], w.objectCount)
+	putSystemTime(header[40:], w.start)
+	putSystemTime(header[56:], w.stop)
+	return header
+}
+
+func (w *BLFWriter) write(data []byte) error {
+	n, err := w.w.Write(data)
+	w.fileSize += uint64(n)
+	return err
+}
+
+func (w *BLFWriter) begin(start time.Time) error {
+	w.started = true
+	// the start time has a millisecond resolution, the objects are relative to it
+	w.start = start.Truncate(time.Millisecond)
+	w.stop = w.start
+	return w.write(w.fileHeader())
+}
+
+// addObject adds an object to the current log container.
+func (w *BLFWriter) addObject(objType uint32, timestamp time.Time, data []byte) error {
+	objSize := blfObjHeaderBaseSize + blfObjHeaderV1Size + len(data)
+
+	header := make([]byte, blfObjHeaderBaseSize+blfObjHeaderV1Size)
+	copy(header, blfObjSignature)
+	binary.LittleEndian.PutUint16(header[4:], blfObjHeaderBaseSize+blfObjHeaderV1Size)
+	binary.LittleEndian.PutUint16(header[6:], 1)
+	binary.LittleEndian.PutUint32(header[8:], uint32(objSize))
+	binary.LittleEndian.PutUint32(header[12:], objType)
+	binary.LittleEndian.PutUint32(header[blfObjHeaderFlagsPos:], blfTimeOneNanos)
+	binary.LittleEndian.PutUint64(header[blfTimestampOffset:], uint64(max(timestamp.Sub(w.start), 0)))
+
+	w.container.Write(header)
+	w.container.Write(data)
+	w.container.Write(make([]byte, objSize%4))
+	w.objectCount++
+
+	if timestamp.After(w.stop) {
+		w.stop = timestamp
+	}
+
+	if w.container.Len() >= blfMaxContainerSize {
+		return w.flushContainer()
+	}
+	return nil
+}
+
+func (w *BLFWriter) flushContainer() error {
+	if w.container.Len() == 0 {
+		return nil
+	}
+
+	compressed := &bytes.Buffer{}
+	zw := zlib.NewWriter(compressed)
+	if _, err := zw.Write(w.container.Bytes()); err != nil {
+		return err
+	}
+	if err := zw.Close(); err != nil {
+		return err
+	}
+
+	objSize := blfObjHeaderBaseSize + blfContainerSize + compressed.Len()
+
+	header := make([]byte, blfObjHeaderBaseSize+blfContainerSize)
+	copy(header, blfObjSignature)
+	binary.LittleEndian.PutUint16(header[4:], blfObjHeaderBaseSize)
+	binary.LittleEndian.PutUint16(header[6:], 1)
+	binary.LittleEndian.PutUint32(header[8:], uint32(objSize))
+	binary.LittleEndian.PutUint32(header[12:], blfLogContainer)
+	binary.LittleEndian.PutUint16(header[16:], blfZlibDeflate)
+	binary.LittleEndian.PutUint32(header[24:], uint32(w.container.Len()))
+
+	for _, data := range [][]byte{header, compressed.Bytes(), make([]byte, objSize%4)} {
+		if err := w.write(data); err != nil {
+			return err
+		}
+	}
+
+	w.uncompressedSize += uint64(blfObjHeaderBaseSize + blfContainerSize + w.container.Len())
+	w.container.Reset()
+
+	return nil
+}
+
+// Write writes a frame into the file.
+func (w *BLFWriter) Write(frame *Frame) error {
+	if !w.started {
+		if err := w.begin(frame.Timestamp); err != nil {
+			return err
+		}
+	}
+
+	channel := frame.Channel
+	if channel <= 0 {
+		channel = 1
+	}
+
+	id := frame.ID
+	if frame.Extended {
+		id |= blfCANMsgExtFlag
+	}
+
+	msgFlags := byte(0)
+	if frame.Direction == DirectionTx {
+		msgFlags |= blfDirTxFlag
+	}
+	if frame.Remote {
+		msgFlags |= blfRemoteFlag
+	}
+
+	switch {
+	case frame.Error:
+		data := make([]byte, 32)
+		binary.LittleEndian.PutUint16(data, uint16(channel))
+		binary.LittleEndian.PutUint32(data[16:], id)
+		return w.addObject(blfCANErrorExt, frame.Timestamp, data)
+
+	case frame.FD || len(frame.Data) > MaxClassicLength:
+		fdFlags := byte(0)
+		if frame.FD {
+			fdFlags |= blfFDEDLFlag
+		}
+		if frame.BRS {
+			fdFlags |= blfFDBRSFlag
+		}
+		if frame.ESI {
+			fdFlags |= blfFDESIFlag
+		}
+
+		data := make([]byte, 84)
+		binary.LittleEndian.PutUint16(data, uint16(channel))
+		data[2] = msgFlags
+		data[3] = LengthToDLC(len(frame.Data))
+		binary.LittleEndian.PutUint32(data[4:], id)
+		data[13] = fdFlags
+		data[14] = byte(len(frame.Data))
+		copy(data[20:], frame.Data)
+		return w.addObject(blfCANFDMessage, frame.Timestamp, data)
+	}
+
+	dlc := frame.DLC
+	if int(dlc) < len(frame.Data) {
+		dlc = uint8(len(frame.Data))
+	}
+
+	data := make([]byte, 16)
+	binary.LittleEndian.PutUint16(data, uint16(channel))
+	data[2] = msgFlags
+	data[3] = dlc
+	binary.LittleEndian.PutUint32(data[4:], id)
+	copy(data[8:], frame.Data)
+	return w.addObject(blfCANMessage, frame.Timestamp, data)
+}
+
+// Close flushes the last log container and rewrites the file header,
+// the underlying writer is not closed.
+func (w *BLFWriter) Close() error {
+	if !w.started {
+		if err := w.begin(time.Now()); err != nil {
+			return err
+		}
+	}
+
+	if err := w.flushContainer(); err != nil {
+		return err
+	}
+
+	if _, err := w.w.Seek(0, io.SeekStart); err != nil {
+		return err
+	}
+	if _, err := w.w.Write(w.fileHeader()); err != nil {
+		return err
+	}
+	_, err := w.w.Seek(0, io.SeekEnd)
+	return err
+}
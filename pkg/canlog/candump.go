@@ -0,0 +1,272 @@
+package canlog
+
+import (
+	"bufio"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Flags used by SocketCAN in the frame id.
+const (
+	canERRFlag = 0x20000000
+	canEFFMask = 0x1FFFFFFF
+	canSFFMask = 0x000007FF
+)
+
+// Flags used by SocketCAN in CAN FD frames.
+const (
+	canFDBRSFlag = 0x01
+	canFDESIFlag = 0x02
+)
+
+// CandumpReader reads the log files produced by "candump -l".
+type CandumpReader struct {
+	scanner *bufio.Scanner
+	line    int
+
+	channels map[string]int
+}
+
+// NewCandumpReader returns a new candump log reader.
+func NewCandumpReader(r io.Reader) *CandumpReader {
+	return &CandumpReader{
+		scanner: bufio.NewScanner(r),
+		line:    0,
+
+		channels: make(map[string]int),
+	}
+}
+
+func (r *CandumpReader) getError(err error) error {
+	return fmt.Errorf("line %d: %v", r.line, err)
+}
+
+// Read reads the next frame of the log.
+func (r *CandumpReader) Read() (*Frame, error) {
+	for r.scanner.Scan() {
+		r.line++
+
+		line := strings.TrimSpace(r.scanner.Text())
+		if len(line) == 0 {
+			continue
+		}
+
+		frame, err := r.parseLine(line)
+		if err != nil {
+			return nil, r.getError(err)
+		}
+		return frame, nil
+	}
+
+	if err := r.scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return nil, io.EOF
+}
+
+func (r *CandumpReader) parseLine(line string) (*Frame, error) {
+	fields := strings.Fields(line)
+	if len(fields) < 3 {
+		return nil, errors.New("invalid candump line")
+	}
+
+	timestamp, err := parseCandumpTimestamp(fields[0])
+	if err != nil {
+		return nil, err
+	}
+
+	iface := fields[1]
+	channel, ok := r.channels[iface]
+	if !ok {
+		channel = len(r.channels) + 1
+		r.channels[iface] = channel
+	}
+
+	frame, err := parseCandumpFrame(fields[2])
+	if err != nil {
+		return nil, err
+	}
+	frame.Timestamp = timestamp
+	frame.Interface = iface
+	frame.Channel = channel
+
+	if len(fields) > 3 && fields[3] == "T" {
+		frame.Direction = DirectionTx
+	}
+
+	return frame, nil
+}
+
+func parseCandumpTimestamp(str string) (time.Time, error) {
+	if !strings.HasPrefix(str, "(") || !strings.HasSuffix(str, ")") {
+		return time.Time{}, fmt.Errorf("invalid timestamp %q", str)
+	}
+
+	d, err := parseSeconds(str[1 : len(str)-1])
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	return time.Unix(0, int64(d)), nil
+}
+
+func parseCandumpFrame(str string) (*Frame, error) {
+	idStr, dataStr, ok := strings.Cut(str, "#")
+	if !ok {
+		return nil, fmt.Errorf("invalid frame %q", str)
+	}
+
+	rawID, err := strconv.ParseUint(idStr, 16, 32)
+	if err != nil {
+		return nil, fmt.Errorf("invalid frame id %q", idStr)
+	}
+
+	frame := &Frame{}
+	switch {
+	case uint32(rawID)&canERRFlag != 0:
+		frame.Error = true
+		frame.Extended = true
+		frame.ID = uint32(rawID) & canEFFMask
+	case len(idStr) == 8:
+		frame.Extended = true
+		frame.ID = uint32(rawID) & canEFFMask
+	default:
+		frame.ID = uint32(rawID) & canSFFMask
+	}
+
+	if strings.HasPrefix(dataStr, "#") {
+		if strings.HasPrefix(dataStr, "##") {
+			return nil, fmt.Errorf("CAN XL frames are not supported %q", str)
+		}
+
+		dataStr = dataStr[1:]
+		if len(dataStr) == 0 {
+			return nil, fmt.Errorf("missing CAN FD flags %q", str)
+		}
+		flags, err := strconv.ParseUint(dataStr[:1], 16, 8)
+		if err != nil {
+			return nil, fmt.Errorf("invalid CAN FD flags %q", str)
+		}
+
+		frame.FD = true
+		frame.BRS = flags&canFDBRSFlag != 0
+		frame.ESI = flags&canFDESIFlag != 0
+
+		data, err := hex.DecodeString(dataStr[1:])
+		if err != nil {
+			return nil, fmt.Errorf("invalid payload %q", str)
+		}
+		if len(data) > MaxFDLength {
+			return nil, fmt.Errorf("payload too long %q", str)
+		}
+		frame.Data = data
+		frame.DLC = LengthToDLC(len(data))
+
+		return frame, nil
+	}
+
+	if strings.HasPrefix(dataStr, "R") || strings.HasPrefix(dataStr, "r") {
+		frame.Remote = true
+		frame.Data = []byte{}
+		if len(dataStr) > 1 {
+			dlc, err := strconv.ParseUint(dataStr[1:], 16, 8)
+			if err != nil || dlc > 15 {
+				return nil, fmt.Errorf("invalid remote frame length %q", str)
+			}
+			frame.DLC = uint8(dlc)
+		}
+		return frame, nil
+	}
+
+	// optional data length code for classic frames with more than 8 bytes of DLC
+	dataStr, dlcStr, hasDLC := strings.Cut(dataStr, "_")
+	data, err := hex.DecodeString(strings.ReplaceAll(dataStr, ".", ""))
+	if err != nil {
+		return nil, fmt.Errorf("invalid payload %q", str)
+	}
+	if len(data) > MaxClassicLength {
+		return nil, fmt.Errorf("payload too long %q", str)
+	}
+	frame.Data = data
+	frame.DLC = uint8(len(data))
+
+	if hasDLC {
+		dlc, err := strconv.ParseUint(dlcStr, 16, 8)
+		if err != nil || dlc > 15 {
+			return nil, fmt.Errorf("invalid data length code %q", str)
+		}
+		frame.DLC = uint8(dlc)
+	}
+
+	return frame, nil
+}
+
+// FormatCandumpFrame returns the frame in the candump/cansend notation (e.g. 123#DEADBEEF).
+func FormatCandumpFrame(frame *Frame) string {
+	id := frame.FormatID()
+	if frame.Error {
+		id = fmt.Sprintf("%08X", frame.ID|canERRFlag)
+	}
+
+	if frame.Remote {
+		if frame.DLC > 0 {
+			return fmt.Sprintf("%s#R%X", id, frame.DLC)
+		}
+		return id + "#R"
+	}
+
+	data := strings.ToUpper(hex.EncodeToString(frame.Data))
+
+	if frame.FD {
+		flags := 0
+		if frame.BRS {
+			flags |= canFDBRSFlag
+		}
+		if frame.ESI {
+			flags |= canFDESIFlag
+		}
+		return fmt.Sprintf("%s##%X%s", id, flags, data)
+	}
+
+	if frame.DLC > MaxClassicLength && len(frame.Data) == MaxClassicLength {
+		return fmt.Sprintf("%s#%s_%X", id, data, frame.DLC)
+	}
+
+	return id + "#" + data
+}
+
+// CandumpWriter writes log files in the format of "candump -l".
+// The transmitted frames are flagged with T, as done by "candump -l -x".
+type CandumpWriter struct {
+	w *bufio.Writer
+}
+
+// NewCandumpWriter returns a new candump log writer.
+func NewCandumpWriter(w io.Writer) *CandumpWriter {
+	return &CandumpWriter{
+		w: bufio.NewWriter(w),
+	}
+}
+
+// Write writes a frame into the log.
+func (w *CandumpWriter) Write(frame *Frame) error {
+	ts := frame.Timestamp
+	line := fmt.Sprintf("(%d.%06d) %s %s", ts.Unix(), ts.Nanosecond()/1000, frame.InterfaceName(), FormatCandumpFrame(frame))
+	if frame.Direction == DirectionTx {
+		line += " T"
+	}
+
+	_, err := fmt.Fprintln(w.w, line)
+	return err
+}
+
+// Close flushes the log, the underlying writer is not closed.
+func (w *CandumpWriter) Close() error {
+	return w.w.Flush()
+}
@@ -0,0 +1,421 @@
+package canlog
+
+import (
+	"bufio"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// CSVColumn represents the content of a column of a CSV log.
+type CSVColumn string
+
+const (
+	CSVColumnSkip      CSVColumn = "-"
+	CSVColumnTimestamp CSVColumn = "time"
+	CSVColumnChannel   CSVColumn = "channel"
+	CSVColumnID        CSVColumn = "id"
+	CSVColumnDirection CSVColumn = "dir"
+	CSVColumnDLC       CSVColumn = "dlc"
+	CSVColumnData      CSVColumn = "data"
+	CSVColumnName      CSVColumn = "name"
+)
+
+var validCSVColumns = []CSVColumn{CSVColumnSkip, CSVColumnTimestamp, CSVColumnChannel, CSVColumnID, CSVColumnDirection, CSVColumnDLC, CSVColumnData, CSVColumnName}
+
+// ParseCSVColumns parses a comma separated list of columns (e.g. "time,channel,id,dlc,data").
+func ParseCSVColumns(str string) ([]CSVColumn, error) {
+	columns := []CSVColumn{}
+	found := make(map[CSVColumn]bool)
+
+	for _, colStr := range strings.Split(str, ",") {
+		col := CSVColumn(strings.ToLower(strings.TrimSpace(colStr)))
+
+		valid := false
+		for _, validCol := range validCSVColumns {
+			if col == validCol {
+				valid = true
+				break
+			}
+		}
+		if !valid {
+			return nil, fmt.Errorf("%q is not a valid column, valid are %v", colStr, validCSVColumns)
+		}
+
+		if found[col] && col != CSVColumnSkip {
+			return nil, fmt.Errorf("column %q is duplicated", col)
+		}
+		found[col] = true
+
+		columns = append(columns, col)
+	}
+
+	for _, col := range []CSVColumn{CSVColumnTimestamp, CSVColumnID, CSVColumnData} {
+		if !found[col] {
+			return nil, fmt.Errorf("column %q is required", col)
+		}
+	}
+
+	return columns, nil
+}
+
+// CSVConfig describes the layout of a CSV or text log.
+type CSVConfig struct {
+	// Columns is the content of each column, when the data column is the last one
+	// the bytes can be split across the remaining fields
+	Columns []CSVColumn
+	// Separator splits the fields, the space separates them by any white space
+	Separator rune
+	// SkipLines is the number of lines to skip at the start (e.g. the header)
+	SkipLines int
+	// TimeUnit is the unit of the timestamps
+	TimeUnit time.Duration
+}
+
+// DefaultCSVConfig returns the configuration of a CSV log with the header
+// and the columns "time,channel,id,dir,dlc,data".
+func DefaultCSVConfig() *CSVConfig {
+	return &CSVConfig{
+		Columns:   []CSVColumn{CSVColumnTimestamp, CSVColumnChannel, CSVColumnID, CSVColumnDirection, CSVColumnDLC, CSVColumnData},
+		Separator: ',',
+		SkipLines: 1,
+		TimeUnit:  time.Second,
+	}
+}
+
+var csvTimeUnits = map[string]time.Duration{
+	"s":  time.Second,
+	"ms": time.Millisecond,
+	"us": time.Microsecond,
+	"ns": time.Nanosecond,
+}
+
+// ParseCSVConfig returns the configuration of a CSV log from its textual description:
+// the comma separated columns, the separator, the lines to skip and the time unit (s, ms, us or ns).
+func ParseCSVConfig(columns, separator string, skipLines int, timeUnit string) (*CSVConfig, error) {
+	cols, err := ParseCSVColumns(columns)
+	if err != nil {
+		return nil, err
+	}
+
+	sep := []rune(separator)
+	if len(sep) != 1 {
+		return nil, fmt.Errorf("separator %q must be a single character", separator)
+	}
+
+	if skipLines < 0 {
+		return nil, fmt.Errorf("lines to skip cannot be negative")
+	}
+
+	unit, ok := csvTimeUnits[timeUnit]
+	if !ok {
+		return nil, fmt.Errorf("%s time unit is not supported, valid are s, ms, us and ns", timeUnit)
+	}
+
+	return &CSVConfig{
+		Columns:   cols,
+		Separator: sep[0],
+		SkipLines: skipLines,
+		TimeUnit:  unit,
+	}, nil
+}
+
+// CSVReader reads the frames of CSV or text logs with a configurable layout.
+// The ids are hexadecimal (with an optional 0x prefix), they are extended if they have the x suffix,
+// more than 4 digits or a value greater than 0x7FF. The numeric channels are 1-based,
+// the other ones (e.g. can0) are interface names. Lines starting with # or // are comments.
+type CSVReader struct {
+	scanner *bufio.Scanner
+	line    int
+
+	config   *CSVConfig
+	channels map[string]int
+}
+
+// NewCSVReader returns a new CSV log reader.
+func NewCSVReader(r io.Reader, config *CSVConfig) *CSVReader {
+	return &CSVReader{
+		scanner: bufio.NewScanner(r),
+		line:    0,
+
+		config:   config,
+		channels: make(map[string]int),
+	}
+}
+
+func (r *CSVReader) getError(err error) error {
+	return fmt.Errorf("line %d: %v", r.line, err)
+}
+
+// Read reads the next frame of the log.
+func (r *CSVReader) Read() (*Frame, error) {
+	for r.scanner.Scan() {
+		r.line++
+
+		if r.line <= r.config.SkipLines {
+			continue
+		}
+
+		line := strings.TrimSpace(r.scanner.Text())
+		if len(line) == 0 || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "//") {
+			continue
+		}
+
+		frame, err := r.parseLine(r.splitLine(line))
+		if err != nil {
+			return nil, r.getError(err)
+		}
+		return frame, nil
+	}
+
+	if err := r.scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return nil, io.EOF
+}
+
+func (r *CSVReader) splitLine(line string) []string {
+	if r.config.Separator == ' ' {
+		return strings.Fields(line)
+	}
+
+	fields := strings.Split(line, string(r.config.Separator))
+	for i, field := range fields {
+		fields[i] = strings.Trim(strings.TrimSpace(field), `"`)
+	}
+	return fields
+}
+
+func (r *CSVReader) parseLine(fields []string) (*Frame, error) {
+	if len(fields) < len(r.config.Columns) {
+		return nil, fmt.Errorf("expected %d fields, got %d", len(r.config.Columns), len(fields))
+	}
+
+	frame := &Frame{
+		Channel: 1,
+	}
+	dlcStr := ""
+
+	for idx, col := range r.config.Columns {
+		field := fields[idx]
+
+		switch col {
+		case CSVColumnTimestamp:
+			ts, err := parseTimestamp(field, r.config.TimeUnit)
+			if err != nil {
+				return nil, err
+			}
+			frame.Timestamp = time.Unix(0, 0).Add(ts)
+
+		case CSVColumnChannel:
+			if channel, err := strconv.Atoi(field); err == nil && channel > 0 {
+				frame.Channel = channel
+				continue
+			}
+			channel, ok := r.channels[field]
+			if !ok {
+				channel = len(r.channels) + 1
+				r.channels[field] = channel
+			}
+			frame.Channel = channel
+			frame.Interface = field
+
+		case CSVColumnID:
+			id, extended, err := parseCSVID(field)
+			if err != nil {
+				return nil, err
+			}
+			frame.ID = id
+			frame.Extended = extended
+
+		case CSVColumnDirection:
+			switch strings.ToLower(field) {
+			case "rx", "r", "":
+				frame.Direction = DirectionRx
+			case "tx", "t":
+				frame.Direction = DirectionTx
+			default:
+				return nil, fmt.Errorf("invalid direction %q", field)
+			}
+
+		case CSVColumnDLC:
+			dlcStr = field
+
+		case CSVColumnName:
+			frame.Name = field
+
+		case CSVColumnData:
+			dataStr := field
+			// the last data column includes the remaining fields
+			if idx == len(r.config.Columns)-1 {
+				dataStr = strings.Join(fields[idx:], "")
+			}
+			data, err := hex.DecodeString(strings.Join(strings.Fields(dataStr), ""))
+			if err != nil || len(data) > MaxFDLength {
+				return nil, fmt.Errorf("invalid data %q", dataStr)
+			}
+			frame.Data = data
+		}
+	}
+
+	frame.DLC = LengthToDLC(len(frame.Data))
+	if len(frame.Data) > MaxClassicLength {
+		frame.FD = true
+	}
+
+	if len(dlcStr) > 0 {
+		dlc, err := strconv.ParseUint(dlcStr, 10, 8)
+		if err != nil || dlc > 15 {
+			return nil, fmt.Errorf("invalid DLC %q", dlcStr)
+		}
+		frame.DLC = uint8(dlc)
+
+		// the data can be truncated by the DLC, e.g. when the log has a column for each byte
+		if length := DLCToLength(frame.DLC, frame.FD); length < len(frame.Data) {
+			frame.Data = frame.Data[:length]
+		}
+	}
+
+	return frame, nil
+}
+
+// parseCSVID parses an hexadecimal id.
+func parseCSVID(str string) (uint32, bool, error) {
+	idStr := strings.TrimPrefix(strings.TrimPrefix(str, "0x"), "0X")
+
+	extended := false
+	if strings.HasSuffix(idStr, "x") || strings.HasSuffix(idStr, "X") {
+		extended = true
+		idStr = idStr[:len(idStr)-1]
+	}
+
+	id, err := strconv.ParseUint(idStr, 16, 32)
+	if err != nil || len(idStr) == 0 {
+		return 0, false, fmt.Errorf("invalid id %q", str)
+	}
+
+	if len(idStr) > 4 || id > canSFFMask {
+		extended = true
+	}
+	return uint32(id) & canEFFMask, extended, nil
+}
+
+// CSVWriter writes the frames into CSV or text logs with a configurable layout.
+// A header with the column names is written if the configuration skips any line.
+// Remote and error frames cannot be represented, so they are not written and Write returns ErrUnsupportedFrame.
+// The FD, BRS and ESI flags are not written either: a frame is read back as CAN FD only if its payload is
+// longer than 8 bytes, so a CAN FD frame of up to 8 bytes is read back as a classic frame.
+type CSVWriter struct {
+	w *bufio.Writer
+
+	config  *CSVConfig
+	started bool
+}
+
+// NewCSVWriter returns a new CSV log writer.
+func NewCSVWriter(w io.Writer, config *CSVConfig) *CSVWriter {
+	return &CSVWriter{
+		w: bufio.NewWriter(w),
+
+		config:  config,
+		started: false,
+	}
+}
+
+func (w *CSVWriter) writeHeader() error {
+	w.started = true
+	if w.config.SkipLines == 0 {
+		return nil
+	}
+
+	header := make([]string, len(w.config.Columns))
+	for i, col := range w.config.Columns {
+		header[i] = string(col)
+	}
+	if err := w.writeLine(header); err != nil {
+		return err
+	}
+
+	for i := 1; i < w.config.SkipLines; i++ {
+		if _, err := fmt.Fprintln(w.w); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (w *CSVWriter) writeLine(fields []string) error {
+	_, err := fmt.Fprintln(w.w, strings.Join(fields, string(w.config.Separator)))
+	return err
+}
+
+// formatTimestamp formats the timestamp in the time unit, with up to the microsecond precision.
+func (w *CSVWriter) formatTimestamp(ts time.Time) string {
+	unit := w.config.TimeUnit
+	d := time.Duration(ts.UnixNano())
+
+	decimals, resolution := 0, unit
+	for ; resolution > time.Microsecond; resolution /= 10 {
+		decimals++
+	}
+
+	if decimals == 0 {
+		return strconv.FormatInt(int64(d/unit), 10)
+	}
+	return fmt.Sprintf("%d.%0*d", d/unit, decimals, (d%unit)/resolution)
+}
+
+// Write writes a frame into the log.
+func (w *CSVWriter) Write(frame *Frame) error {
+	if !w.started {
+		if err := w.writeHeader(); err != nil {
+			return err
+		}
+	}
+
+	switch {
+	case frame.Remote:
+		return fmt.Errorf("remote frame: %w", ErrUnsupportedFrame)
+	case frame.Error:
+		return fmt.Errorf("error frame: %w", ErrUnsupportedFrame)
+	}
+
+	fields := make([]string, len(w.config.Columns))
+	for i, col := range w.config.Columns {
+		switch col {
+		case CSVColumnTimestamp:
+			fields[i] = w.formatTimestamp(frame.Timestamp)
+		case CSVColumnChannel:
+			fields[i] = strconv.Itoa(max(frame.Channel, 1))
+			if len(frame.Interface) > 0 {
+				fields[i] = frame.Interface
+			}
+		case CSVColumnID:
+			fields[i] = frame.FormatID()
+		case CSVColumnDirection:
+			fields[i] = frame.Direction.String()
+		case CSVColumnDLC:
+			fields[i] = strconv.Itoa(int(frame.DLC))
+		case CSVColumnData:
+			fields[i] = strings.ToUpper(hex.EncodeToString(frame.Data))
+		case CSVColumnName:
+			fields[i] = frame.Name
+		}
+	}
+
+	return w.writeLine(fields)
+}
+
+// Close flushes the log, the underlying writer is not closed.
+func (w *CSVWriter) Close() error {
+	if !w.started {
+		if err := w.writeHeader(); err != nil {
+			return err
+		}
+	}
+	return w.w.Flush()
+}
@@ -0,0 +1,232 @@
+// Package canlog contains the readers and writers of CAN trace files.
+package canlog
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MaxClassicLength is the maximum payload length of a classic CAN frame.
+const MaxClassicLength = 8
+
+// MaxFDLength is the maximum payload length of a CAN FD frame.
+const MaxFDLength = 64
+
+// Direction represents the direction of a frame.
+type Direction uint8
+
+const (
+	DirectionRx Direction = iota
+	DirectionTx
+)
+
+func (d Direction) String() string {
+	if d == DirectionTx {
+		return "Tx"
+	}
+	return "Rx"
+}
+
+// Frame represents a CAN or CAN FD frame read from a trace.
+type Frame struct {
+	Timestamp time.Time
+	// Channel is the 1-based channel number
+	Channel int
+	// Interface is the name of the interface the frame was recorded on, if known
+	Interface string
+	Direction Direction
+
+	ID       uint32
+	Extended bool
+	Remote   bool
+	Error    bool
+	FD       bool
+	BRS      bool
+	ESI      bool
+	DLC      uint8
+	Data     []byte
+
+	// Name is the symbolic name of the frame (e.g. the message name), if known
+	Name string
+}
+
+// InterfaceName returns the interface name of the frame,
+// falling back to canX (0-based) derived from the channel number.
+func (f *Frame) InterfaceName() string {
+	if len(f.Interface) > 0 {
+		return f.Interface
+	}
+	return fmt.Sprintf("can%d", f.Channel-1)
+}
+
+// FormatID returns the frame id in hex, using 8 digits for extended frames.
+func (f *Frame) FormatID() string {
+	if f.Extended {
+		return fmt.Sprintf("%08X", f.ID)
+	}
+	return fmt.Sprintf("%03X", f.ID)
+}
+
+// Reader reads frames from a trace.
+// Read returns io.EOF when there are no more frames.
+type Reader interface {
+	Read() (*Frame, error)
+}
+
+// ErrUnsupportedFrame is returned by the writers that cannot represent a frame, the frame is not written
+// and the trace can be written further.
+var ErrUnsupportedFrame = errors.New("frame is not supported")
+
+// Writer writes frames into a trace.
+// Close completes the trace and must be called after the last frame,
+// it does not close the underlying writer.
+type Writer interface {
+	Write(frame *Frame) error
+	Close() error
+}
+
+// Format represents a trace file format.
+type Format string
+
+const (
+	FormatCandump Format = "candump"
+	FormatASC     Format = "asc"
+	FormatBLF     Format = "blf"
+	FormatTRC     Format = "trc"
+	FormatCSV     Format = "csv"
+)
+
+var formatExtensions = map[string]Format{
+	".log": FormatCandump,
+	".asc": FormatASC,
+	".blf": FormatBLF,
+	".trc": FormatTRC,
+	".csv": FormatCSV,
+	".txt": FormatCSV,
+}
+
+// FormatFromFileName returns the trace format matching the file extension.
+func FormatFromFileName(fileName string) (Format, error) {
+	ext := strings.ToLower(filepath.Ext(fileName))
+	format, ok := formatExtensions[ext]
+	if !ok {
+		return "", fmt.Errorf("%s extension is not a supported trace format", ext)
+	}
+	return format, nil
+}
+
+// NewReader returns a reader of the given trace format.
+func NewReader(format Format, r io.Reader) (Reader, error) {
+	switch format {
+	case FormatCandump:
+		return NewCandumpReader(r), nil
+	case FormatASC:
+		return NewASCReader(r), nil
+	case FormatBLF:
+		return NewBLFReader(r), nil
+	case FormatTRC:
+		return NewTRCReader(r), nil
+	case FormatCSV:
+		return NewCSVReader(r, DefaultCSVConfig()), nil
+	}
+
+	return nil, fmt.Errorf("%s format is not supported as input", format)
+}
+
+// NewWriter returns a writer of the given trace format.
+func NewWriter(format Format, w io.Writer) (Writer, error) {
+	switch format {
+	case FormatCandump:
+		return NewCandumpWriter(w), nil
+	case FormatASC:
+		return NewASCWriter(w), nil
+	case FormatTRC:
+		return NewTRCWriter(w), nil
+	case FormatCSV:
+		return NewCSVWriter(w, DefaultCSVConfig()), nil
+	case FormatBLF:
+		ws, ok := w.(io.WriteSeeker)
+		if !ok {
+			return nil, fmt.Errorf("%s format requires a seekable output", format)
+		}
+		return NewBLFWriter(ws), nil
+	}
+
+	return nil, fmt.Errorf("%s format is not supported as output", format)
+}
+
+// parseSeconds parses a decimal number of seconds (e.g. 1436509052.249713) without losing precision.
+func parseSeconds(str string) (time.Duration, error) {
+	return parseTimestamp(str, time.Second)
+}
+
+// parseTimestamp parses a decimal timestamp in the given unit (e.g. 1700000000009.560 ms) without losing precision.
+// The integer and fractional parts are scaled separately, it returns an error if the timestamp overflows a duration.
+func parseTimestamp(str string, unit time.Duration) (time.Duration, error) {
+	neg := strings.HasPrefix(str, "-")
+	secStr, fracStr, _ := strings.Cut(strings.TrimPrefix(str, "-"), ".")
+
+	sec, err := strconv.ParseInt(secStr, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid timestamp %q", str)
+	}
+	if sec > math.MaxInt64/int64(unit) {
+		return 0, fmt.Errorf("timestamp %q overflows", str)
+	}
+	d := time.Duration(sec) * unit
+
+	if len(fracStr) > 0 {
+		// the digits beyond the nanosecond are dropped, a unit is at most a second
+		if len(fracStr) > 9 {
+			fracStr = fracStr[:9]
+		}
+		frac, err := strconv.ParseInt(fracStr, 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("invalid timestamp %q", str)
+		}
+		scale := int64(1)
+		for range fracStr {
+			scale *= 10
+		}
+		nsec := time.Duration(frac * int64(unit) / scale)
+		if d > math.MaxInt64-nsec {
+			return 0, fmt.Errorf("timestamp %q overflows", str)
+		}
+		d += nsec
+	}
+
+	if neg {
+		return -d, nil
+	}
+	return d, nil
+}
+
+// dlcToLength maps the DLC of a CAN FD frame to its payload length.
+var dlcToLength = [16]int{0, 1, 2, 3, 4, 5, 6, 7, 8, 12, 16, 20, 24, 32, 48, 64}
+
+// DLCToLength returns the payload length of the given DLC.
+func DLCToLength(dlc uint8, fd bool) int {
+	if dlc > 15 {
+		dlc = 15
+	}
+	if !fd && dlc > MaxClassicLength {
+		return MaxClassicLength
+	}
+	return dlcToLength[dlc]
+}
+
+// LengthToDLC returns the smallest DLC able to hold a payload of the given length.
+func LengthToDLC(length int) uint8 {
+	for dlc, l := range dlcToLength {
+		if l >= length {
+			return uint8(dlc)
+		}
+	}
+	return 15
+}
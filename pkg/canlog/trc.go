@@ -0,0 +1,430 @@
+package canlog
+
+import (
+	"bufio"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Columns of the TRC files, as named by the $COLUMNS header of version 2.1.
+const (
+	trcColNumber    = 'N'
+	trcColOffset    = 'O'
+	trcColType      = 'T'
+	trcColBus       = 'B'
+	trcColID        = 'I'
+	trcColDirection = 'd'
+	trcColReserved  = 'R'
+	trcColDLC       = 'L'
+	trcColLength    = 'l'
+	trcColData      = 'D'
+)
+
+// trcVersionColumns are the columns of the TRC versions without the $COLUMNS header.
+var trcVersionColumns = map[string]string{
+	"1.0": "N,O,I,l,D",
+	"1.1": "N,O,T,I,l,D",
+	"1.2": "N,O,B,T,I,l,D",
+	"1.3": "N,O,B,T,I,R,l,D",
+	"2.0": "N,O,T,I,d,l,D",
+	"2.1": "N,O,T,B,I,d,R,L,D",
+}
+
+// trcStartDate is the origin of the $STARTTIME header, expressed in days.
+var trcStartDate = time.Date(1899, 12, 30, 0, 0, 0, 0, time.Local)
+
+// TRCReader reads the trace files (.trc) of PCAN-View, from version 1.0 to 2.1.
+// Lines that are not CAN frames (e.g. status and error counter changes) are skipped.
+type TRCReader struct {
+	scanner *bufio.Scanner
+	line    int
+
+	start   time.Time
+	columns map[byte]int
+}
+
+// NewTRCReader returns a new TRC trace reader.
+func NewTRCReader(r io.Reader) *TRCReader {
+	tr := &TRCReader{
+		scanner: bufio.NewScanner(r),
+		line:    0,
+
+		start: time.Unix(0, 0),
+	}
+	// files without the $FILEVERSION header are version 1.0
+	tr.setColumns(trcVersionColumns["1.0"])
+	return tr
+}
+
+func (r *TRCReader) getError(err error) error {
+	return fmt.Errorf("line %d: %v", r.line, err)
+}
+
+func (r *TRCReader) setColumns(str string) {
+	r.columns = make(map[byte]int)
+	for idx, col := range strings.Split(str, ",") {
+		if col = strings.TrimSpace(col); len(col) > 0 {
+			r.columns[col[0]] = idx
+		}
+	}
+}
+
+// field returns the field of the given column.
+func (r *TRCReader) field(fields []string, col byte) (string, bool) {
+	idx, ok := r.columns[col]
+	if !ok || idx >= len(fields) {
+		return "", false
+	}
+	return fields[idx], true
+}
+
+// Read reads the next frame of the trace.
+func (r *TRCReader) Read() (*Frame, error) {
+	for r.scanner.Scan() {
+		r.line++
+
+		line := strings.TrimSpace(r.scanner.Text())
+		if len(line) == 0 {
+			continue
+		}
+
+		if strings.HasPrefix(line, ";") {
+			if err := r.parseHeader(line); err != nil {
+				return nil, r.getError(err)
+			}
+			continue
+		}
+
+		frame, err := r.parseLine(strings.Fields(line))
+		if err != nil {
+			return nil, r.getError(err)
+		}
+		if frame != nil {
+			return frame, nil
+		}
+	}
+
+	if err := r.scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return nil, io.EOF
+}
+
+// parseHeader parses the ;$KEY=VALUE header lines, the other comments are skipped.
+func (r *TRCReader) parseHeader(line string) error {
+	key, value, ok := strings.Cut(strings.TrimPrefix(line, ";$"), "=")
+	if !ok || !strings.HasPrefix(line, ";$") {
+		return nil
+	}
+	value = strings.TrimSpace(value)
+
+	switch strings.ToUpper(key) {
+	case "FILEVERSION":
+		columns, ok := trcVersionColumns[value]
+		if !ok {
+			return fmt.Errorf("file version %s is not supported", value)
+		}
+		r.setColumns(columns)
+
+	case "STARTTIME":
+		start, err := parseTRCStartTime(value)
+		if err != nil {
+			return err
+		}
+		r.start = start
+
+	case "COLUMNS":
+		r.setColumns(value)
+	}
+
+	return nil
+}
+
+// parseTRCStartTime parses the start time, expressed in days since 30 December 1899.
+func parseTRCStartTime(str string) (time.Time, error) {
+	daysStr, fracStr, _ := strings.Cut(str, ".")
+
+	days, err := strconv.Atoi(daysStr)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid start time %q", str)
+	}
+
+	frac := 0.0
+	if len(fracStr) > 0 {
+		frac, err = strconv.ParseFloat("0."+fracStr, 64)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("invalid start time %q", str)
+		}
+	}
+
+	start := trcStartDate.AddDate(0, 0, days)
+	// the start time is rounded to the microsecond, the file precision
+	return start.Add(time.Duration(frac * float64(24*time.Hour)).Round(time.Microsecond)), nil
+}
+
+// parseLine parses a message line.
+// It returns a nil frame if the line is not a CAN frame.
+func (r *TRCReader) parseLine(fields []string) (*Frame, error) {
+	frame := &Frame{
+		Channel: 1,
+		Data:    []byte{},
+	}
+
+	offsetStr, ok := r.field(fields, trcColOffset)
+	if !ok {
+		return nil, errors.New("invalid TRC line")
+	}
+	offset, err := parseSeconds(offsetStr)
+	if err != nil {
+		return nil, err
+	}
+	// the offset is expressed in milliseconds
+	frame.Timestamp = r.start.Add(offset / 1000)
+
+	typ := "DT"
+	if typStr, ok := r.field(fields, trcColType); ok {
+		typ = typStr
+	}
+
+	switch typ {
+	case "DT":
+	case "FD":
+		frame.FD = true
+	case "FB":
+		frame.FD, frame.BRS = true, true
+	case "FE":
+		frame.FD, frame.ESI = true, true
+	case "BI":
+		frame.FD, frame.BRS, frame.ESI = true, true, true
+	case "RR":
+		frame.Remote = true
+
+	// version 1.x types
+	case "Rx", "Tx":
+		frame.Direction, _ = parseTRCDirection(typ)
+
+	case "ER", "Error":
+		frame.Error = true
+
+	default:
+		// status, error counter, event and warning lines
+		return nil, nil
+	}
+
+	if busStr, ok := r.field(fields, trcColBus); ok {
+		bus, err := strconv.Atoi(busStr)
+		if err != nil || bus <= 0 {
+			return nil, fmt.Errorf("invalid bus %q", busStr)
+		}
+		frame.Channel = bus
+	}
+
+	if dirStr, ok := r.field(fields, trcColDirection); ok {
+		dir, err := parseTRCDirection(dirStr)
+		if err != nil && !frame.Error {
+			return nil, err
+		}
+		frame.Direction = dir
+	}
+
+	// the content of the error frames is not decoded
+	if frame.Error {
+		return frame, nil
+	}
+
+	idStr, ok := r.field(fields, trcColID)
+	if !ok {
+		return nil, errors.New("missing id")
+	}
+	id, err := strconv.ParseUint(idStr, 16, 32)
+	if err != nil {
+		return nil, fmt.Errorf("invalid id %q", idStr)
+	}
+	frame.ID = uint32(id) & canEFFMask
+	// extended ids are written with 8 digits
+	frame.Extended = len(idStr) > 4
+
+	length := 0
+	if dlcStr, ok := r.field(fields, trcColDLC); ok {
+		dlc, err := strconv.ParseUint(dlcStr, 10, 8)
+		if err != nil || dlc > 15 {
+			return nil, fmt.Errorf("invalid DLC %q", dlcStr)
+		}
+		frame.DLC = uint8(dlc)
+		length = DLCToLength(frame.DLC, frame.FD)
+	} else if lengthStr, ok := r.field(fields, trcColLength); ok {
+		length, err = strconv.Atoi(lengthStr)
+		if err != nil || length < 0 || length > MaxFDLength {
+			return nil, fmt.Errorf("invalid data length %q", lengthStr)
+		}
+		frame.DLC = LengthToDLC(length)
+		if !frame.FD && length <= MaxClassicLength {
+			frame.DLC = uint8(length)
+		}
+	}
+
+	dataIdx, ok := r.columns[trcColData]
+	if !ok || frame.Remote {
+		return frame, nil
+	}
+	dataFields := []string{}
+	if dataIdx < len(fields) {
+		dataFields = fields[dataIdx:]
+	}
+
+	// version 1.x remote frames have RTR instead of the data
+	if len(dataFields) > 0 && dataFields[0] == "RTR" {
+		frame.Remote = true
+		return frame, nil
+	}
+
+	if len(dataFields) < length {
+		return nil, fmt.Errorf("expected %d data bytes, got %d", length, len(dataFields))
+	}
+	data, err := hex.DecodeString(strings.Join(dataFields[:length], ""))
+	if err != nil || len(data) != length {
+		return nil, fmt.Errorf("invalid data %q", strings.Join(dataFields[:length], " "))
+	}
+	frame.Data = data
+
+	return frame, nil
+}
+
+func parseTRCDirection(str string) (Direction, error) {
+	switch str {
+	case "Rx":
+		return DirectionRx, nil
+	case "Tx":
+		return DirectionTx, nil
+	}
+	return DirectionRx, fmt.Errorf("invalid direction %q", str)
+}
+
+// trcWriteColumns are the columns of the written TRC files.
+const trcWriteColumns = "N,O,T,B,I,d,R,L,D"
+
+// TRCWriter writes the trace files (.trc) of PCAN-View with the version 2.1.
+// Offsets are written relative to the first frame, whose time is used as start time of the trace.
+type TRCWriter struct {
+	w *bufio.Writer
+
+	start   time.Time
+	started bool
+	number  int
+}
+
+// NewTRCWriter returns a new TRC trace writer.
+func NewTRCWriter(w io.Writer) *TRCWriter {
+	return &TRCWriter{
+		w: bufio.NewWriter(w),
+
+		started: false,
+		number:  0,
+	}
+}
+
+// formatTRCStartTime formats the start time in days since 30 December 1899.
+func formatTRCStartTime(start time.Time) string {
+	start = start.In(time.Local)
+	date := time.Date(start.Year(), start.Month(), start.Day(), 0, 0, 0, 0, time.UTC)
+	days := int(date.Sub(time.Date(1899, 12, 30, 0, 0, 0, 0, time.UTC)) / (24 * time.Hour))
+
+	sinceMidnight := time.Duration(start.Hour())*time.Hour + time.Duration(start.Minute())*time.Minute +
+		time.Duration(start.Second())*time.Second + time.Duration(start.Nanosecond())
+	frac := strconv.FormatFloat(float64(sinceMidnight)/float64(24*time.Hour), 'f', 12, 64)
+
+	return strconv.Itoa(days) + strings.TrimPrefix(frac, "0")
+}
+
+func (w *TRCWriter) writeHeader(start time.Time) {
+	// the start time is written with the microsecond precision of the offsets
+	w.start = start.Truncate(time.Microsecond)
+	w.started = true
+
+	fmt.Fprintln(w.w, ";$FILEVERSION=2.1")
+	fmt.Fprintf(w.w, ";$STARTTIME=%s\n", formatTRCStartTime(w.start))
+	fmt.Fprintf(w.w, ";$COLUMNS=%s\n", trcWriteColumns)
+	fmt.Fprintln(w.w, ";")
+	fmt.Fprintf(w.w, ";   Start time: %s\n", w.start.In(time.Local).Format("02.01.2006 15:04:05.000.0"))
+	fmt.Fprintln(w.w, ";   Generated by jsondbc")
+	fmt.Fprintln(w.w, ";-------------------------------------------------------------------------------")
+	fmt.Fprintln(w.w, ";   Message   Time    Type    ID     Rx/Tx")
+	fmt.Fprintln(w.w, ";   Number    Offset  |  Bus  [hex]  |  Reserved")
+	fmt.Fprintln(w.w, ";   |         [ms]    |  |    |      |  |  Data Length Code")
+	fmt.Fprintln(w.w, ";   |         |       |  |    |      |  |  |    Data [hex] ...")
+	fmt.Fprintln(w.w, ";   |         |       |  |    |      |  |  |    |")
+	fmt.Fprintln(w.w, ";---+-- ------+------ +- +- --+----- +- +- +--- +- -- -- -- -- -- -- --")
+}
+
+// Write writes a frame into the trace.
+func (w *TRCWriter) Write(frame *Frame) error {
+	if !w.started {
+		w.writeHeader(frame.Timestamp)
+	}
+	w.number++
+
+	offset := frame.Timestamp.Sub(w.start)
+	offsetStr := fmt.Sprintf("%d.%03d", offset/time.Millisecond, (offset%time.Millisecond)/time.Microsecond)
+	if offset < 0 {
+		offsetStr = fmt.Sprintf("%.3f", float64(offset)/float64(time.Millisecond))
+	}
+
+	channel := frame.Channel
+	if channel <= 0 {
+		channel = 1
+	}
+
+	id := fmt.Sprintf("%04X", frame.ID)
+	if frame.Extended {
+		id = fmt.Sprintf("%08X", frame.ID)
+	}
+
+	typ := "DT"
+	dlc := frame.DLC
+	switch {
+	case frame.Error:
+		typ = "ER"
+		id = "-"
+	case frame.Remote:
+		typ = "RR"
+	case frame.FD || len(frame.Data) > MaxClassicLength:
+		switch {
+		case frame.BRS && frame.ESI:
+			typ = "BI"
+		case frame.BRS:
+			typ = "FB"
+		case frame.ESI:
+			typ = "FE"
+		default:
+			typ = "FD"
+		}
+		dlc = LengthToDLC(len(frame.Data))
+	default:
+		if int(dlc) < len(frame.Data) {
+			dlc = uint8(len(frame.Data))
+		}
+	}
+
+	data := ""
+	if !frame.Remote && !frame.Error {
+		data = formatASCBytes(frame.Data)
+	}
+
+	line := fmt.Sprintf("%7d %13s %s %d %8s %s - %-4d %s", w.number, offsetStr, typ, channel, id, frame.Direction, dlc, data)
+	_, err := fmt.Fprintln(w.w, strings.TrimRight(line, " "))
+	return err
+}
+
+// Close flushes the trace, the underlying writer is not closed.
+func (w *TRCWriter) Close() error {
+	if !w.started {
+		w.writeHeader(time.Now())
+	}
+	return w.w.Flush()
+}
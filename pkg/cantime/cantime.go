@@ -0,0 +1,275 @@
+// Package cantime computes the length in bits and the transmission time of CAN and CAN FD frames.
+package cantime
+
+import (
+	"time"
+
+	"github.com/squadracorsepolito/jsondbc/pkg/canlog"
+)
+
+// DefaultBitrate is the nominal bitrate used when the model does not define one.
+const DefaultBitrate = 500_000
+
+// Fields at the end of the frames that are not stuffed:
+// CRC delimiter, ACK slot, ACK delimiter, end of frame and intermission.
+const trailerBits = 1 + 1 + 1 + 7 + 3
+
+// errorFrameBits is the length of an error frame: error flag, error delimiter and intermission.
+const errorFrameBits = 6 + 8 + 3
+
+// Bits is the length of a frame, split into the bits transmitted with the nominal
+// bitrate (arbitration phase) and the ones transmitted with the data bitrate (data phase of CAN FD frames with BRS).
+type Bits struct {
+	Nominal int
+	Data    int
+}
+
+// Total returns the length of the frame in bits.
+func (b Bits) Total() int {
+	return b.Nominal + b.Data
+}
+
+// bitWriter collects the bits of a frame and counts the stuff bits inserted
+// after 5 consecutive bits with the same value.
+type bitWriter struct {
+	bits      []bool
+	stuffBits int
+	runValue  bool
+	runLength int
+}
+
+func (w *bitWriter) writeBit(bit bool) {
+	w.bits = append(w.bits, bit)
+
+	if w.runLength > 0 && bit == w.runValue {
+		w.runLength++
+	} else {
+		w.runValue = bit
+		w.runLength = 1
+	}
+
+	// the stuff bit has the opposite value and it starts a new run
+	if w.runLength == 5 {
+		w.stuffBits++
+		w.runValue = !bit
+		w.runLength = 1
+	}
+}
+
+func (w *bitWriter) writeBits(value uint64, count int) {
+	for i := count - 1; i >= 0; i-- {
+		w.writeBit(value&(1<<i) != 0)
+	}
+}
+
+// crc15 returns the CRC of the classic frames, computed on the bits before stuffing.
+func crc15(bits []bool) uint64 {
+	crc := uint64(0)
+	for _, bit := range bits {
+		next := bit != (crc&0x4000 != 0)
+		crc = (crc << 1) & 0x7FFF
+		if next {
+			crc ^= 0x4599
+		}
+	}
+	return crc
+}
+
+// writeHeader writes the bits from the start of frame to the control field, before the DLC.
+func writeHeader(w *bitWriter, frame *canlog.Frame) {
+	// start of frame
+	w.writeBit(false)
+
+	if frame.Extended {
+		w.writeBits(uint64(frame.ID>>18), 11)
+		// SRR and IDE
+		w.writeBits(0b11, 2)
+		w.writeBits(uint64(frame.ID), 18)
+	} else {
+		w.writeBits(uint64(frame.ID), 11)
+	}
+
+	if frame.FD {
+		// RRS (and IDE for standard frames), FDF and res
+		if !frame.Extended {
+			w.writeBit(false)
+		}
+		w.writeBits(0b010, 3)
+		return
+	}
+
+	// RTR
+	w.writeBit(frame.Remote)
+	// IDE for standard frames, r1 for extended ones, then r0
+	w.writeBits(0, 2)
+}
+
+// FrameBits returns the exact length of the frame, including the stuff bits of its content.
+// The length includes the 3 bits of intermission after the frame.
+func FrameBits(frame *canlog.Frame) Bits {
+	if frame.Error {
+		return Bits{Nominal: errorFrameBits}
+	}
+
+	w := &bitWriter{}
+	writeHeader(w, frame)
+
+	if !frame.FD {
+		w.writeBits(uint64(frame.DLC), 4)
+		if !frame.Remote {
+			for _, b := range frame.Data {
+				w.writeBits(uint64(b), 8)
+			}
+		}
+		w.writeBits(crc15(w.bits), 15)
+
+		return Bits{Nominal: len(w.bits) + w.stuffBits + trailerBits}
+	}
+
+	// the arbitration phase ends with the BRS bit
+	w.writeBit(frame.BRS)
+	arbitrationBits := len(w.bits) + w.stuffBits
+
+	w.writeBit(frame.ESI)
+	w.writeBits(uint64(frame.DLC), 4)
+	for _, b := range frame.Data {
+		w.writeBits(uint64(b), 8)
+	}
+
+	// the stuff count (4 bits) and the CRC have fixed stuff bits: one at the start and one every 4 bits
+	crcBits := 17
+	fixedStuffBits := 6
+	if len(frame.Data) > 16 {
+		crcBits = 21
+		fixedStuffBits = 7
+	}
+	dataBits := len(w.bits) + w.stuffBits - arbitrationBits + 4 + crcBits + fixedStuffBits
+
+	if !frame.BRS {
+		return Bits{Nominal: arbitrationBits + dataBits + trailerBits}
+	}
+	return Bits{Nominal: arbitrationBits + trailerBits, Data: dataBits}
+}
+
+// ArbitrationKey returns a key ordering the frames as the arbitration does, the lower key wins:
+// the identifier bits are compared from the most significant one, a standard frame wins against
+// an extended one with the same base id and a data frame wins against a remote one.
+func ArbitrationKey(frame *canlog.Frame) uint64 {
+	if frame.Extended {
+		return uint64(frame.ID>>18)<<20 | 1<<19 | uint64(frame.ID&0x3FFFF)<<1 | boolKey(frame.Remote)
+	}
+	return uint64(frame.ID)<<20 | boolKey(frame.Remote)
+}
+
+func boolKey(b bool) uint64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// Bitrate contains the bitrates of the bus in bit/s, the data bitrate is used
+// by the CAN FD frames that switch the bitrate (BRS).
+type Bitrate struct {
+	Nominal uint32
+	Data    uint32
+}
+
+// NewBitrate returns the bitrates of the bus, a zero nominal bitrate is replaced by DefaultBitrate
+// and a zero data bitrate is replaced by the nominal one.
+func NewBitrate(nominal, data uint32) Bitrate {
+	if nominal == 0 {
+		nominal = DefaultBitrate
+	}
+	if data == 0 {
+		data = nominal
+	}
+	return Bitrate{Nominal: nominal, Data: data}
+}
+
+// Duration returns the time taken to transmit the given bits.
+func (b Bitrate) Duration(bits Bits) time.Duration {
+	nominal := time.Duration(bits.Nominal) * time.Second / time.Duration(b.Nominal)
+	data := time.Duration(bits.Data) * time.Second / time.Duration(b.Data)
+	return nominal + data
+}
+
+// FrameDuration returns the time taken to transmit the frame, including the intermission.
+func (b Bitrate) FrameDuration(frame *canlog.Frame) time.Duration {
+	return b.Duration(FrameBits(frame))
+}
+
+// Stuffing selects the stuff bits counted by MessageBits.
+type Stuffing int
+
+const (
+	// StuffingNone counts the nominal bits of the frame, without stuff bits.
+	StuffingNone Stuffing = iota
+	// StuffingWorstCase counts the maximum number of stuff bits of the frame.
+	StuffingWorstCase
+)
+
+func (s Stuffing) String() string {
+	if s == StuffingWorstCase {
+		return "worst-case"
+	}
+	return "nominal"
+}
+
+// worstCaseStuffBits returns the maximum number of stuff bits in a region of dynamic stuffing:
+// the first stuff bit needs 5 bits, then every stuff bit starts a run of the following 4 bits.
+func worstCaseStuffBits(bits int) int {
+	if bits < 5 {
+		return 0
+	}
+	return (bits - 1) / 4
+}
+
+// MessageBits returns the length of a data frame carrying the given number of bytes,
+// counting the stuff bits as requested. The payload of CAN FD frames is padded to the length of its DLC.
+// The length includes the 3 bits of intermission after the frame.
+func MessageBits(length int, extended, fd, brs bool, stuffing Stuffing) Bits {
+	if !fd {
+		length = min(length, canlog.MaxClassicLength)
+
+		// SOF, identifier, RTR, IDE and r0 (or SRR, IDE, r1 and r0 for extended frames), DLC, data and CRC
+		stuffed := 1 + 11 + 3 + 4 + 8*length + 15
+		if extended {
+			stuffed += 20
+		}
+
+		bits := stuffed + trailerBits
+		if stuffing == StuffingWorstCase {
+			bits += worstCaseStuffBits(stuffed)
+		}
+		return Bits{Nominal: bits}
+	}
+
+	length = canlog.DLCToLength(canlog.LengthToDLC(min(length, canlog.MaxFDLength)), true)
+
+	// SOF, identifier, RRS, IDE, FDF, res and BRS (or SRR, IDE, RRS, FDF, res and BRS for extended frames)
+	arbitration := 1 + 11 + 5
+	if extended {
+		arbitration += 19
+	}
+	// ESI, DLC and data
+	data := 1 + 4 + 8*length
+
+	if stuffing == StuffingWorstCase {
+		arbitrationStuff := worstCaseStuffBits(arbitration)
+		data += worstCaseStuffBits(arbitration+data) - arbitrationStuff
+		arbitration += arbitrationStuff
+	}
+
+	// stuff count, CRC and their fixed stuff bits
+	if length > 16 {
+		data += 4 + 21 + 7
+	} else {
+		data += 4 + 17 + 6
+	}
+
+	if !brs {
+		return Bits{Nominal: arbitration + data + trailerBits}
+	}
+	return Bits{Nominal: arbitration + trailerBits, Data: data}
+}
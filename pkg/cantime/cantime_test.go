@@ -0,0 +1,120 @@
+package cantime
+
+import (
+	"testing"
+
+	"github.com/squadracorsepolito/jsondbc/pkg/canlog"
+)
+
+func TestWorstCaseStuffBits(t *testing.T) {
+	tests := []struct {
+		bits int
+		want int
+	}{
+		{bits: 0, want: 0},
+		{bits: 4, want: 0},
+		{bits: 5, want: 1},
+		{bits: 8, want: 1},
+		{bits: 9, want: 2},
+		{bits: 98, want: 24},
+		{bits: 118, want: 29},
+	}
+
+	for _, tt := range tests {
+		if got := worstCaseStuffBits(tt.bits); got != tt.want {
+			t.Errorf("worstCaseStuffBits(%d) = %d, want %d", tt.bits, got, tt.want)
+		}
+	}
+}
+
+func TestMessageBits(t *testing.T) {
+	tests := []struct {
+		name     string
+		length   int
+		extended bool
+		fd       bool
+		brs      bool
+		stuffing Stuffing
+		want     Bits
+	}{
+		{name: "standard 8 bytes nominal", length: 8, stuffing: StuffingNone, want: Bits{Nominal: 111}},
+		{name: "standard 8 bytes worst-case", length: 8, stuffing: StuffingWorstCase, want: Bits{Nominal: 135}},
+		{name: "standard 0 bytes worst-case", length: 0, stuffing: StuffingWorstCase, want: Bits{Nominal: 55}},
+		{name: "standard length over 8 bytes", length: 12, stuffing: StuffingNone, want: Bits{Nominal: 111}},
+		{name: "extended 8 bytes nominal", length: 8, extended: true, stuffing: StuffingNone, want: Bits{Nominal: 131}},
+		{name: "extended 8 bytes worst-case", length: 8, extended: true, stuffing: StuffingWorstCase, want: Bits{Nominal: 160}},
+		{name: "fd 64 bytes brs nominal", length: 64, fd: true, brs: true, stuffing: StuffingNone, want: Bits{Nominal: 30, Data: 549}},
+		{name: "fd 64 bytes brs worst-case", length: 64, fd: true, brs: true, stuffing: StuffingWorstCase, want: Bits{Nominal: 34, Data: 678}},
+		{name: "fd 8 bytes without brs", length: 8, fd: true, stuffing: StuffingNone, want: Bits{Nominal: 126}},
+		{name: "fd length padded to the dlc", length: 10, fd: true, brs: true, stuffing: StuffingNone, want: Bits{Nominal: 30, Data: 128}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := MessageBits(tt.length, tt.extended, tt.fd, tt.brs, tt.stuffing)
+			if got != tt.want {
+				t.Errorf("MessageBits() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFrameBits(t *testing.T) {
+	tests := []struct {
+		name  string
+		frame *canlog.Frame
+		want  Bits
+	}{
+		{
+			name:  "standard 8 bytes alternating",
+			frame: &canlog.Frame{ID: 0x555, DLC: 8, Data: []byte{0x55, 0x55, 0x55, 0x55, 0x55, 0x55, 0x55, 0x55}},
+			want:  Bits{Nominal: 112},
+		},
+		{
+			name:  "standard 8 bytes zero",
+			frame: &canlog.Frame{ID: 0x000, DLC: 8, Data: make([]byte, 8)},
+			want:  Bits{Nominal: 127},
+		},
+		{
+			name:  "extended 8 bytes zero",
+			frame: &canlog.Frame{ID: 0x18FF1234, Extended: true, DLC: 8, Data: make([]byte, 8)},
+			want:  Bits{Nominal: 147},
+		},
+		{
+			name:  "remote",
+			frame: &canlog.Frame{ID: 0x123, Remote: true, DLC: 8},
+			want:  Bits{Nominal: 48},
+		},
+		{
+			name:  "fd 64 bytes brs",
+			frame: &canlog.Frame{ID: 0x123, FD: true, BRS: true, DLC: 15, Data: make([]byte, 64)},
+			want:  Bits{Nominal: 30, Data: 651},
+		},
+		{
+			name:  "error",
+			frame: &canlog.Frame{Error: true},
+			want:  Bits{Nominal: 17},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := FrameBits(tt.frame)
+			if got != tt.want {
+				t.Errorf("FrameBits() = %+v, want %+v", got, tt.want)
+			}
+
+			if tt.frame.Error || tt.frame.Remote {
+				return
+			}
+
+			// the exact length is bounded by the nominal and the worst-case ones
+			length := len(tt.frame.Data)
+			nominal := MessageBits(length, tt.frame.Extended, tt.frame.FD, tt.frame.BRS, StuffingNone)
+			worstCase := MessageBits(length, tt.frame.Extended, tt.frame.FD, tt.frame.BRS, StuffingWorstCase)
+			if got.Total() < nominal.Total() || got.Total() > worstCase.Total() {
+				t.Errorf("FrameBits() total %d outside [%d|%d]", got.Total(), nominal.Total(), worstCase.Total())
+			}
+		})
+	}
+}
@@ -0,0 +1,132 @@
+// Package codec decodes and encodes CAN frame payloads using a CAN model.
+package codec
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/squadracorsepolito/jsondbc/pkg"
+)
+
+// Codec decodes and encodes the messages of a CAN model.
+type Codec struct {
+	messages       map[uint32]*Message
+	messagesByName map[string]*Message
+}
+
+// New returns a codec for the given CAN model.
+// The model must have been initialized with Init.
+func New(canModel *pkg.CanModel) (*Codec, error) {
+	c := &Codec{
+		messages:       make(map[uint32]*Message),
+		messagesByName: make(map[string]*Message),
+	}
+
+	for msgName, msg := range canModel.Messages {
+		m, err := newMessage(msgName, msg)
+		if err != nil {
+			return nil, err
+		}
+
+		if other, ok := c.messages[m.ID]; ok {
+			return nil, fmt.Errorf("[%s] message id [%d] is already taken by [%s]", m.Name, m.ID, other.Name)
+		}
+		c.messages[m.ID] = m
+		c.messagesByName[m.Name] = m
+	}
+
+	return c, nil
+}
+
+// Message returns the message with the given id.
+func (c *Codec) Message(id uint32) (*Message, bool) {
+	msg, ok := c.messages[id]
+	return msg, ok
+}
+
+// MessageByName returns the message with the given name.
+func (c *Codec) MessageByName(name string) (*Message, bool) {
+	msg, ok := c.messagesByName[name]
+	return msg, ok
+}
+
+// MessageByFrame returns the message matching the id of a frame on the bus.
+func (c *Codec) MessageByFrame(frameID uint32, extended bool) (*Message, bool) {
+	if extended {
+		if msg, ok := c.messages[frameID|ExtendedIDFlag]; ok {
+			return msg, true
+		}
+	}
+
+	msg, ok := c.messages[frameID]
+	if !ok || msg.IsExtended() != extended {
+		return nil, false
+	}
+	return msg, true
+}
+
+// Messages returns all the messages sorted by id.
+func (c *Codec) Messages() []*Message {
+	messages := make([]*Message, 0, len(c.messages))
+	for _, msg := range c.messages {
+		messages = append(messages, msg)
+	}
+
+	sort.Slice(messages, func(i, j int) bool {
+		return messages[i].ID < messages[j].ID
+	})
+
+	return messages
+}
+
+// Decode decodes the payload of the message with the given id.
+func (c *Codec) Decode(id uint32, data []byte) (*DecodedMessage, error) {
+	msg, ok := c.messages[id]
+	if !ok {
+		return nil, fmt.Errorf("message id [%d] is not defined", id)
+	}
+
+	values, err := msg.Decode(data)
+	if err != nil {
+		return nil, err
+	}
+
+	return &DecodedMessage{
+		Message: msg,
+		Signals: values,
+	}, nil
+}
+
+// Encode packs the physical values into the payload of the message with the given name.
+func (c *Codec) Encode(msgName string, values map[string]float64) ([]byte, error) {
+	msg, ok := c.messagesByName[msgName]
+	if !ok {
+		return nil, fmt.Errorf("message [%s] is not defined", msgName)
+	}
+
+	return msg.Encode(values)
+}
+
+// DecodedMessage represents a decoded CAN frame.
+type DecodedMessage struct {
+	Message *Message
+	Signals []*SignalValue
+}
+
+// SignalValue represents the decoded value of a signal.
+type SignalValue struct {
+	Signal *Signal
+	Raw    uint64
+	Value  float64
+	Label  string
+}
+
+// HasLabel returns true if the raw value maps to an enum label.
+func (sv *SignalValue) HasLabel() bool {
+	return len(sv.Label) > 0
+}
+
+// FormatValue returns the physical value formatted with the precision of the signal scale and offset.
+func (sv *SignalValue) FormatValue() string {
+	return sv.Signal.FormatValue(sv.Value)
+}
@@ -0,0 +1,217 @@
+package codec
+
+import (
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/squadracorsepolito/jsondbc/pkg"
+)
+
+// ExtendedIDFlag is set in the DBC id of the messages sent with extended frames.
+const ExtendedIDFlag uint32 = 1 << 31
+
+const maxStandardID = 0x7FF
+
+// Message represents a message of the CAN model ready to be decoded or encoded.
+type Message struct {
+	Name    string
+	ID      uint32
+	Length  uint32
+	Signals []*Signal
+	Model   *pkg.Message
+
+	signals map[string]*Signal
+}
+
+func newMessage(msgName string, msg *pkg.Message) (*Message, error) {
+	m := &Message{
+		Name:   msgName,
+		ID:     msg.ID,
+		Length: msg.Length,
+		Model:  msg,
+
+		signals: make(map[string]*Signal),
+	}
+
+	signals, err := m.newSignals(msg.Signals, nil)
+	if err != nil {
+		return nil, err
+	}
+	m.Signals = signals
+
+	return m, nil
+}
+
+func (m *Message) newSignals(sigMap map[string]*pkg.Signal, multiplexor *Signal) ([]*Signal, error) {
+	signals := make([]*Signal, 0, len(sigMap))
+
+	for sigName, sig := range sigMap {
+		if _, ok := m.signals[sigName]; ok {
+			return nil, fmt.Errorf("message [%s] has duplicated signal [%s]", m.Name, sigName)
+		}
+
+		s, err := newSignal(sigName, sig, m.Length)
+		if err != nil {
+			return nil, fmt.Errorf("message [%s]: %w", m.Name, err)
+		}
+		s.Multiplexor = multiplexor
+		m.signals[sigName] = s
+
+		if sig.IsMultiplexor() {
+			muxGroup, err := m.newSignals(sig.MuxGroup, s)
+			if err != nil {
+				return nil, err
+			}
+			s.MuxGroup = muxGroup
+		}
+
+		signals = append(signals, s)
+	}
+
+	sort.Slice(signals, func(i, j int) bool {
+		if signals[i].Model.StartBit == signals[j].Model.StartBit {
+			return signals[i].Name < signals[j].Name
+		}
+		return signals[i].Model.StartBit < signals[j].Model.StartBit
+	})
+
+	return signals, nil
+}
+
+// IsExtended returns true if the message is sent with an extended frame.
+func (m *Message) IsExtended() bool {
+	return m.ID&ExtendedIDFlag != 0 || m.ID > maxStandardID
+}
+
+// FrameID returns the id of the message on the bus, without the extended flag.
+func (m *Message) FrameID() uint32 {
+	return m.ID &^ ExtendedIDFlag
+}
+
+// FormatID returns the hexadecimal id of the message on the bus, with 8 digits for the extended ids.
+func (m *Message) FormatID() string {
+	if m.IsExtended() {
+		return fmt.Sprintf("%08X", m.FrameID())
+	}
+	return fmt.Sprintf("%03X", m.FrameID())
+}
+
+// CycleTime returns the cycle time of the message, taken from cycle_time or period_ms.
+// It returns 0 if the message is not cyclic.
+func (m *Message) CycleTime() time.Duration {
+	cycleTime := m.Model.CycleTime
+	if cycleTime <= 0 {
+		cycleTime = int(m.Model.Period)
+	}
+	return time.Duration(cycleTime) * time.Millisecond
+}
+
+// Deadline returns the deadline of the message, taken from deadline_ms or from the cycle time.
+func (m *Message) Deadline() time.Duration {
+	if m.Model.Deadline > 0 {
+		return time.Duration(m.Model.Deadline) * time.Millisecond
+	}
+	return m.CycleTime()
+}
+
+// Signal returns the signal with the given name, searching also in the mux groups.
+func (m *Message) Signal(name string) (*Signal, bool) {
+	sig, ok := m.signals[name]
+	return sig, ok
+}
+
+// Decode decodes the payload and returns the values of the signals
+// selected by the multiplexors.
+func (m *Message) Decode(data []byte) ([]*SignalValue, error) {
+	values := []*SignalValue{}
+	if err := m.decodeSignals(m.Signals, data, &values); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+func (m *Message) decodeSignals(signals []*Signal, data []byte, values *[]*SignalValue) error {
+	for _, sig := range signals {
+		raw, err := sig.Unpack(data)
+		if err != nil {
+			return fmt.Errorf("message [%s]: %w", m.Name, err)
+		}
+
+		*values = append(*values, &SignalValue{
+			Signal: sig,
+			Raw:    raw,
+			Value:  sig.Physical(raw),
+			Label:  sig.Label(raw),
+		})
+
+		if !sig.IsMultiplexor() {
+			continue
+		}
+
+		if err := m.decodeSignals(sig.selectedSignals(raw), data, values); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Encode packs the physical values of the signals into a payload of the message length.
+// Multiplexed signals are packed only if their multiplexor selects them.
+// Signals without a value are packed with their start value.
+func (m *Message) Encode(values map[string]float64) ([]byte, error) {
+	for sigName := range values {
+		if _, ok := m.signals[sigName]; !ok {
+			return nil, fmt.Errorf("message [%s] has no signal [%s]", m.Name, sigName)
+		}
+	}
+
+	data := make([]byte, m.Length)
+	packed := make(map[string]bool)
+	if err := m.encodeSignals(m.Signals, values, data, packed); err != nil {
+		return nil, err
+	}
+
+	for sigName := range values {
+		if !packed[sigName] {
+			sig := m.signals[sigName]
+			return nil, fmt.Errorf("message [%s]: signal [%s] is not selected by multiplexor [%s]", m.Name, sigName, sig.Multiplexor.Name)
+		}
+	}
+
+	return data, nil
+}
+
+func (m *Message) encodeSignals(signals []*Signal, values map[string]float64, data []byte, packed map[string]bool) error {
+	for _, sig := range signals {
+		value, ok := values[sig.Name]
+		if ok {
+			if err := sig.CheckRange(value); err != nil {
+				return fmt.Errorf("message [%s]: %w", m.Name, err)
+			}
+		} else {
+			value = sig.StartValue()
+		}
+
+		raw, err := sig.Raw(value)
+		if err != nil {
+			return fmt.Errorf("message [%s]: %w", m.Name, err)
+		}
+
+		if err := sig.Pack(data, raw); err != nil {
+			return fmt.Errorf("message [%s]: %w", m.Name, err)
+		}
+		packed[sig.Name] = true
+
+		if !sig.IsMultiplexor() {
+			continue
+		}
+
+		if err := m.encodeSignals(sig.selectedSignals(raw), values, data, packed); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
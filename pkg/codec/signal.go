@@ -0,0 +1,294 @@
+package codec
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/squadracorsepolito/jsondbc/pkg"
+	"github.com/squadracorsepolito/jsondbc/pkg/sym"
+)
+
+const maxSignalSize = 64
+
+// Signal represents a signal of a message ready to be decoded or encoded.
+type Signal struct {
+	Name        string
+	Model       *pkg.Signal
+	MuxGroup    []*Signal
+	Multiplexor *Signal
+
+	// positions contains the payload bit positions from the LSB to the MSB of the raw value
+	positions []uint32
+	labels    map[uint64]string
+}
+
+func newSignal(sigName string, sig *pkg.Signal, msgLength uint32) (*Signal, error) {
+	if sig.Size == 0 {
+		return nil, fmt.Errorf("signal [%s] size cannot be 0", sigName)
+	}
+	if sig.Size > maxSignalSize {
+		return nil, fmt.Errorf("signal [%s] size %d exceeds %d bits", sigName, sig.Size, maxSignalSize)
+	}
+
+	s := &Signal{
+		Name:  sigName,
+		Model: sig,
+
+		positions: getBitPositions(sig.StartBit, sig.Size, sig.Endianness == "big"),
+		labels:    make(map[uint64]string, len(sig.Enum)),
+	}
+
+	for _, pos := range s.positions {
+		if pos >= msgLength*8 {
+			return nil, fmt.Errorf("signal [%s] exceeds message length %d: start bit %d, size %d", sigName, msgLength, sig.StartBit, sig.Size)
+		}
+	}
+
+	for label, value := range sig.Enum {
+		// keep the first label in alphabetical order when more labels share the same value
+		if curr, ok := s.labels[uint64(value)]; ok && curr < label {
+			continue
+		}
+		s.labels[uint64(value)] = label
+	}
+
+	return s, nil
+}
+
+// getBitPositions returns the payload bit positions of a signal, ordered from the LSB to the MSB.
+// For little endian signals the start bit is the LSB, for big endian signals
+// it is the MSB following the DBC (Motorola) sawtooth bit numbering.
+func getBitPositions(startBit, size uint32, isBigEndian bool) []uint32 {
+	positions := make([]uint32, size)
+
+	if !isBigEndian {
+		for i := uint32(0); i < size; i++ {
+			positions[i] = startBit + i
+		}
+		return positions
+	}
+
+	pos := startBit
+	for i := int(size) - 1; i >= 0; i-- {
+		positions[i] = pos
+		if pos%8 == 0 {
+			pos += 15
+		} else {
+			pos--
+		}
+	}
+
+	return positions
+}
+
+// IsMultiplexor returns true if the signal is a multiplexor.
+func (s *Signal) IsMultiplexor() bool {
+	return len(s.MuxGroup) > 0
+}
+
+// IsMultiplexed returns true if the signal is part of a mux group.
+func (s *Signal) IsMultiplexed() bool {
+	return s.Multiplexor != nil
+}
+
+// BitPositions returns the payload bit positions of the signal, ordered from the LSB to the MSB.
+func (s *Signal) BitPositions() []uint32 {
+	return s.positions
+}
+
+func (s *Signal) selectedSignals(raw uint64) []*Signal {
+	selected := []*Signal{}
+	for _, muxSig := range s.MuxGroup {
+		if uint64(muxSig.Model.MuxSwitch) == raw {
+			selected = append(selected, muxSig)
+		}
+	}
+	return selected
+}
+
+func (s *Signal) mask() uint64 {
+	if s.Model.Size == maxSignalSize {
+		return math.MaxUint64
+	}
+	return 1<<s.Model.Size - 1
+}
+
+// Unpack extracts the raw value of the signal from the payload.
+func (s *Signal) Unpack(data []byte) (uint64, error) {
+	raw := uint64(0)
+	for i, pos := range s.positions {
+		if int(pos/8) >= len(data) {
+			return 0, fmt.Errorf("signal [%s] exceeds payload length %d", s.Name, len(data))
+		}
+		if data[pos/8]&(1<<(pos%8)) != 0 {
+			raw |= 1 << i
+		}
+	}
+	return raw, nil
+}
+
+// Pack inserts the raw value of the signal into the payload.
+func (s *Signal) Pack(data []byte, raw uint64) error {
+	for i, pos := range s.positions {
+		if int(pos/8) >= len(data) {
+			return fmt.Errorf("signal [%s] exceeds payload length %d", s.Name, len(data))
+		}
+		if raw&(1<<i) != 0 {
+			data[pos/8] |= 1 << (pos % 8)
+		} else {
+			data[pos/8] &^= 1 << (pos % 8)
+		}
+	}
+	return nil
+}
+
+// Signed returns the raw value interpreted as a two's complement integer of the signal size.
+func (s *Signal) Signed(raw uint64) int64 {
+	size := s.Model.Size
+	if size < maxSignalSize && raw&(1<<(size-1)) != 0 {
+		raw |= ^s.mask()
+	}
+	return int64(raw)
+}
+
+// Physical converts the raw value into the physical one by applying scale and offset.
+func (s *Signal) Physical(raw uint64) float64 {
+	if s.Model.Signed {
+		return float64(s.Signed(raw))*s.scale() + s.Model.Offset
+	}
+	return float64(raw)*s.scale() + s.Model.Offset
+}
+
+// Raw converts the physical value into the raw one.
+// It returns an error if the value is not a finite number or if it cannot be represented with the signal size.
+func (s *Signal) Raw(value float64) (uint64, error) {
+	if err := s.checkFinite(value); err != nil {
+		return 0, err
+	}
+	tmpRaw := math.Round((value - s.Model.Offset) / s.scale())
+	if err := s.checkFinite(tmpRaw); err != nil {
+		return 0, err
+	}
+
+	// the bounds are compared as the exclusive powers of 2, that are exact as float64 also for the 64 bit signals
+	if s.Model.Signed {
+		limit := math.Ldexp(1, int(s.Model.Size)-1)
+		if tmpRaw < -limit || tmpRaw >= limit {
+			minRaw := -int64(1) << (s.Model.Size - 1)
+			maxRaw := int64(s.mask() >> 1)
+			return 0, fmt.Errorf("signal [%s] value %s out of raw range [%d|%d]", s.Name, formatFloat(value), minRaw, maxRaw)
+		}
+		return uint64(int64(tmpRaw)) & s.mask(), nil
+	}
+
+	if tmpRaw < 0 || tmpRaw >= math.Ldexp(1, int(s.Model.Size)) {
+		return 0, fmt.Errorf("signal [%s] value %s out of raw range [0|%d]", s.Name, formatFloat(value), s.mask())
+	}
+	return uint64(tmpRaw), nil
+}
+
+// checkFinite returns an error if the value is NaN or infinite.
+func (s *Signal) checkFinite(value float64) error {
+	if math.IsNaN(value) || math.IsInf(value, 0) {
+		return fmt.Errorf("signal [%s] value %s is not a finite number", s.Name, formatFloat(value))
+	}
+	return nil
+}
+
+// CheckRange returns an error if the physical value is not a finite number or if it is outside the signal [min|max] range.
+// The range is not checked if both min and max are 0.
+func (s *Signal) CheckRange(value float64) error {
+	if err := s.checkFinite(value); err != nil {
+		return err
+	}
+	if s.Model.Min == 0 && s.Model.Max == 0 {
+		return nil
+	}
+	if value < s.Model.Min || value > s.Model.Max {
+		return fmt.Errorf("signal [%s] value %s out of range [%s|%s]", s.Name, formatFloat(value), formatFloat(s.Model.Min), formatFloat(s.Model.Max))
+	}
+	return nil
+}
+
+// StartValue returns the physical start value of the signal.
+// The start value of a DBC model is the raw value of its GenSigStartValue attribute, if any.
+// If the start value is not set, it is the physical value of the raw 0, that is the offset.
+func (s *Signal) StartValue() float64 {
+	if s.Model.StartValue != nil {
+		return *s.Model.StartValue
+	}
+
+	if s.Model.AttributeAssignments != nil {
+		switch raw := s.Model.Attributes[sym.SigStartValue].(type) {
+		case int:
+			return float64(raw)*s.scale() + s.Model.Offset
+		case float64:
+			return raw*s.scale() + s.Model.Offset
+		}
+	}
+
+	return s.Model.Offset
+}
+
+func (s *Signal) scale() float64 {
+	if s.Model.Scale == 0 {
+		return 1
+	}
+	return s.Model.Scale
+}
+
+// Label returns the enum label of the raw value, or an empty string if there is none.
+func (s *Signal) Label(raw uint64) string {
+	return s.labels[raw]
+}
+
+// LabelValue returns the physical value of the given enum label.
+func (s *Signal) LabelValue(label string) (float64, bool) {
+	raw, ok := s.Model.Enum[label]
+	if !ok {
+		return 0, false
+	}
+	return s.Physical(uint64(raw)), true
+}
+
+// FormatValue returns the physical value formatted with the precision of the signal scale and offset.
+func (s *Signal) FormatValue(value float64) string {
+	return formatFloatPrec(value, max(getDecimals(s.scale()), getDecimals(s.Model.Offset)))
+}
+
+// Segment represents a run of contiguous signal bits inside a payload byte.
+// The value bits [ValueBit, ValueBit+Size) are stored in the byte bits [ByteBit, ByteBit+Size).
+type Segment struct {
+	Byte     uint32
+	ByteBit  uint32
+	ValueBit uint32
+	Size     uint32
+}
+
+// Mask returns the mask of the segment bits, aligned to bit 0.
+func (seg *Segment) Mask() uint8 {
+	return uint8(1<<seg.Size - 1)
+}
+
+// Segments returns the segments of the signal, ordered from the LSB to the MSB.
+func (s *Signal) Segments() []*Segment {
+	segments := []*Segment{}
+
+	var curr *Segment
+	for i, pos := range s.positions {
+		if curr != nil && pos/8 == curr.Byte && pos%8 == curr.ByteBit+curr.Size {
+			curr.Size++
+			continue
+		}
+
+		curr = &Segment{
+			Byte:     pos / 8,
+			ByteBit:  pos % 8,
+			ValueBit: uint32(i),
+			Size:     1,
+		}
+		segments = append(segments, curr)
+	}
+
+	return segments
+}
@@ -0,0 +1,33 @@
+package codec
+
+import (
+	"strconv"
+	"strings"
+)
+
+const maxDecimals = 9
+
+func formatFloat(val float64) string {
+	return strconv.FormatFloat(val, 'f', -1, 64)
+}
+
+// formatFloatPrec formats the value with at most prec decimals, removing the trailing zeros.
+func formatFloatPrec(val float64, prec int) string {
+	str := strconv.FormatFloat(val, 'f', prec, 64)
+	if strings.Contains(str, ".") {
+		str = strings.TrimRight(strings.TrimRight(str, "0"), ".")
+	}
+	if str == "-0" {
+		return "0"
+	}
+	return str
+}
+
+// getDecimals returns the number of decimals needed to represent the value.
+func getDecimals(val float64) int {
+	_, dec, ok := strings.Cut(formatFloat(val), ".")
+	if !ok {
+		return 0
+	}
+	return min(len(dec), maxDecimals)
+}
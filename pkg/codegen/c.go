@@ -0,0 +1,618 @@
+package codegen
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/squadracorsepolito/jsondbc/pkg/codec"
+)
+
+var cKeywords = map[string]bool{
+	"auto": true, "break": true, "case": true, "char": true, "const": true, "continue": true,
+	"default": true, "do": true, "double": true, "else": true, "enum": true, "extern": true,
+	"float": true, "for": true, "goto": true, "if": true, "inline": true, "int": true,
+	"long": true, "register": true, "restrict": true, "return": true, "short": true,
+	"signed": true, "sizeof": true, "static": true, "struct": true, "switch": true,
+	"typedef": true, "union": true, "unsigned": true, "void": true, "volatile": true,
+	"while": true, "bool": true, "true": true, "false": true,
+}
+
+// cGenerator generates a C header and source file with the pack/unpack functions of the messages.
+type cGenerator struct {
+	m *Model
+
+	prefix      string
+	macroPrefix string
+}
+
+// GenerateC generates the C header and source files of the model.
+func GenerateC(m *Model) ([]*File, error) {
+	g := &cGenerator{
+		m: m,
+
+		prefix:      snakeCase(m.Name),
+		macroPrefix: upperSnakeCase(m.Name),
+	}
+
+	if err := g.checkNames(); err != nil {
+		return nil, err
+	}
+
+	source, err := g.generateSource()
+	if err != nil {
+		return nil, err
+	}
+
+	return []*File{
+		{Name: g.prefix + ".h", Content: g.generateHeader()},
+		{Name: g.prefix + ".c", Content: source},
+	}, nil
+}
+
+func (g *cGenerator) checkNames() error {
+	msgNames := []string{}
+	for _, msg := range g.m.Messages {
+		msgNames = append(msgNames, msg.Name)
+
+		sigNames := []string{}
+		for _, sig := range g.m.MessageSignals(msg) {
+			sigNames = append(sigNames, sig.Name)
+		}
+		if err := checkNames(fmt.Sprintf("message [%s]", msg.Name), sigNames, snakeCase); err != nil {
+			return err
+		}
+	}
+	return checkNames("messages", msgNames, snakeCase)
+}
+
+func (g *cGenerator) msgIdent(msg *codec.Message) string {
+	return g.prefix + "_" + snakeCase(msg.Name)
+}
+
+func (g *cGenerator) msgMacro(msg *codec.Message) string {
+	return g.macroPrefix + "_" + upperSnakeCase(msg.Name)
+}
+
+func (g *cGenerator) msgType(msg *codec.Message) string {
+	return g.msgIdent(msg) + "_t"
+}
+
+func (g *cGenerator) fieldIdent(sig *codec.Signal) string {
+	return safeIdent(snakeCase(sig.Name), cKeywords)
+}
+
+func (g *cGenerator) sigIdent(msg *codec.Message, sig *codec.Signal) string {
+	return g.msgIdent(msg) + "_" + snakeCase(sig.Name)
+}
+
+func (g *cGenerator) enumType(msg *codec.Message, enum *Enum) string {
+	if enum.IsGlobal {
+		return g.prefix + "_" + snakeCase(enum.Name) + "_t"
+	}
+	return g.msgIdent(msg) + "_" + snakeCase(enum.Name) + "_t"
+}
+
+func (g *cGenerator) enumValueMacro(msg *codec.Message, enum *Enum, val *EnumValue) string {
+	if enum.IsGlobal {
+		return g.macroPrefix + "_" + upperSnakeCase(enum.Name) + "_" + upperSnakeCase(val.Name)
+	}
+	return g.msgMacro(msg) + "_" + upperSnakeCase(enum.Name) + "_" + upperSnakeCase(val.Name)
+}
+
+func (g *cGenerator) rawType(sig *codec.Signal) string {
+	return fmt.Sprintf("uint%d_t", rawTypeSize(sig))
+}
+
+func (g *cGenerator) fieldType(sig *codec.Signal) string {
+	if sig.Model.Signed {
+		return fmt.Sprintf("int%d_t", rawTypeSize(sig))
+	}
+	return g.rawType(sig)
+}
+
+func (g *cGenerator) rawVar(sig *codec.Signal) string {
+	return fmt.Sprintf("raw_u%d", rawTypeSize(sig))
+}
+
+// cUint returns an unsigned C literal of the given type size.
+func cUint(val uint64, typeSize int) string {
+	if typeSize == 64 {
+		return fmt.Sprintf("0x%xull", val)
+	}
+	return fmt.Sprintf("0x%xu", val)
+}
+
+// cDouble returns a double literal, valid both in C and in Go.
+func cDouble(val float64) string {
+	str := strconv.FormatFloat(val, 'g', -1, 64)
+	if !strings.ContainsAny(str, ".eE") {
+		str += ".0"
+	}
+	return str
+}
+
+// cComment escapes a string to be placed inside a C block comment.
+func cComment(str string) string {
+	return strings.ReplaceAll(str, "*/", "* /")
+}
+
+func (g *cGenerator) writeDocComment(w *codeWriter, lines ...string) {
+	w.line("/**")
+	for _, l := range lines {
+		if len(l) == 0 {
+			w.line(" *")
+			continue
+		}
+		w.line(" * %s", cComment(l))
+	}
+	w.line(" */")
+}
+
+func (g *cGenerator) writeFileComment(w *codeWriter) {
+	w.line("/**")
+	w.line(" * Generated by jsondbc from the CAN model \"%s\" version \"%s\".", cComment(g.m.Name), cComment(g.m.Version))
+	w.line(" * Do not edit.")
+	w.line(" */")
+	w.newLine()
+}
+
+func (g *cGenerator) signalDoc(sig *codec.Signal) []string {
+	lines := commentLines(sig.Model.Description)
+	if len(lines) > 0 {
+		lines = append(lines, "")
+	}
+
+	rng := fmt.Sprintf("Range: %s..%s", formatFloat(sig.Model.Min), formatFloat(sig.Model.Max))
+	if len(sig.Model.Unit) > 0 {
+		rng += " (" + sig.Model.Unit + ")"
+	}
+	lines = append(lines,
+		rng,
+		fmt.Sprintf("Scale: %s", formatFloat(sig.Model.Scale)),
+		fmt.Sprintf("Offset: %s", formatFloat(sig.Model.Offset)),
+	)
+
+	if sig.IsMultiplexed() {
+		lines = append(lines, fmt.Sprintf("Multiplexed by %s when equal to %d", sig.Multiplexor.Name, sig.Model.MuxSwitch))
+	}
+
+	return lines
+}
+
+func (g *cGenerator) generateHeader() []byte {
+	w := newCodeWriter("    ")
+	guard := g.macroPrefix + "_H"
+
+	g.writeFileComment(w)
+	w.line("#ifndef %s", guard)
+	w.line("#define %s", guard)
+	w.newLine()
+	w.line("#ifdef __cplusplus")
+	w.line("extern \"C\" {")
+	w.line("#endif")
+	w.newLine()
+	w.line("#include <stdbool.h>")
+	w.line("#include <stddef.h>")
+	w.line("#include <stdint.h>")
+	w.newLine()
+
+	w.line("/* Frame ids. */")
+	for _, msg := range g.m.Messages {
+		w.line("#define %s_FRAME_ID (%s)", g.msgMacro(msg), cUint(uint64(msg.FrameID()), 32))
+	}
+	w.newLine()
+
+	w.line("/* Frame lengths in bytes. */")
+	for _, msg := range g.m.Messages {
+		w.line("#define %s_LENGTH (%du)", g.msgMacro(msg), msg.Length)
+	}
+	w.newLine()
+
+	w.line("/* Extended or standard frame types. */")
+	for _, msg := range g.m.Messages {
+		isExt := 0
+		if msg.IsExtended() {
+			isExt = 1
+		}
+		w.line("#define %s_IS_EXTENDED (%d)", g.msgMacro(msg), isExt)
+	}
+	w.newLine()
+
+	hasCycleTimes := false
+	for _, msg := range g.m.Messages {
+		if msg.Model.CycleTime <= 0 {
+			continue
+		}
+		if !hasCycleTimes {
+			w.line("/* Frame cycle times in milliseconds. */")
+			hasCycleTimes = true
+		}
+		w.line("#define %s_CYCLE_TIME_MS (%du)", g.msgMacro(msg), msg.Model.CycleTime)
+	}
+	if hasCycleTimes {
+		w.newLine()
+	}
+
+	for _, enum := range g.m.Enums {
+		g.writeEnum(w, nil, enum)
+	}
+	for _, msg := range g.m.Messages {
+		for _, sig := range g.m.MessageSignals(msg) {
+			if enum, ok := g.m.SignalEnum(sig); ok && !enum.IsGlobal {
+				g.writeEnum(w, msg, enum)
+			}
+		}
+	}
+
+	for _, msg := range g.m.Messages {
+		g.writeMessageStruct(w, msg)
+	}
+
+	for _, msg := range g.m.Messages {
+		g.writeMessagePrototypes(w, msg)
+	}
+
+	w.line("#ifdef __cplusplus")
+	w.line("}")
+	w.line("#endif")
+	w.newLine()
+	w.line("#endif /* %s */", guard)
+
+	return w.bytes()
+}
+
+func (g *cGenerator) writeEnum(w *codeWriter, msg *codec.Message, enum *Enum) {
+	if enum.IsGlobal {
+		g.writeDocComment(w, fmt.Sprintf("Signal enum %s.", enum.Name))
+	} else {
+		g.writeDocComment(w, fmt.Sprintf("Values of signal %s of message %s.", enum.Name, msg.Name))
+	}
+	w.line("typedef enum {")
+	w.in()
+	for _, val := range enum.Values {
+		w.line("%s = %d,", g.enumValueMacro(msg, enum, val), val.Value)
+	}
+	w.out()
+	w.line("} %s;", g.enumType(msg, enum))
+	w.newLine()
+}
+
+func (g *cGenerator) writeMessageStruct(w *codeWriter, msg *codec.Message) {
+	lines := []string{fmt.Sprintf("Signals of message %s (0x%x).", msg.Name, msg.FrameID())}
+	if desc := commentLines(msg.Model.Description); len(desc) > 0 {
+		lines = append(lines, "")
+		lines = append(lines, desc...)
+	}
+	lines = append(lines, "", "All the signal values are raw.")
+	g.writeDocComment(w, lines...)
+
+	w.line("typedef struct {")
+	w.in()
+	signals := g.m.MessageSignals(msg)
+	for idx, sig := range signals {
+		g.writeDocComment(w, g.signalDoc(sig)...)
+		w.line("%s %s;", g.fieldType(sig), g.fieldIdent(sig))
+		if idx < len(signals)-1 {
+			w.newLine()
+		}
+	}
+	if len(signals) == 0 {
+		w.line("/* Dummy field, the message has no signals. */")
+		w.line("uint8_t dummy;")
+	}
+	w.out()
+	w.line("} %s;", g.msgType(msg))
+	w.newLine()
+}
+
+func (g *cGenerator) writeMessagePrototypes(w *codeWriter, msg *codec.Message) {
+	msgIdent := g.msgIdent(msg)
+	msgType := g.msgType(msg)
+
+	g.writeDocComment(w,
+		fmt.Sprintf("Pack message %s.", msg.Name),
+		"Multiplexed signals are packed only if selected by their multiplexor.",
+		"",
+		"@param[out] dst_p Buffer to pack the message into.",
+		"@param[in] src_p Data to pack.",
+		"@param[in] size Size of dst_p.",
+		"",
+		"@return Size of packed data, or negative error code.",
+	)
+	w.line("int %s_pack(uint8_t *dst_p, const %s *src_p, size_t size);", msgIdent, msgType)
+	w.newLine()
+
+	g.writeDocComment(w,
+		fmt.Sprintf("Unpack message %s.", msg.Name),
+		"Multiplexed signals not selected by their multiplexor are set to 0.",
+		"",
+		"@param[out] dst_p Object to unpack the message into.",
+		"@param[in] src_p Message to unpack.",
+		"@param[in] size Size of src_p.",
+		"",
+		"@return zero(0) or negative error code.",
+	)
+	w.line("int %s_unpack(%s *dst_p, const uint8_t *src_p, size_t size);", msgIdent, msgType)
+	w.newLine()
+
+	g.writeDocComment(w,
+		fmt.Sprintf("Init message %s with the start values of the signals.", msg.Name),
+		"",
+		"@param[out] msg_p Message to init.",
+		"",
+		"@return zero(0) or negative error code.",
+	)
+	w.line("int %s_init(%s *msg_p);", msgIdent, msgType)
+	w.newLine()
+
+	for _, sig := range g.m.MessageSignals(msg) {
+		sigIdent := g.sigIdent(msg, sig)
+		fieldType := g.fieldType(sig)
+
+		g.writeDocComment(w,
+			fmt.Sprintf("Encode the physical value of signal %s into its raw value.", sig.Name),
+			"The value is saturated to the raw range of the signal.",
+		)
+		w.line("%s %s_encode(double value);", fieldType, sigIdent)
+		w.newLine()
+
+		g.writeDocComment(w, fmt.Sprintf("Decode the raw value of signal %s into its physical value.", sig.Name))
+		w.line("double %s_decode(%s value);", sigIdent, fieldType)
+		w.newLine()
+
+		g.writeDocComment(w, fmt.Sprintf("Check that the raw value of signal %s is within the [min|max] range.", sig.Name))
+		w.line("bool %s_is_in_range(%s value);", sigIdent, fieldType)
+		w.newLine()
+	}
+}
+
+func (g *cGenerator) generateSource() ([]byte, error) {
+	w := newCodeWriter("    ")
+
+	g.writeFileComment(w)
+	w.line("#include <errno.h>")
+	w.line("#include <string.h>")
+	w.newLine()
+	w.line("#include \"%s.h\"", g.prefix)
+	w.newLine()
+
+	for _, msg := range g.m.Messages {
+		g.writePack(w, msg)
+		g.writeUnpack(w, msg)
+		if err := g.writeInit(w, msg); err != nil {
+			return nil, err
+		}
+
+		for _, sig := range g.m.MessageSignals(msg) {
+			g.writeSignalFunctions(w, msg, sig)
+		}
+	}
+
+	return w.bytes(), nil
+}
+
+// writeRawVars declares the temporary raw variables used by the signals of the message.
+func (g *cGenerator) writeRawVars(w *codeWriter, msg *codec.Message) {
+	used := make(map[int]bool)
+	for _, sig := range g.m.MessageSignals(msg) {
+		used[rawTypeSize(sig)] = true
+	}
+	for _, size := range []int{8, 16, 32, 64} {
+		if used[size] {
+			w.line("uint%d_t raw_u%d;", size, size)
+		}
+	}
+	if len(used) > 0 {
+		w.newLine()
+	}
+}
+
+func (g *cGenerator) writePack(w *codeWriter, msg *codec.Message) {
+	w.line("int %s_pack(uint8_t *dst_p, const %s *src_p, size_t size)", g.msgIdent(msg), g.msgType(msg))
+	w.line("{")
+	w.in()
+	g.writeRawVars(w, msg)
+	w.line("if ((dst_p == NULL) || (src_p == NULL) || (size < %du)) {", msg.Length)
+	w.in()
+	w.line("return (-EINVAL);")
+	w.out()
+	w.line("}")
+	w.newLine()
+	w.line("memset(&dst_p[0], 0, %du);", msg.Length)
+	w.newLine()
+
+	g.writePackSignals(w, msg.Signals)
+
+	w.line("return (%d);", msg.Length)
+	w.out()
+	w.line("}")
+	w.newLine()
+}
+
+func (g *cGenerator) writePackSignals(w *codeWriter, signals []*codec.Signal) {
+	for _, sig := range signals {
+		rawVar := g.rawVar(sig)
+		typeSize := rawTypeSize(sig)
+
+		w.line("/* %s */", cComment(sig.Name))
+		w.line("%s = (%s)src_p->%s;", rawVar, g.rawType(sig), g.fieldIdent(sig))
+		for _, seg := range sig.Segments() {
+			w.line("dst_p[%d] |= (uint8_t)(((%s >> %d) & %s) << %d);", seg.Byte, rawVar, seg.ValueBit, cUint(uint64(seg.Mask()), typeSize), seg.ByteBit)
+		}
+		w.newLine()
+
+		if sig.IsMultiplexor() {
+			g.writeMuxSwitch(w, sig, "src_p", g.writePackSignals)
+		}
+	}
+}
+
+func (g *cGenerator) writeMuxSwitch(w *codeWriter, muxor *codec.Signal, structVar string, writeSignals func(*codeWriter, []*codec.Signal)) {
+	w.line("switch (%s->%s) {", structVar, g.fieldIdent(muxor))
+	for _, muxSwitch := range MuxSwitches(muxor) {
+		w.line("case %d:", muxSwitch)
+		w.in()
+		writeSignals(w, MuxedSignals(muxor, muxSwitch))
+		w.line("break;")
+		w.out()
+		w.newLine()
+	}
+	w.line("default:")
+	w.in()
+	w.line("break;")
+	w.out()
+	w.line("}")
+	w.newLine()
+}
+
+func (g *cGenerator) writeUnpack(w *codeWriter, msg *codec.Message) {
+	w.line("int %s_unpack(%s *dst_p, const uint8_t *src_p, size_t size)", g.msgIdent(msg), g.msgType(msg))
+	w.line("{")
+	w.in()
+	g.writeRawVars(w, msg)
+	w.line("if ((dst_p == NULL) || (src_p == NULL) || (size < %du)) {", msg.Length)
+	w.in()
+	w.line("return (-EINVAL);")
+	w.out()
+	w.line("}")
+	w.newLine()
+	w.line("memset(dst_p, 0, sizeof(*dst_p));")
+	w.newLine()
+
+	g.writeUnpackSignals(w, msg.Signals)
+
+	w.line("return (0);")
+	w.out()
+	w.line("}")
+	w.newLine()
+}
+
+func (g *cGenerator) writeUnpackSignals(w *codeWriter, signals []*codec.Signal) {
+	for _, sig := range signals {
+		rawVar := g.rawVar(sig)
+		rawType := g.rawType(sig)
+		typeSize := rawTypeSize(sig)
+
+		w.line("/* %s */", cComment(sig.Name))
+		w.line("%s = 0;", rawVar)
+		for _, seg := range sig.Segments() {
+			w.line("%s |= (%s)((%s)((src_p[%d] >> %d) & %s) << %d);", rawVar, rawType, rawType, seg.Byte, seg.ByteBit, cUint(uint64(seg.Mask()), typeSize), seg.ValueBit)
+		}
+
+		if sig.Model.Signed && int(sig.Model.Size) < typeSize {
+			signBit := uint64(1) << (sig.Model.Size - 1)
+			extMask := ^uint64(0) << sig.Model.Size
+			if typeSize < 64 {
+				extMask &= 1<<typeSize - 1
+			}
+			w.line("if ((%s & %s) != 0u) {", rawVar, cUint(signBit, typeSize))
+			w.in()
+			w.line("%s |= %s;", rawVar, cUint(extMask, typeSize))
+			w.out()
+			w.line("}")
+		}
+
+		w.line("dst_p->%s = (%s)%s;", g.fieldIdent(sig), g.fieldType(sig), rawVar)
+		w.newLine()
+
+		if sig.IsMultiplexor() {
+			g.writeMuxSwitch(w, sig, "dst_p", g.writeUnpackSignals)
+		}
+	}
+}
+
+func (g *cGenerator) writeInit(w *codeWriter, msg *codec.Message) error {
+	w.line("int %s_init(%s *msg_p)", g.msgIdent(msg), g.msgType(msg))
+	w.line("{")
+	w.in()
+	w.line("if (msg_p == NULL) {")
+	w.in()
+	w.line("return (-EINVAL);")
+	w.out()
+	w.line("}")
+	w.newLine()
+	w.line("memset(msg_p, 0, sizeof(*msg_p));")
+
+	for _, sig := range g.m.MessageSignals(msg) {
+		raw, err := sig.Raw(sig.StartValue())
+		if err != nil {
+			return fmt.Errorf("message [%s]: start value: %w", msg.Name, err)
+		}
+		// the message is already zeroed
+		if raw == 0 {
+			continue
+		}
+		if sig.Model.Signed {
+			w.line("msg_p->%s = %d;", g.fieldIdent(sig), sig.Signed(raw))
+		} else {
+			w.line("msg_p->%s = %s;", g.fieldIdent(sig), cUint(raw, rawTypeSize(sig)))
+		}
+	}
+
+	w.newLine()
+	w.line("return (0);")
+	w.out()
+	w.line("}")
+	w.newLine()
+
+	return nil
+}
+
+func (g *cGenerator) writeSignalFunctions(w *codeWriter, msg *codec.Message, sig *codec.Signal) {
+	sigIdent := g.sigIdent(msg, sig)
+	fieldType := g.fieldType(sig)
+	scale := cDouble(sig.Model.Scale)
+	offset := cDouble(sig.Model.Offset)
+
+	rawMin, rawMax := 0.0, math.Ldexp(1, int(sig.Model.Size))-1
+	if sig.Model.Signed {
+		rawMin = -math.Ldexp(1, int(sig.Model.Size)-1)
+		rawMax = math.Ldexp(1, int(sig.Model.Size)-1) - 1
+	}
+
+	w.line("%s %s_encode(double value)", fieldType, sigIdent)
+	w.line("{")
+	w.in()
+	w.line("double raw = (value - %s) / %s;", offset, scale)
+	w.newLine()
+	w.line("if (raw < %s) {", cDouble(rawMin))
+	w.in()
+	w.line("raw = %s;", cDouble(rawMin))
+	w.out()
+	w.line("} else if (raw > %s) {", cDouble(rawMax))
+	w.in()
+	w.line("raw = %s;", cDouble(rawMax))
+	w.out()
+	w.line("}")
+	w.newLine()
+	w.line("return (%s)((raw < 0.0) ? (raw - 0.5) : (raw + 0.5));", fieldType)
+	w.out()
+	w.line("}")
+	w.newLine()
+
+	w.line("double %s_decode(%s value)", sigIdent, fieldType)
+	w.line("{")
+	w.in()
+	w.line("return (((double)value * %s) + %s);", scale, offset)
+	w.out()
+	w.line("}")
+	w.newLine()
+
+	w.line("bool %s_is_in_range(%s value)", sigIdent, fieldType)
+	w.line("{")
+	w.in()
+	if sig.Model.Min == 0 && sig.Model.Max == 0 {
+		w.line("(void)value;")
+		w.newLine()
+		w.line("return (true);")
+	} else {
+		w.line("double phys = %s_decode(value);", sigIdent)
+		w.newLine()
+		w.line("return ((phys >= %s) && (phys <= %s));", cDouble(sig.Model.Min), cDouble(sig.Model.Max))
+	}
+	w.out()
+	w.line("}")
+	w.newLine()
+}
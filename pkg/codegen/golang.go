@@ -0,0 +1,615 @@
+package codegen
+
+import (
+	"fmt"
+	"go/format"
+	"strings"
+	"unicode"
+
+	"github.com/squadracorsepolito/jsondbc/pkg/codec"
+)
+
+// goMessageMethods are the methods of the generated message structs,
+// fields with the same name are not allowed.
+var goMessageMethods = []string{"MessageName", "FrameID", "IsExtended", "MarshalCAN", "UnmarshalCAN"}
+
+// goReservedIdents are the top level identifiers always declared by the generated package.
+var goReservedIdents = []string{
+	"Message", "NewMessage", "UnmarshalCAN", "messageKey", "messagesByKey",
+	"rawMask", "uintToRaw", "intToRaw", "floatToRaw", "rawToInt", "rawToFloat", "checkPhysRange",
+}
+
+// goName returns the name as an exported Go identifier.
+// Words written in upper case of up to 4 characters are considered acronyms and kept as they are.
+func goName(name string) string {
+	words := splitWords(name)
+	for i, w := range words {
+		if len(w) <= 4 && strings.ToUpper(w) == w {
+			continue
+		}
+		runes := []rune(strings.ToLower(w))
+		runes[0] = unicode.ToUpper(runes[0])
+		words[i] = string(runes)
+	}
+
+	ident := strings.Join(words, "")
+	if len(ident) == 0 || unicode.IsDigit(rune(ident[0])) {
+		return "X" + ident
+	}
+	return ident
+}
+
+// goPackageName returns the name as a Go package name.
+func goPackageName(name string) string {
+	pkgName := strings.ReplaceAll(snakeCase(name), "_", "")
+	if len(pkgName) == 0 || unicode.IsDigit(rune(pkgName[0])) {
+		return "x" + pkgName
+	}
+	return pkgName
+}
+
+// goGenerator generates a Go package with a struct for each message.
+type goGenerator struct {
+	m *Model
+
+	pkgName string
+}
+
+// GenerateGo generates the Go source file of the model.
+func GenerateGo(m *Model) ([]*File, error) {
+	g := &goGenerator{
+		m: m,
+
+		pkgName: goPackageName(m.Name),
+	}
+
+	if err := g.checkNames(); err != nil {
+		return nil, err
+	}
+
+	source, err := g.generateSource()
+	if err != nil {
+		return nil, err
+	}
+
+	return []*File{{Name: snakeCase(m.Name) + ".go", Content: source}}, nil
+}
+
+func (g *goGenerator) checkNames() error {
+	idents := make(map[string]string)
+	addIdent := func(ident, src string) error {
+		if other, ok := idents[ident]; ok {
+			return fmt.Errorf("%s and %s generate the same identifier [%s]", other, src, ident)
+		}
+		idents[ident] = src
+		return nil
+	}
+
+	for _, ident := range goReservedIdents {
+		idents[ident] = "the generated code"
+	}
+
+	for _, enum := range g.m.Enums {
+		if err := g.checkEnumNames(nil, enum, addIdent); err != nil {
+			return err
+		}
+	}
+
+	for _, msg := range g.m.Messages {
+		msgSrc := fmt.Sprintf("message [%s]", msg.Name)
+		msgType := g.msgType(msg)
+		for _, ident := range []string{msgType, msgType + "FrameID", msgType + "Length", msgType + "IsExtended"} {
+			if err := addIdent(ident, msgSrc); err != nil {
+				return err
+			}
+		}
+
+		fields := make(map[string]string)
+		for _, method := range goMessageMethods {
+			fields[method] = "the generated code"
+		}
+		for _, sig := range g.m.MessageSignals(msg) {
+			sigSrc := fmt.Sprintf("signal [%s] of message [%s]", sig.Name, msg.Name)
+			field := g.fieldName(sig)
+			if other, ok := fields[field]; ok {
+				return fmt.Errorf("%s and %s generate the same identifier [%s]", other, sigSrc, field)
+			}
+			fields[field] = sigSrc
+
+			if enum, ok := g.m.SignalEnum(sig); ok && !enum.IsGlobal {
+				if err := g.checkEnumNames(msg, enum, addIdent); err != nil {
+					return err
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+func (g *goGenerator) checkEnumNames(msg *codec.Message, enum *Enum, addIdent func(ident, src string) error) error {
+	enumType := g.enumType(msg, enum)
+
+	src := fmt.Sprintf("enum [%s]", enum.Name)
+	if !enum.IsGlobal {
+		src = fmt.Sprintf("enum of signal [%s] of message [%s]", enum.Name, msg.Name)
+	}
+	if err := addIdent(enumType, src); err != nil {
+		return err
+	}
+
+	for _, val := range enum.Values {
+		if err := addIdent(enumType+goName(val.Name), fmt.Sprintf("value [%s] of %s", val.Name, src)); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (g *goGenerator) msgType(msg *codec.Message) string {
+	return goName(msg.Name)
+}
+
+func (g *goGenerator) fieldName(sig *codec.Signal) string {
+	return goName(sig.Name)
+}
+
+func (g *goGenerator) enumType(msg *codec.Message, enum *Enum) string {
+	if enum.IsGlobal {
+		return goName(enum.Name)
+	}
+	return g.msgType(msg) + goName(enum.Name)
+}
+
+// enumRawType returns the smallest unsigned type able to hold all the values of the enum.
+func (g *goGenerator) enumRawType(enum *Enum) string {
+	maxVal := uint32(0)
+	for _, val := range enum.Values {
+		if val.Value > maxVal {
+			maxVal = val.Value
+		}
+	}
+
+	switch {
+	case maxVal <= 0xFF:
+		return "uint8"
+	case maxVal <= 0xFFFF:
+		return "uint16"
+	}
+	return "uint32"
+}
+
+// isIntegerSignal reports whether the physical value of the signal is equal to the raw one.
+func isIntegerSignal(sig *codec.Signal) bool {
+	return sig.Model.Scale == 1 && sig.Model.Offset == 0
+}
+
+func (g *goGenerator) fieldType(msg *codec.Message, sig *codec.Signal) string {
+	if enum, ok := g.m.SignalEnum(sig); ok {
+		return g.enumType(msg, enum)
+	}
+
+	if isIntegerSignal(sig) {
+		if sig.Model.Signed {
+			return fmt.Sprintf("int%d", rawTypeSize(sig))
+		}
+		return fmt.Sprintf("uint%d", rawTypeSize(sig))
+	}
+
+	return "float64"
+}
+
+func (g *goGenerator) writeComment(w *codeWriter, lines ...string) {
+	for _, l := range lines {
+		if len(l) == 0 {
+			w.line("//")
+			continue
+		}
+		w.line("// %s", l)
+	}
+}
+
+func (g *goGenerator) generateSource() ([]byte, error) {
+	w := newCodeWriter("\t")
+
+	w.line("// Code generated by jsondbc from the CAN model %q version %q. DO NOT EDIT.", g.m.Name, g.m.Version)
+	w.newLine()
+	w.line("// Package %s contains the messages of the CAN model %s.", g.pkgName, g.m.Name)
+	w.line("package %s", g.pkgName)
+	w.newLine()
+	w.line("import (")
+	w.in()
+	w.line("%q", "fmt")
+	w.line("%q", "math")
+	w.out()
+	w.line(")")
+	w.newLine()
+
+	g.writeMessageInterface(w)
+
+	for _, enum := range g.m.Enums {
+		g.writeEnum(w, nil, enum)
+	}
+
+	for _, msg := range g.m.Messages {
+		for _, sig := range g.m.MessageSignals(msg) {
+			if enum, ok := g.m.SignalEnum(sig); ok && !enum.IsGlobal {
+				g.writeEnum(w, msg, enum)
+			}
+		}
+
+		g.writeMessage(w, msg)
+	}
+
+	g.writeHelpers(w)
+
+	source, err := format.Source(w.bytes())
+	if err != nil {
+		return nil, fmt.Errorf("cannot format the generated Go code: %w", err)
+	}
+
+	return source, nil
+}
+
+func (g *goGenerator) writeMessageInterface(w *codeWriter) {
+	w.line("// Message is implemented by all the messages of the CAN model.")
+	w.line("type Message interface {")
+	w.in()
+	w.line("// MessageName returns the name of the message.")
+	w.line("MessageName() string")
+	w.line("// FrameID returns the CAN id of the message.")
+	w.line("FrameID() uint32")
+	w.line("// IsExtended reports whether the message has an extended CAN id.")
+	w.line("IsExtended() bool")
+	w.line("// MarshalCAN packs the signals into the payload of the message.")
+	w.line("MarshalCAN() ([]byte, error)")
+	w.line("// UnmarshalCAN unpacks the payload of the message into the signals.")
+	w.line("UnmarshalCAN(data []byte) error")
+	w.out()
+	w.line("}")
+	w.newLine()
+
+	w.line("type messageKey struct {")
+	w.in()
+	w.line("frameID  uint32")
+	w.line("extended bool")
+	w.out()
+	w.line("}")
+	w.newLine()
+
+	w.line("var messagesByKey = map[messageKey]func() Message{")
+	w.in()
+	for _, msg := range g.m.Messages {
+		w.line("{0x%X, %t}: func() Message { return &%s{} },", msg.FrameID(), msg.IsExtended(), g.msgType(msg))
+	}
+	w.out()
+	w.line("}")
+	w.newLine()
+
+	w.line("// NewMessage returns a new empty message with the given CAN id.")
+	w.line("func NewMessage(frameID uint32, extended bool) (Message, bool) {")
+	w.in()
+	w.line("newMsg, ok := messagesByKey[messageKey{frameID, extended}]")
+	w.line("if !ok {")
+	w.in()
+	w.line("return nil, false")
+	w.out()
+	w.line("}")
+	w.line("return newMsg(), true")
+	w.out()
+	w.line("}")
+	w.newLine()
+
+	w.line("// UnmarshalCAN unpacks the payload of a frame into the message with the given CAN id.")
+	w.line("func UnmarshalCAN(frameID uint32, extended bool, data []byte) (Message, error) {")
+	w.in()
+	w.line("msg, ok := NewMessage(frameID, extended)")
+	w.line("if !ok {")
+	w.in()
+	w.line("return nil, fmt.Errorf(\"frame id 0x%%X is not defined\", frameID)")
+	w.out()
+	w.line("}")
+	w.line("if err := msg.UnmarshalCAN(data); err != nil {")
+	w.in()
+	w.line("return nil, err")
+	w.out()
+	w.line("}")
+	w.line("return msg, nil")
+	w.out()
+	w.line("}")
+	w.newLine()
+}
+
+func (g *goGenerator) writeEnum(w *codeWriter, msg *codec.Message, enum *Enum) {
+	enumType := g.enumType(msg, enum)
+
+	if enum.IsGlobal {
+		w.line("// %s is the signal enum %s.", enumType, enum.Name)
+	} else {
+		w.line("// %s is the enum of signal %s of message %s.", enumType, enum.Name, msg.Name)
+	}
+	w.line("type %s %s", enumType, g.enumRawType(enum))
+	w.newLine()
+
+	w.line("// Values of %s.", enumType)
+	w.line("const (")
+	w.in()
+	for _, val := range enum.Values {
+		w.line("%s%s %s = %d", enumType, goName(val.Name), enumType, val.Value)
+	}
+	w.out()
+	w.line(")")
+	w.newLine()
+
+	w.line("// String returns the label of the value.")
+	w.line("func (v %s) String() string {", enumType)
+	w.in()
+	w.line("switch v {")
+	found := make(map[uint32]bool)
+	for _, val := range enum.Values {
+		if found[val.Value] {
+			continue
+		}
+		found[val.Value] = true
+		w.line("case %s%s:", enumType, goName(val.Name))
+		w.in()
+		w.line("return %q", val.Name)
+		w.out()
+	}
+	w.line("}")
+	w.line("return fmt.Sprintf(\"%s(%%d)\", v)", enumType)
+	w.out()
+	w.line("}")
+	w.newLine()
+}
+
+func (g *goGenerator) writeMessage(w *codeWriter, msg *codec.Message) {
+	msgType := g.msgType(msg)
+
+	w.line("// Properties of message %s.", msg.Name)
+	w.line("const (")
+	w.in()
+	w.line("%sFrameID uint32 = 0x%X", msgType, msg.FrameID())
+	w.line("%sLength = %d", msgType, msg.Length)
+	w.line("%sIsExtended = %t", msgType, msg.IsExtended())
+	w.out()
+	w.line(")")
+	w.newLine()
+
+	w.line("// %s represents the message %s.", msgType, msg.Name)
+	if desc := commentLines(msg.Model.Description); len(desc) > 0 {
+		w.line("//")
+		g.writeComment(w, desc...)
+	}
+	w.line("type %s struct {", msgType)
+	w.in()
+	for _, sig := range g.m.MessageSignals(msg) {
+		g.writeComment(w, g.signalDoc(sig)...)
+		w.line("%s %s", g.fieldName(sig), g.fieldType(msg, sig))
+	}
+	w.out()
+	w.line("}")
+	w.newLine()
+
+	w.line("// MessageName returns the name of the message.")
+	w.line("func (m *%s) MessageName() string { return %q }", msgType, msg.Name)
+	w.newLine()
+	w.line("// FrameID returns the CAN id of the message.")
+	w.line("func (m *%s) FrameID() uint32 { return %sFrameID }", msgType, msgType)
+	w.newLine()
+	w.line("// IsExtended reports whether the message has an extended CAN id.")
+	w.line("func (m *%s) IsExtended() bool { return %sIsExtended }", msgType, msgType)
+	w.newLine()
+
+	w.line("// MarshalCAN packs the signals into the payload of the message.")
+	w.line("// Multiplexed signals are packed only if selected by their multiplexor.")
+	w.line("func (m *%s) MarshalCAN() ([]byte, error) {", msgType)
+	w.in()
+	w.line("data := make([]byte, %sLength)", msgType)
+	if len(msg.Signals) > 0 {
+		w.line("var raw uint64")
+		w.line("var err error")
+		w.newLine()
+		g.writeMarshalSignals(w, msg.Signals)
+	}
+	w.line("return data, nil")
+	w.out()
+	w.line("}")
+	w.newLine()
+
+	w.line("// UnmarshalCAN unpacks the payload of the message into the signals.")
+	w.line("// Multiplexed signals not selected by their multiplexor are set to their zero value.")
+	w.line("func (m *%s) UnmarshalCAN(data []byte) error {", msgType)
+	w.in()
+	w.line("if len(data) < %sLength {", msgType)
+	w.in()
+	w.line("return fmt.Errorf(\"message [%s]: payload of %%d bytes, expected %%d\", len(data), %sLength)", msg.Name, msgType)
+	w.out()
+	w.line("}")
+	w.newLine()
+	w.line("*m = %s{}", msgType)
+	if len(msg.Signals) > 0 {
+		w.line("var raw uint64")
+		w.newLine()
+		g.writeUnmarshalSignals(w, msg, msg.Signals)
+	}
+	w.line("return nil")
+	w.out()
+	w.line("}")
+	w.newLine()
+}
+
+func (g *goGenerator) signalDoc(sig *codec.Signal) []string {
+	lines := commentLines(sig.Model.Description)
+
+	rng := fmt.Sprintf("Range: %s..%s", formatFloat(sig.Model.Min), formatFloat(sig.Model.Max))
+	if len(sig.Model.Unit) > 0 {
+		rng += " (" + sig.Model.Unit + ")"
+	}
+	lines = append(lines, rng)
+
+	if _, ok := g.m.SignalEnum(sig); ok {
+		lines = append(lines, "The value is raw.")
+	}
+
+	if sig.IsMultiplexed() {
+		lines = append(lines, fmt.Sprintf("Multiplexed by %s when equal to %d.", sig.Multiplexor.Name, sig.Model.MuxSwitch))
+	}
+
+	return lines
+}
+
+func (g *goGenerator) writeMarshalSignals(w *codeWriter, signals []*codec.Signal) {
+	for _, sig := range signals {
+		field := "m." + g.fieldName(sig)
+		size := sig.Model.Size
+
+		w.line("// %s", sig.Name)
+
+		_, isEnum := g.m.SignalEnum(sig)
+		if !isEnum && !(sig.Model.Min == 0 && sig.Model.Max == 0) {
+			value := field
+			if isIntegerSignal(sig) {
+				value = "float64(" + field + ")"
+			}
+			w.line("if err = checkPhysRange(%q, %s, %s, %s); err != nil {", sig.Name, value, cDouble(sig.Model.Min), cDouble(sig.Model.Max))
+			w.in()
+			w.line("return nil, err")
+			w.out()
+			w.line("}")
+		}
+
+		switch {
+		case isEnum || (isIntegerSignal(sig) && !sig.Model.Signed):
+			w.line("if raw, err = uintToRaw(%q, uint64(%s), %d); err != nil {", sig.Name, field, size)
+		case isIntegerSignal(sig):
+			w.line("if raw, err = intToRaw(%q, int64(%s), %d); err != nil {", sig.Name, field, size)
+		default:
+			w.line("if raw, err = floatToRaw(%q, %s, %s, %s, %d, %t); err != nil {", sig.Name, field, cDouble(sig.Model.Scale), cDouble(sig.Model.Offset), size, sig.Model.Signed)
+		}
+		w.in()
+		w.line("return nil, err")
+		w.out()
+		w.line("}")
+
+		for _, seg := range sig.Segments() {
+			w.line("data[%d] |= byte(raw>>%d&0x%X) << %d", seg.Byte, seg.ValueBit, seg.Mask(), seg.ByteBit)
+		}
+		w.newLine()
+
+		if sig.IsMultiplexor() {
+			g.writeMuxSwitch(w, sig, func(muxSigs []*codec.Signal) {
+				g.writeMarshalSignals(w, muxSigs)
+			})
+		}
+	}
+}
+
+func (g *goGenerator) writeUnmarshalSignals(w *codeWriter, msg *codec.Message, signals []*codec.Signal) {
+	for _, sig := range signals {
+		field := "m." + g.fieldName(sig)
+		size := sig.Model.Size
+
+		w.line("// %s", sig.Name)
+		w.line("raw = 0")
+		for _, seg := range sig.Segments() {
+			w.line("raw |= uint64(data[%d]>>%d&0x%X) << %d", seg.Byte, seg.ByteBit, seg.Mask(), seg.ValueBit)
+		}
+
+		_, isEnum := g.m.SignalEnum(sig)
+		switch {
+		case isEnum || (isIntegerSignal(sig) && !sig.Model.Signed):
+			w.line("%s = %s(raw)", field, g.fieldType(msg, sig))
+		case isIntegerSignal(sig):
+			w.line("%s = %s(rawToInt(raw, %d))", field, g.fieldType(msg, sig), size)
+		default:
+			w.line("%s = rawToFloat(raw, %s, %s, %d, %t)", field, cDouble(sig.Model.Scale), cDouble(sig.Model.Offset), size, sig.Model.Signed)
+		}
+		w.newLine()
+
+		if sig.IsMultiplexor() {
+			g.writeMuxSwitch(w, sig, func(muxSigs []*codec.Signal) {
+				g.writeUnmarshalSignals(w, msg, muxSigs)
+			})
+		}
+	}
+}
+
+// writeMuxSwitch writes a switch on the raw value of the multiplexor.
+// The raw variable must contain the value of the multiplexor.
+func (g *goGenerator) writeMuxSwitch(w *codeWriter, muxor *codec.Signal, writeSignals func(muxSigs []*codec.Signal)) {
+	w.line("switch raw {")
+	for _, muxSwitch := range MuxSwitches(muxor) {
+		w.line("case %d:", muxSwitch)
+		w.in()
+		writeSignals(MuxedSignals(muxor, muxSwitch))
+		w.out()
+	}
+	w.line("}")
+	w.newLine()
+}
+
+func (g *goGenerator) writeHelpers(w *codeWriter) {
+	w.line(`func rawMask(size uint) uint64 {
+	if size >= 64 {
+		return math.MaxUint64
+	}
+	return 1<<size - 1
+}
+
+func checkPhysRange(sigName string, value, minValue, maxValue float64) error {
+	if value < minValue || value > maxValue {
+		return fmt.Errorf("signal [%%s]: value %%v is out of range [%%v, %%v]", sigName, value, minValue, maxValue)
+	}
+	return nil
+}
+
+func uintToRaw(sigName string, value uint64, size uint) (uint64, error) {
+	if value&^rawMask(size) != 0 {
+		return 0, fmt.Errorf("signal [%%s]: value %%d does not fit in %%d bits", sigName, value, size)
+	}
+	return value, nil
+}
+
+func intToRaw(sigName string, value int64, size uint) (uint64, error) {
+	if size < 64 {
+		limit := int64(1) << (size - 1)
+		if value < -limit || value >= limit {
+			return 0, fmt.Errorf("signal [%%s]: value %%d does not fit in %%d bits", sigName, value, size)
+		}
+	}
+	return uint64(value) & rawMask(size), nil
+}
+
+func floatToRaw(sigName string, value, scale, offset float64, size uint, signed bool) (uint64, error) {
+	raw := math.Round((value - offset) / scale)
+
+	minRaw, maxRaw := 0.0, math.Ldexp(1, int(size))
+	if signed {
+		minRaw, maxRaw = -math.Ldexp(1, int(size)-1), math.Ldexp(1, int(size)-1)
+	}
+	if math.IsNaN(raw) || raw < minRaw || raw >= maxRaw {
+		return 0, fmt.Errorf("signal [%%s]: value %%v does not fit in %%d bits", sigName, value, size)
+	}
+
+	if signed {
+		return uint64(int64(raw)) & rawMask(size), nil
+	}
+	return uint64(raw), nil
+}
+
+func rawToInt(raw uint64, size uint) int64 {
+	if size < 64 && raw&(1<<(size-1)) != 0 {
+		raw |= ^rawMask(size)
+	}
+	return int64(raw)
+}
+
+func rawToFloat(raw uint64, scale, offset float64, size uint, signed bool) float64 {
+	if signed {
+		return float64(rawToInt(raw, size))*scale + offset
+	}
+	return float64(raw)*scale + offset
+}`)
+}
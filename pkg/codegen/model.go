@@ -0,0 +1,190 @@
+// Package codegen generates the source code to pack and unpack the messages of a CAN model.
+package codegen
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/squadracorsepolito/jsondbc/pkg"
+	"github.com/squadracorsepolito/jsondbc/pkg/codec"
+	"golang.org/x/exp/maps"
+)
+
+// File represents a generated source file.
+type File struct {
+	Name    string
+	Content []byte
+}
+
+// EnumValue represents a value of an enum.
+type EnumValue struct {
+	Name  string
+	Value uint32
+}
+
+// Enum represents an enum of a signal, or a global one defined in signal_enums.
+type Enum struct {
+	Name     string
+	IsGlobal bool
+	Values   []*EnumValue
+}
+
+func newEnum(name string, isGlobal bool, values map[string]uint32) *Enum {
+	e := &Enum{
+		Name:     name,
+		IsGlobal: isGlobal,
+		Values:   make([]*EnumValue, 0, len(values)),
+	}
+
+	for valName, val := range values {
+		e.Values = append(e.Values, &EnumValue{
+			Name:  valName,
+			Value: val,
+		})
+	}
+
+	sort.Slice(e.Values, func(i, j int) bool {
+		if e.Values[i].Value == e.Values[j].Value {
+			return e.Values[i].Name < e.Values[j].Name
+		}
+		return e.Values[i].Value < e.Values[j].Value
+	})
+
+	return e
+}
+
+// Model represents the CAN model prepared for the code generation.
+type Model struct {
+	Name     string
+	Version  string
+	Messages []*codec.Message
+	Enums    []*Enum
+
+	signalEnums map[*codec.Signal]*Enum
+}
+
+// NewModel returns the generation model of the CAN model.
+// The name is used as prefix, package or module name by the generators.
+// The CAN model must have been initialized with Init.
+func NewModel(name string, canModel *pkg.CanModel) (*Model, error) {
+	c, err := codec.New(canModel)
+	if err != nil {
+		return nil, err
+	}
+
+	m := &Model{
+		Name:     name,
+		Version:  canModel.Version,
+		Messages: c.Messages(),
+		Enums:    []*Enum{},
+
+		signalEnums: make(map[*codec.Signal]*Enum),
+	}
+
+	globalEnums := make(map[string]*Enum, len(canModel.SignalEnums))
+	enumNames := maps.Keys(canModel.SignalEnums)
+	sort.Strings(enumNames)
+	for _, enumName := range enumNames {
+		enum := newEnum(enumName, true, canModel.SignalEnums[enumName])
+		globalEnums[enumName] = enum
+		m.Enums = append(m.Enums, enum)
+	}
+
+	for _, msg := range m.Messages {
+		for _, sig := range m.MessageSignals(msg) {
+			if !sig.Model.IsBitmap() {
+				continue
+			}
+
+			// signals referencing a global enum share its definition
+			if globalEnum, ok := globalEnums[sig.Model.EnumRef]; ok && maps.Equal(sig.Model.Enum, canModel.SignalEnums[sig.Model.EnumRef]) {
+				m.signalEnums[sig] = globalEnum
+				continue
+			}
+
+			m.signalEnums[sig] = newEnum(sig.Name, false, sig.Model.Enum)
+		}
+	}
+
+	return m, nil
+}
+
+// MessageSignals returns all the signals of the message, including the multiplexed ones,
+// in depth-first order.
+func (m *Model) MessageSignals(msg *codec.Message) []*codec.Signal {
+	signals := []*codec.Signal{}
+
+	var visit func(sigs []*codec.Signal)
+	visit = func(sigs []*codec.Signal) {
+		for _, sig := range sigs {
+			signals = append(signals, sig)
+			if sig.IsMultiplexor() {
+				visit(sig.MuxGroup)
+			}
+		}
+	}
+	visit(msg.Signals)
+
+	return signals
+}
+
+// SignalEnum returns the enum of the signal, if it has one.
+func (m *Model) SignalEnum(sig *codec.Signal) (*Enum, bool) {
+	enum, ok := m.signalEnums[sig]
+	return enum, ok
+}
+
+// MuxSwitches returns the sorted distinct mux switch values selected by the multiplexor.
+func MuxSwitches(muxor *codec.Signal) []uint32 {
+	switches := []uint32{}
+	found := make(map[uint32]bool)
+	for _, muxSig := range muxor.MuxGroup {
+		if found[muxSig.Model.MuxSwitch] {
+			continue
+		}
+		found[muxSig.Model.MuxSwitch] = true
+		switches = append(switches, muxSig.Model.MuxSwitch)
+	}
+	sort.Slice(switches, func(i, j int) bool {
+		return switches[i] < switches[j]
+	})
+	return switches
+}
+
+// MuxedSignals returns the signals selected by the multiplexor when it has the given value.
+func MuxedSignals(muxor *codec.Signal, muxSwitch uint32) []*codec.Signal {
+	signals := []*codec.Signal{}
+	for _, muxSig := range muxor.MuxGroup {
+		if muxSig.Model.MuxSwitch == muxSwitch {
+			signals = append(signals, muxSig)
+		}
+	}
+	return signals
+}
+
+// rawTypeSize returns the size in bits of the smallest integer type able to hold the signal.
+func rawTypeSize(sig *codec.Signal) int {
+	switch {
+	case sig.Model.Size <= 8:
+		return 8
+	case sig.Model.Size <= 16:
+		return 16
+	case sig.Model.Size <= 32:
+		return 32
+	}
+	return 64
+}
+
+// checkNames returns an error if two names of the same scope are converted
+// into the same identifier.
+func checkNames(scope string, names []string, convert func(string) string) error {
+	idents := make(map[string]string, len(names))
+	for _, name := range names {
+		ident := convert(name)
+		if other, ok := idents[ident]; ok {
+			return fmt.Errorf("%s: names [%s] and [%s] generate the same identifier [%s]", scope, other, name, ident)
+		}
+		idents[ident] = name
+	}
+	return nil
+}
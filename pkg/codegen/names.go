@@ -0,0 +1,87 @@
+package codegen
+
+import (
+	"strings"
+	"unicode"
+)
+
+// splitWords splits a name into words, handling snake_case, CamelCase and acronyms.
+// For example "EngineSpeed_RPM" becomes ["Engine", "Speed", "RPM"].
+func splitWords(name string) []string {
+	words := []string{}
+
+	runes := []rune(name)
+	curr := []rune{}
+	flush := func() {
+		if len(curr) > 0 {
+			words = append(words, string(curr))
+			curr = []rune{}
+		}
+	}
+
+	for i, r := range runes {
+		if !unicode.IsLetter(r) && !unicode.IsDigit(r) {
+			flush()
+			continue
+		}
+
+		if unicode.IsUpper(r) && len(curr) > 0 {
+			prev := runes[i-1]
+			nextIsLower := i+1 < len(runes) && unicode.IsLower(runes[i+1])
+			if unicode.IsLower(prev) || unicode.IsDigit(prev) || (unicode.IsUpper(prev) && nextIsLower) {
+				flush()
+			}
+		}
+
+		curr = append(curr, r)
+	}
+	flush()
+
+	return words
+}
+
+// snakeCase returns the name in snake_case.
+func snakeCase(name string) string {
+	words := splitWords(name)
+	for i, w := range words {
+		words[i] = strings.ToLower(w)
+	}
+	return strings.Join(words, "_")
+}
+
+// upperSnakeCase returns the name in UPPER_SNAKE_CASE.
+func upperSnakeCase(name string) string {
+	return strings.ToUpper(snakeCase(name))
+}
+
+// pascalCase returns the name in PascalCase.
+func pascalCase(name string) string {
+	words := splitWords(name)
+	for i, w := range words {
+		runes := []rune(strings.ToLower(w))
+		runes[0] = unicode.ToUpper(runes[0])
+		words[i] = string(runes)
+	}
+	return strings.Join(words, "")
+}
+
+// pascalIdent returns the name in PascalCase, prefixed if it would start with a digit.
+// It is used for type names.
+func pascalIdent(name string) string {
+	ident := pascalCase(name)
+	if len(ident) == 0 || unicode.IsDigit(rune(ident[0])) {
+		return "X" + ident
+	}
+	return ident
+}
+
+// safeIdent prefixes the identifier if it is empty, starts with a digit or is a reserved keyword.
+func safeIdent(ident string, keywords map[string]bool) string {
+	if len(ident) == 0 {
+		return "_"
+	}
+	if unicode.IsDigit(rune(ident[0])) || keywords[ident] {
+		return "_" + ident
+	}
+	return ident
+}
@@ -0,0 +1,481 @@
+package codegen
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/squadracorsepolito/jsondbc/pkg/codec"
+)
+
+var pythonKeywords = map[string]bool{
+	"False": true, "None": true, "True": true, "and": true, "as": true, "assert": true,
+	"async": true, "await": true, "break": true, "class": true, "continue": true, "def": true,
+	"del": true, "elif": true, "else": true, "except": true, "finally": true, "for": true,
+	"from": true, "global": true, "if": true, "import": true, "in": true, "is": true,
+	"lambda": true, "nonlocal": true, "not": true, "or": true, "pass": true, "raise": true,
+	"return": true, "try": true, "while": true, "with": true, "yield": true,
+}
+
+// pythonReservedNames are the top level names always defined by the generated module.
+var pythonReservedNames = []string{"SignalSpec", "Message", "MESSAGES", "decode", "encode"}
+
+// pythonMessageMethods are the methods of the generated message classes,
+// fields with the same name are not allowed.
+var pythonMessageMethods = []string{"encode", "decode"}
+
+// pythonGenerator generates a dependency-free Python module with a dataclass for each message.
+type pythonGenerator struct {
+	m *Model
+
+	enums     []*Enum
+	enumTypes map[*Enum]string
+}
+
+// GeneratePython generates the Python module of the model.
+func GeneratePython(m *Model) ([]*File, error) {
+	g := &pythonGenerator{
+		m: m,
+
+		enumTypes: make(map[*Enum]string),
+	}
+
+	for _, enum := range m.Enums {
+		g.enums = append(g.enums, enum)
+		g.enumTypes[enum] = pascalIdent(enum.Name)
+	}
+	for _, msg := range m.Messages {
+		for _, sig := range m.MessageSignals(msg) {
+			if enum, ok := m.SignalEnum(sig); ok && !enum.IsGlobal {
+				g.enums = append(g.enums, enum)
+				g.enumTypes[enum] = pascalIdent(msg.Name) + pascalIdent(enum.Name)
+			}
+		}
+	}
+
+	if err := g.checkNames(); err != nil {
+		return nil, err
+	}
+
+	return []*File{{Name: snakeCase(m.Name) + ".py", Content: g.generateSource()}}, nil
+}
+
+func (g *pythonGenerator) checkNames() error {
+	names := make(map[string]string)
+	for _, name := range pythonReservedNames {
+		names[name] = "the generated code"
+	}
+	addName := func(name, src string) error {
+		if other, ok := names[name]; ok {
+			return fmt.Errorf("%s and %s generate the same identifier [%s]", other, src, name)
+		}
+		names[name] = src
+		return nil
+	}
+
+	for _, enum := range g.enums {
+		src := fmt.Sprintf("enum [%s]", enum.Name)
+		if err := addName(g.enumTypes[enum], src); err != nil {
+			return err
+		}
+
+		members := []string{}
+		for _, val := range enum.Values {
+			members = append(members, val.Name)
+		}
+		if err := checkNames(src, members, g.enumMemberName); err != nil {
+			return err
+		}
+	}
+
+	for _, msg := range g.m.Messages {
+		if err := addName(g.className(msg), fmt.Sprintf("message [%s]", msg.Name)); err != nil {
+			return err
+		}
+
+		fields := []string{}
+		for _, sig := range g.m.MessageSignals(msg) {
+			fields = append(fields, sig.Name)
+		}
+		if err := checkNames(fmt.Sprintf("message [%s]", msg.Name), append(fields, pythonMessageMethods...), g.fieldNameOf); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (g *pythonGenerator) className(msg *codec.Message) string {
+	return pascalIdent(msg.Name)
+}
+
+func (g *pythonGenerator) fieldNameOf(name string) string {
+	return safeIdent(snakeCase(name), pythonKeywords)
+}
+
+func (g *pythonGenerator) fieldName(sig *codec.Signal) string {
+	return g.fieldNameOf(sig.Name)
+}
+
+func (g *pythonGenerator) enumMemberName(name string) string {
+	return safeIdent(upperSnakeCase(name), pythonKeywords)
+}
+
+// fieldType returns the type annotation of the signal field.
+func (g *pythonGenerator) fieldType(sig *codec.Signal) string {
+	typ := "float"
+	if enum, ok := g.m.SignalEnum(sig); ok {
+		typ = fmt.Sprintf("Union[%s, int]", g.enumTypes[enum])
+	} else if isIntegerSignal(sig) {
+		typ = "int"
+	}
+
+	if sig.IsMultiplexed() {
+		return fmt.Sprintf("Optional[%s]", typ)
+	}
+	return typ
+}
+
+// fieldDefault returns the default value of the signal field, its start value.
+// Multiplexed signals default to None, since they are set only if selected.
+func (g *pythonGenerator) fieldDefault(sig *codec.Signal) string {
+	if sig.IsMultiplexed() {
+		return "None"
+	}
+
+	if enum, ok := g.m.SignalEnum(sig); ok {
+		raw, err := sig.Raw(sig.StartValue())
+		if err == nil {
+			for _, val := range enum.Values {
+				if uint64(val.Value) == raw {
+					return g.enumTypes[enum] + "." + g.enumMemberName(val.Name)
+				}
+			}
+			return fmt.Sprintf("%d", raw)
+		}
+		return "0"
+	}
+
+	if isIntegerSignal(sig) {
+		return formatFloat(sig.StartValue())
+	}
+	return cDouble(sig.StartValue())
+}
+
+func pythonBool(b bool) string {
+	if b {
+		return "True"
+	}
+	return "False"
+}
+
+func (g *pythonGenerator) generateSource() []byte {
+	w := newCodeWriter("    ")
+
+	w.line(`"""Messages of the CAN model %s.`, g.m.Name)
+	w.newLine()
+	w.line("Generated by jsondbc from the CAN model %q version %q. Do not edit.", g.m.Name, g.m.Version)
+	w.line(`"""`)
+	w.newLine()
+	w.line("import math")
+	w.line("from dataclasses import dataclass")
+	w.line("from enum import IntEnum")
+	w.line("from typing import ClassVar, Dict, NamedTuple, Optional, Tuple, Type, Union")
+	w.newLine()
+	w.newLine()
+
+	g.writeRuntime(w)
+
+	for _, enum := range g.enums {
+		g.writeEnum(w, enum)
+	}
+
+	for _, msg := range g.m.Messages {
+		g.writeMessage(w, msg)
+	}
+
+	w.line("MESSAGES: Tuple[Type[Message], ...] = (")
+	w.in()
+	for _, msg := range g.m.Messages {
+		w.line("%s,", g.className(msg))
+	}
+	w.out()
+	w.line(")")
+	w.line(`"""All the messages of the CAN model."""`)
+	w.newLine()
+	w.line("_MESSAGES_BY_ID: Dict[Tuple[int, bool], Type[Message]] = {")
+	w.in()
+	w.line("(msg_cls.FRAME_ID, msg_cls.IS_EXTENDED): msg_cls for msg_cls in MESSAGES")
+	w.out()
+	w.line("}")
+	w.newLine()
+	w.newLine()
+
+	w.line(`def decode(frame_id: int, data: bytes, extended: Optional[bool] = None) -> Message:
+    """Decodes the payload of a frame into the message with the given frame id.
+
+    If extended is None, the frame id is looked up between both the standard and the extended ones.
+    """
+    for is_extended in ((False, True) if extended is None else (extended,)):
+        msg_cls = _MESSAGES_BY_ID.get((frame_id, is_extended))
+        if msg_cls is not None:
+            return msg_cls.decode(data)
+    raise KeyError(f"frame id 0x{frame_id:X} is not defined")
+
+
+def encode(msg: Message) -> bytes:
+    """Encodes the message into its payload."""
+    return msg.encode()`)
+
+	return w.bytes()
+}
+
+func (g *pythonGenerator) writeRuntime(w *codeWriter) {
+	w.line(`class SignalSpec(NamedTuple):
+    """Describes a signal and how it is stored in the payload of its message."""
+
+    name: str
+    field: str
+    size: int
+    signed: bool
+    scale: float
+    offset: float
+    minimum: float
+    maximum: float
+    unit: str
+    is_integer: bool
+    enum: Optional[Type[IntEnum]]
+    multiplexor: Optional[str]
+    mux_switch: Optional[int]
+    segments: Tuple[Tuple[int, int, int, int], ...]
+    """Runs of contiguous bits as (byte, byte_bit, value_bit, size), from the LSB to the MSB."""
+
+
+def _round(value: float) -> int:
+    return int(math.copysign(math.floor(abs(value) + 0.5), value))
+
+
+def _is_selected(spec: SignalSpec, raws: Dict[str, int]) -> bool:
+    return spec.multiplexor is None or raws.get(spec.multiplexor) == spec.mux_switch
+
+
+def _get_raw(spec: SignalSpec, data: bytes) -> int:
+    raw = 0
+    for byte, byte_bit, value_bit, size in spec.segments:
+        raw |= ((data[byte] >> byte_bit) & ((1 << size) - 1)) << value_bit
+    if spec.signed and raw & (1 << (spec.size - 1)):
+        raw -= 1 << spec.size
+    return raw
+
+
+def _set_raw(spec: SignalSpec, data: bytearray, raw: int) -> None:
+    raw &= (1 << spec.size) - 1
+    for byte, byte_bit, value_bit, size in spec.segments:
+        data[byte] |= ((raw >> value_bit) & ((1 << size) - 1)) << byte_bit
+
+
+def _to_phys(spec: SignalSpec, raw: int) -> Union[IntEnum, int, float]:
+    if spec.enum is not None:
+        try:
+            return spec.enum(raw)
+        except ValueError:
+            return raw
+    if spec.is_integer:
+        return raw
+    return raw * spec.scale + spec.offset
+
+
+def _to_raw(spec: SignalSpec, value: Union[IntEnum, int, float]) -> int:
+    if spec.enum is None and not (spec.minimum == 0 and spec.maximum == 0):
+        if not spec.minimum <= value <= spec.maximum:
+            raise ValueError(
+                f"signal [{spec.name}]: value {value} is out of range [{spec.minimum}, {spec.maximum}]"
+            )
+
+    if spec.enum is not None or spec.is_integer:
+        raw = int(value)
+    else:
+        raw = _round((value - spec.offset) / spec.scale)
+
+    if spec.signed:
+        min_raw, max_raw = -(1 << (spec.size - 1)), (1 << (spec.size - 1)) - 1
+    else:
+        min_raw, max_raw = 0, (1 << spec.size) - 1
+    if not min_raw <= raw <= max_raw:
+        raise ValueError(f"signal [{spec.name}]: value {value} does not fit in {spec.size} bits")
+
+    return raw
+
+
+class Message:
+    """Base class of the messages of the CAN model."""
+
+    NAME: ClassVar[str]
+    FRAME_ID: ClassVar[int]
+    IS_EXTENDED: ClassVar[bool]
+    LENGTH: ClassVar[int]
+    SIGNALS: ClassVar[Tuple[SignalSpec, ...]]
+
+    def encode(self) -> bytes:
+        """Encodes the message into its payload.
+
+        Multiplexed signals are encoded only if selected by their multiplexor.
+        """
+        data = bytearray(self.LENGTH)
+        raws: Dict[str, int] = {}
+        for spec in self.SIGNALS:
+            if not _is_selected(spec, raws):
+                continue
+            value = getattr(self, spec.field)
+            if value is None:
+                raise ValueError(f"message [{self.NAME}]: signal [{spec.name}] is selected but not set")
+            raw = _to_raw(spec, value)
+            raws[spec.field] = raw
+            _set_raw(spec, data, raw)
+        return bytes(data)
+
+    @classmethod
+    def decode(cls, data: bytes) -> "Message":
+        """Decodes the payload into a message.
+
+        Multiplexed signals not selected by their multiplexor are set to None.
+        """
+        if len(data) < cls.LENGTH:
+            raise ValueError(f"message [{cls.NAME}]: payload of {len(data)} bytes, expected {cls.LENGTH}")
+        values = {}
+        raws: Dict[str, int] = {}
+        for spec in cls.SIGNALS:
+            if not _is_selected(spec, raws):
+                continue
+            raw = _get_raw(spec, data)
+            raws[spec.field] = raw
+            values[spec.field] = _to_phys(spec, raw)
+        return cls(**values)`)
+	w.newLine()
+	w.newLine()
+}
+
+func (g *pythonGenerator) writeEnum(w *codeWriter, enum *Enum) {
+	w.line("class %s(IntEnum):", g.enumTypes[enum])
+	w.in()
+	if enum.IsGlobal {
+		w.line(`"""Signal enum %s."""`, enum.Name)
+	} else {
+		w.line(`"""Values of signal %s."""`, enum.Name)
+	}
+	w.newLine()
+	for _, val := range enum.Values {
+		w.line("%s = %d", g.enumMemberName(val.Name), val.Value)
+	}
+	w.out()
+	w.newLine()
+	w.newLine()
+}
+
+// pythonDocString escapes a string to be placed inside a docstring.
+func pythonDocString(str string) string {
+	str = strings.ReplaceAll(str, `\`, `\\`)
+	return strings.ReplaceAll(str, `"""`, `\"\"\"`)
+}
+
+func (g *pythonGenerator) writeMessage(w *codeWriter, msg *codec.Message) {
+	w.line("@dataclass")
+	w.line("class %s(Message):", g.className(msg))
+	w.in()
+
+	w.line(`"""Message %s (0x%x).`, pythonDocString(msg.Name), msg.FrameID())
+	if desc := commentLines(msg.Model.Description); len(desc) > 0 {
+		w.newLine()
+		for _, l := range desc {
+			w.line("%s", pythonDocString(l))
+		}
+	}
+	w.line(`"""`)
+	w.newLine()
+
+	w.line("NAME: ClassVar[str] = %q", msg.Name)
+	w.line("FRAME_ID: ClassVar[int] = 0x%x", msg.FrameID())
+	w.line("IS_EXTENDED: ClassVar[bool] = %s", pythonBool(msg.IsExtended()))
+	w.line("LENGTH: ClassVar[int] = %d", msg.Length)
+
+	signals := g.m.MessageSignals(msg)
+	if len(signals) == 0 {
+		w.line("SIGNALS: ClassVar[Tuple[SignalSpec, ...]] = ()")
+	} else {
+		w.line("SIGNALS: ClassVar[Tuple[SignalSpec, ...]] = (")
+		w.in()
+		for _, sig := range signals {
+			g.writeSignalSpec(w, sig)
+		}
+		w.out()
+		w.line(")")
+	}
+
+	for _, sig := range signals {
+		w.newLine()
+		w.line("%s: %s = %s", g.fieldName(sig), g.fieldType(sig), g.fieldDefault(sig))
+		w.line(`"""%s"""`, pythonDocString(g.signalDoc(sig)))
+	}
+
+	w.out()
+	w.newLine()
+	w.newLine()
+}
+
+func (g *pythonGenerator) signalDoc(sig *codec.Signal) string {
+	parts := []string{}
+	if desc := commentLines(sig.Model.Description); len(desc) > 0 {
+		parts = append(parts, strings.Join(desc, " "))
+	}
+
+	rng := fmt.Sprintf("Range: %s..%s", formatFloat(sig.Model.Min), formatFloat(sig.Model.Max))
+	if len(sig.Model.Unit) > 0 {
+		rng += " (" + sig.Model.Unit + ")"
+	}
+	parts = append(parts, rng+".")
+
+	if sig.IsMultiplexed() {
+		parts = append(parts, fmt.Sprintf("Multiplexed by %s when equal to %d.", sig.Multiplexor.Name, sig.Model.MuxSwitch))
+	}
+
+	return strings.Join(parts, " ")
+}
+
+func (g *pythonGenerator) writeSignalSpec(w *codeWriter, sig *codec.Signal) {
+	enum := "None"
+	if sigEnum, ok := g.m.SignalEnum(sig); ok {
+		enum = g.enumTypes[sigEnum]
+	}
+
+	multiplexor, muxSwitch := "None", "None"
+	if sig.IsMultiplexed() {
+		multiplexor = fmt.Sprintf("%q", g.fieldName(sig.Multiplexor))
+		muxSwitch = fmt.Sprintf("%d", sig.Model.MuxSwitch)
+	}
+
+	segments := []string{}
+	for _, seg := range sig.Segments() {
+		segments = append(segments, fmt.Sprintf("(%d, %d, %d, %d)", seg.Byte, seg.ByteBit, seg.ValueBit, seg.Size))
+	}
+	if len(segments) == 1 {
+		segments[0] += ","
+	}
+
+	w.line("SignalSpec(")
+	w.in()
+	w.line("name=%q,", sig.Name)
+	w.line("field=%q,", g.fieldName(sig))
+	w.line("size=%d,", sig.Model.Size)
+	w.line("signed=%s,", pythonBool(sig.Model.Signed))
+	w.line("scale=%s,", cDouble(sig.Model.Scale))
+	w.line("offset=%s,", cDouble(sig.Model.Offset))
+	w.line("minimum=%s,", cDouble(sig.Model.Min))
+	w.line("maximum=%s,", cDouble(sig.Model.Max))
+	w.line("unit=%q,", sig.Model.Unit)
+	w.line("is_integer=%s,", pythonBool(isIntegerSignal(sig)))
+	w.line("enum=%s,", enum)
+	w.line("multiplexor=%s,", multiplexor)
+	w.line("mux_switch=%s,", muxSwitch)
+	w.line("segments=(%s),", strings.Join(segments, ", "))
+	w.out()
+	w.line("),")
+}
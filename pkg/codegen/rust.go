@@ -0,0 +1,837 @@
+package codegen
+
+import (
+	"fmt"
+	"math"
+	"strings"
+
+	"github.com/squadracorsepolito/jsondbc/pkg/codec"
+)
+
+var rustKeywords = map[string]bool{
+	"as": true, "break": true, "const": true, "continue": true, "crate": true, "else": true,
+	"enum": true, "extern": true, "false": true, "fn": true, "for": true, "if": true,
+	"impl": true, "in": true, "let": true, "loop": true, "match": true, "mod": true,
+	"move": true, "mut": true, "pub": true, "ref": true, "return": true, "self": true,
+	"static": true, "struct": true, "super": true, "trait": true, "true": true, "type": true,
+	"unsafe": true, "use": true, "where": true, "while": true, "async": true, "await": true,
+	"dyn": true, "abstract": true, "become": true, "box": true, "do": true, "final": true,
+	"macro": true, "override": true, "priv": true, "typeof": true, "unsized": true,
+	"virtual": true, "yield": true, "try": true, "gen": true,
+}
+
+// rustContainer is a struct holding the payload of a message, or of a branch of a multiplexor.
+// It has the getters and setters of its signals.
+type rustContainer struct {
+	typeName string
+	msg      *codec.Message
+	signals  []*codec.Signal
+
+	isMessage bool
+}
+
+// rustMuxBranch is a branch of a multiplexor, selected when it is equal to the switch value.
+type rustMuxBranch struct {
+	muxSwitch uint32
+	variant   string
+	container *rustContainer
+}
+
+// rustGenerator generates a no_std Rust module with a struct for each message.
+type rustGenerator struct {
+	m *Model
+
+	enums     []*Enum
+	enumTypes map[*Enum]string
+}
+
+// GenerateRust generates the Rust module of the model.
+func GenerateRust(m *Model) ([]*File, error) {
+	g := &rustGenerator{
+		m: m,
+
+		enumTypes: make(map[*Enum]string),
+	}
+
+	for _, enum := range m.Enums {
+		g.enums = append(g.enums, enum)
+		g.enumTypes[enum] = pascalIdent(enum.Name)
+	}
+	for _, msg := range m.Messages {
+		for _, sig := range m.MessageSignals(msg) {
+			if enum, ok := m.SignalEnum(sig); ok && !enum.IsGlobal {
+				g.enums = append(g.enums, enum)
+				g.enumTypes[enum] = pascalIdent(msg.Name) + pascalIdent(enum.Name)
+			}
+		}
+	}
+
+	if err := g.checkNames(); err != nil {
+		return nil, err
+	}
+
+	return []*File{{Name: snakeCase(m.Name) + ".rs", Content: g.generateSource()}}, nil
+}
+
+func (g *rustGenerator) messageContainer(msg *codec.Message) *rustContainer {
+	return &rustContainer{
+		typeName:  pascalIdent(msg.Name),
+		msg:       msg,
+		signals:   msg.Signals,
+		isMessage: true,
+	}
+}
+
+func (g *rustGenerator) muxIndexType(c *rustContainer, muxor *codec.Signal) string {
+	return c.typeName + pascalIdent(muxor.Name) + "Index"
+}
+
+func (g *rustGenerator) muxBranches(c *rustContainer, muxor *codec.Signal) []*rustMuxBranch {
+	branches := []*rustMuxBranch{}
+	for _, muxSwitch := range MuxSwitches(muxor) {
+		variant := fmt.Sprintf("M%d", muxSwitch)
+		branches = append(branches, &rustMuxBranch{
+			muxSwitch: muxSwitch,
+			variant:   variant,
+			container: &rustContainer{
+				typeName: c.typeName + pascalIdent(muxor.Name) + variant,
+				msg:      c.msg,
+				signals:  MuxedSignals(muxor, muxSwitch),
+			},
+		})
+	}
+	return branches
+}
+
+// containers returns the container of the message followed by the ones of all the mux branches.
+func (g *rustGenerator) containers(msg *codec.Message) []*rustContainer {
+	containers := []*rustContainer{}
+
+	var visit func(c *rustContainer)
+	visit = func(c *rustContainer) {
+		containers = append(containers, c)
+		for _, sig := range c.signals {
+			if !sig.IsMultiplexor() {
+				continue
+			}
+			for _, branch := range g.muxBranches(c, sig) {
+				visit(branch.container)
+			}
+		}
+	}
+	visit(g.messageContainer(msg))
+
+	return containers
+}
+
+func (g *rustGenerator) checkNames() error {
+	types := map[string]string{
+		"CanError": "the generated code",
+		"Messages": "the generated code",
+	}
+	addType := func(ident, src string) error {
+		if other, ok := types[ident]; ok {
+			return fmt.Errorf("%s and %s generate the same identifier [%s]", other, src, ident)
+		}
+		types[ident] = src
+		return nil
+	}
+
+	for _, enum := range g.enums {
+		enumType := g.enumTypes[enum]
+		if err := addType(enumType, fmt.Sprintf("enum [%s]", enum.Name)); err != nil {
+			return err
+		}
+
+		variants := []string{"_Other"}
+		for _, val := range enum.Values {
+			variants = append(variants, val.Name)
+		}
+		if err := checkNames(fmt.Sprintf("enum [%s]", enum.Name), variants, pascalIdent); err != nil {
+			return err
+		}
+	}
+
+	for _, msg := range g.m.Messages {
+		src := fmt.Sprintf("message [%s]", msg.Name)
+		for _, c := range g.containers(msg) {
+			if err := addType(c.typeName, src); err != nil {
+				return err
+			}
+
+			idents := map[string]string{
+				"new":         "the generated code",
+				"raw":         "the generated code",
+				"MESSAGE_ID":  "the generated code",
+				"IS_EXTENDED": "the generated code",
+				"LENGTH":      "the generated code",
+			}
+			for _, sig := range c.signals {
+				sigSrc := fmt.Sprintf("signal [%s] of message [%s]", sig.Name, msg.Name)
+				fn := g.fnName(sig)
+				sigIdents := []string{fn, fn + "_raw", "set_" + fn, "set_" + fn + "_raw", strings.ToUpper(fn) + "_MIN", strings.ToUpper(fn) + "_MAX"}
+				if sig.IsMultiplexor() {
+					if err := addType(g.muxIndexType(c, sig), sigSrc); err != nil {
+						return err
+					}
+					for _, branch := range g.muxBranches(c, sig) {
+						sigIdents = append(sigIdents, "set_"+strings.ToLower(branch.variant))
+					}
+				}
+				for _, ident := range sigIdents {
+					if other, ok := idents[ident]; ok {
+						return fmt.Errorf("%s and %s generate the same identifier [%s]", other, sigSrc, ident)
+					}
+					idents[ident] = sigSrc
+				}
+			}
+		}
+	}
+
+	return nil
+}
+
+func (g *rustGenerator) fnName(sig *codec.Signal) string {
+	return safeIdent(snakeCase(sig.Name), rustKeywords)
+}
+
+func rustUint(size int) string {
+	return fmt.Sprintf("u%d", size)
+}
+
+// rawType returns the type of the raw value of the signal.
+func (g *rustGenerator) rawType(sig *codec.Signal) string {
+	if sig.Model.Signed {
+		return fmt.Sprintf("i%d", rawTypeSize(sig))
+	}
+	return rustUint(rawTypeSize(sig))
+}
+
+// enumRawType returns the type of the enum, able to hold its values and the raw values of its signals.
+func (g *rustGenerator) enumRawType(enum *Enum) string {
+	size := 8
+	for _, val := range enum.Values {
+		switch {
+		case val.Value > 0xFFFF:
+			size = max(size, 32)
+		case val.Value > 0xFF:
+			size = max(size, 16)
+		}
+	}
+	for _, msg := range g.m.Messages {
+		for _, sig := range g.m.MessageSignals(msg) {
+			if sigEnum, ok := g.m.SignalEnum(sig); ok && sigEnum == enum {
+				size = max(size, rawTypeSize(sig))
+			}
+		}
+	}
+	return rustUint(size)
+}
+
+// physType returns the type of the physical value of the signal.
+func (g *rustGenerator) physType(sig *codec.Signal) string {
+	if enum, ok := g.m.SignalEnum(sig); ok {
+		return g.enumTypes[enum]
+	}
+	if isIntegerSignal(sig) {
+		return g.rawType(sig)
+	}
+	return "f32"
+}
+
+// rawRange returns the range of the raw values of the signal.
+func rawRange(sig *codec.Signal) (string, string) {
+	size := sig.Model.Size
+	if sig.Model.Signed {
+		return fmt.Sprintf("-%d", uint64(1)<<(size-1)), fmt.Sprintf("%d", uint64(1)<<(size-1)-1)
+	}
+	if size == 64 {
+		return "0", fmt.Sprintf("%d", uint64(1<<64-1))
+	}
+	return "0", fmt.Sprintf("%d", uint64(1)<<size-1)
+}
+
+func (g *rustGenerator) writeDoc(w *codeWriter, lines ...string) {
+	for _, l := range lines {
+		if len(l) == 0 {
+			w.line("///")
+			continue
+		}
+		w.line("/// %s", l)
+	}
+}
+
+func (g *rustGenerator) generateSource() []byte {
+	w := newCodeWriter("    ")
+
+	w.line("// Generated by jsondbc from the CAN model %q version %q.", g.m.Name, g.m.Version)
+	w.line("// Do not edit.")
+	w.newLine()
+	w.line("//! Messages of the CAN model %s.", g.m.Name)
+	w.line("//!")
+	w.line("//! The module only depends on `core`, so it can be used in `no_std` crates.")
+	w.newLine()
+	w.line("#![allow(dead_code)]")
+	w.line("#![allow(unused_comparisons)]")
+	w.line("#![allow(clippy::all)]")
+	w.newLine()
+	w.line("use core::convert::TryFrom;")
+	w.newLine()
+
+	g.writeCanError(w)
+	g.writeMessagesEnum(w)
+
+	for _, enum := range g.m.Enums {
+		g.writeEnum(w, enum)
+	}
+
+	for _, msg := range g.m.Messages {
+		for _, sig := range g.m.MessageSignals(msg) {
+			if enum, ok := g.m.SignalEnum(sig); ok && !enum.IsGlobal {
+				g.writeEnum(w, enum)
+			}
+		}
+
+		for _, c := range g.containers(msg) {
+			g.writeContainer(w, c)
+		}
+	}
+
+	return w.bytes()
+}
+
+func (g *rustGenerator) writeCanError(w *codeWriter) {
+	g.writeDoc(w, "Errors returned by the generated code.")
+	w.line("#[derive(Clone, Copy, Debug, PartialEq, Eq)]")
+	w.line("pub enum CanError {")
+	w.in()
+	g.writeDoc(w, "The frame id does not belong to any message.")
+	w.line("UnknownFrameId,")
+	g.writeDoc(w, "The payload size is different from the message length.")
+	w.line("InvalidPayloadSize,")
+	g.writeDoc(w, "The value is out of the [min|max] range of the signal, or does not fit in its raw value.")
+	w.line("ParameterOutOfRange {")
+	w.in()
+	g.writeDoc(w, "Frame id of the message of the signal.")
+	w.line("message_id: u32,")
+	w.out()
+	w.line("},")
+	g.writeDoc(w, "The multiplexor value does not select any branch.")
+	w.line("InvalidMultiplexor {")
+	w.in()
+	g.writeDoc(w, "Frame id of the message of the multiplexor.")
+	w.line("message_id: u32,")
+	g.writeDoc(w, "Raw value of the multiplexor.")
+	w.line("multiplexor: u64,")
+	w.out()
+	w.line("},")
+	w.out()
+	w.line("}")
+	w.newLine()
+}
+
+func (g *rustGenerator) writeMessagesEnum(w *codeWriter) {
+	g.writeDoc(w, "All the messages of the CAN model.")
+	w.line("#[derive(Clone, Copy, Debug)]")
+	w.line("pub enum Messages {")
+	w.in()
+	for _, msg := range g.m.Messages {
+		g.writeDoc(w, fmt.Sprintf("Message %s.", msg.Name))
+		w.line("%s(%s),", pascalIdent(msg.Name), pascalIdent(msg.Name))
+	}
+	w.out()
+	w.line("}")
+	w.newLine()
+
+	w.line("impl Messages {")
+	w.in()
+	g.writeDoc(w, "Decodes the payload of a frame into the message with the given frame id.")
+	w.line("pub fn from_can_message(id: u32, extended: bool, payload: &[u8]) -> Result<Self, CanError> {")
+	w.in()
+	w.line("let res = match (id, extended) {")
+	w.in()
+	for _, msg := range g.m.Messages {
+		msgType := pascalIdent(msg.Name)
+		w.line("(%s::MESSAGE_ID, %s::IS_EXTENDED) => Messages::%s(%s::try_from(payload)?),", msgType, msgType, msgType, msgType)
+	}
+	w.line("_ => return Err(CanError::UnknownFrameId),")
+	w.out()
+	w.line("};")
+	w.line("Ok(res)")
+	w.out()
+	w.line("}")
+	w.out()
+	w.line("}")
+	w.newLine()
+}
+
+func (g *rustGenerator) writeEnum(w *codeWriter, enum *Enum) {
+	enumType := g.enumTypes[enum]
+	rawType := g.enumRawType(enum)
+
+	if enum.IsGlobal {
+		g.writeDoc(w, fmt.Sprintf("Signal enum %s.", enum.Name))
+	} else {
+		g.writeDoc(w, fmt.Sprintf("Values of signal %s.", enum.Name))
+	}
+	w.line("#[derive(Clone, Copy, Debug, PartialEq, Eq)]")
+	w.line("pub enum %s {", enumType)
+	w.in()
+	for _, val := range enum.Values {
+		w.line("%s,", pascalIdent(val.Name))
+	}
+	g.writeDoc(w, "Value without a label.")
+	w.line("_Other(%s),", rawType)
+	w.out()
+	w.line("}")
+	w.newLine()
+
+	w.line("impl From<%s> for %s {", rawType, enumType)
+	w.in()
+	w.line("fn from(raw: %s) -> Self {", rawType)
+	w.in()
+	w.line("match raw {")
+	w.in()
+	found := make(map[uint32]bool)
+	for _, val := range enum.Values {
+		if found[val.Value] {
+			continue
+		}
+		found[val.Value] = true
+		w.line("%d => %s::%s,", val.Value, enumType, pascalIdent(val.Name))
+	}
+	w.line("_ => %s::_Other(raw),", enumType)
+	w.out()
+	w.line("}")
+	w.out()
+	w.line("}")
+	w.out()
+	w.line("}")
+	w.newLine()
+
+	w.line("impl From<%s> for %s {", enumType, rawType)
+	w.in()
+	w.line("fn from(val: %s) -> Self {", enumType)
+	w.in()
+	w.line("match val {")
+	w.in()
+	for _, val := range enum.Values {
+		w.line("%s::%s => %d,", enumType, pascalIdent(val.Name), val.Value)
+	}
+	w.line("%s::_Other(raw) => raw,", enumType)
+	w.out()
+	w.line("}")
+	w.out()
+	w.line("}")
+	w.out()
+	w.line("}")
+	w.newLine()
+}
+
+func (g *rustGenerator) writeContainer(w *codeWriter, c *rustContainer) {
+	msg := c.msg
+
+	if c.isMessage {
+		lines := []string{fmt.Sprintf("Message %s (0x%x).", msg.Name, msg.FrameID())}
+		if desc := commentLines(msg.Model.Description); len(desc) > 0 {
+			lines = append(lines, "")
+			lines = append(lines, desc...)
+		}
+		g.writeDoc(w, lines...)
+	} else {
+		g.writeDoc(w, fmt.Sprintf("Multiplexed signals of message %s.", msg.Name))
+	}
+	w.line("#[derive(Clone, Copy, PartialEq, Eq)]")
+	w.line("pub struct %s {", c.typeName)
+	w.in()
+	w.line("raw: [u8; %d],", msg.Length)
+	w.out()
+	w.line("}")
+	w.newLine()
+
+	w.line("impl %s {", c.typeName)
+	w.in()
+
+	if c.isMessage {
+		g.writeDoc(w, "Frame id of the message.")
+		w.line("pub const MESSAGE_ID: u32 = 0x%x;", msg.FrameID())
+		g.writeDoc(w, "Whether the frame id is extended.")
+		w.line("pub const IS_EXTENDED: bool = %t;", msg.IsExtended())
+		g.writeDoc(w, "Length of the payload in bytes.")
+		w.line("pub const LENGTH: usize = %d;", msg.Length)
+		w.newLine()
+	}
+
+	hasConsts := false
+	for _, sig := range c.signals {
+		if g.hasPhysRange(sig) {
+			physType := g.physType(sig)
+			name := strings.ToUpper(g.fnName(sig))
+			minLit, maxLit := g.physRange(sig)
+			w.line("pub const %s_MIN: %s = %s;", name, physType, minLit)
+			w.line("pub const %s_MAX: %s = %s;", name, physType, maxLit)
+			hasConsts = true
+		}
+	}
+	if hasConsts {
+		w.newLine()
+	}
+
+	g.writeConstructor(w, c)
+
+	g.writeDoc(w, "Payload of the message.")
+	w.line("pub fn raw(&self) -> &[u8; %d] {", msg.Length)
+	w.in()
+	w.line("&self.raw")
+	w.out()
+	w.line("}")
+	w.newLine()
+
+	for _, sig := range c.signals {
+		g.writeRawGetter(w, sig)
+		g.writeRawSetter(w, sig)
+
+		if sig.IsMultiplexor() {
+			g.writeMuxGetter(w, c, sig)
+			for _, branch := range g.muxBranches(c, sig) {
+				g.writeMuxSetter(w, sig, branch)
+			}
+			continue
+		}
+
+		g.writeGetter(w, sig)
+		g.writeSetter(w, c, sig)
+	}
+
+	w.out()
+	w.line("}")
+	w.newLine()
+
+	w.line("impl Default for %s {", c.typeName)
+	w.in()
+	w.line("fn default() -> Self {")
+	w.in()
+	w.line("Self { raw: [0u8; %d] }", msg.Length)
+	w.out()
+	w.line("}")
+	w.out()
+	w.line("}")
+	w.newLine()
+
+	w.line("impl core::fmt::Debug for %s {", c.typeName)
+	w.in()
+	w.line("fn fmt(&self, f: &mut core::fmt::Formatter<'_>) -> core::fmt::Result {")
+	w.in()
+	w.line("f.debug_struct(%q)", c.typeName)
+	w.in()
+	for _, sig := range c.signals {
+		if sig.IsMultiplexor() {
+			w.line(".field(%q, &self.%s_raw())", g.fnName(sig), g.fnName(sig))
+			continue
+		}
+		w.line(".field(%q, &self.%s())", g.fnName(sig), g.fnName(sig))
+	}
+	w.line(".finish()")
+	w.out()
+	w.out()
+	w.line("}")
+	w.out()
+	w.line("}")
+	w.newLine()
+
+	if c.isMessage {
+		w.line("impl TryFrom<&[u8]> for %s {", c.typeName)
+		w.in()
+		w.line("type Error = CanError;")
+		w.newLine()
+		w.line("fn try_from(payload: &[u8]) -> Result<Self, Self::Error> {")
+		w.in()
+		w.line("if payload.len() != %d {", msg.Length)
+		w.in()
+		w.line("return Err(CanError::InvalidPayloadSize);")
+		w.out()
+		w.line("}")
+		w.line("let mut raw = [0u8; %d];", msg.Length)
+		w.line("raw.copy_from_slice(payload);")
+		w.line("Ok(Self { raw })")
+		w.out()
+		w.line("}")
+		w.out()
+		w.line("}")
+		w.newLine()
+	}
+
+	for _, sig := range c.signals {
+		if sig.IsMultiplexor() {
+			g.writeMuxIndex(w, c, sig)
+		}
+	}
+}
+
+func (g *rustGenerator) hasPhysRange(sig *codec.Signal) bool {
+	if _, ok := g.m.SignalEnum(sig); ok || sig.IsMultiplexor() {
+		return false
+	}
+	return !(sig.Model.Min == 0 && sig.Model.Max == 0)
+}
+
+// physRange returns the literals of the [min|max] range of the signal.
+// The range of integer signals is limited to the integer values that fit in the raw value.
+func (g *rustGenerator) physRange(sig *codec.Signal) (string, string) {
+	if !isIntegerSignal(sig) {
+		return cDouble(sig.Model.Min) + "_f32", cDouble(sig.Model.Max) + "_f32"
+	}
+
+	rawMin, rawMax := 0.0, math.Ldexp(1, int(sig.Model.Size))-1
+	if sig.Model.Signed {
+		rawMin = -math.Ldexp(1, int(sig.Model.Size)-1)
+		rawMax = math.Ldexp(1, int(sig.Model.Size)-1) - 1
+	}
+	minVal := math.Min(math.Max(math.Ceil(sig.Model.Min), rawMin), rawMax)
+	maxVal := math.Max(math.Min(math.Floor(sig.Model.Max), rawMax), rawMin)
+
+	return formatFloat(minVal), formatFloat(maxVal)
+}
+
+// writeConstructor writes the constructor with all the signals, except the multiplexors
+// that are set with the mux setters.
+func (g *rustGenerator) writeConstructor(w *codeWriter, c *rustContainer) {
+	params := []string{}
+	for _, sig := range c.signals {
+		if sig.IsMultiplexor() {
+			continue
+		}
+		params = append(params, fmt.Sprintf("%s: %s", g.fnName(sig), g.physType(sig)))
+	}
+
+	g.writeDoc(w, "Creates a new payload with the given signal values.")
+	w.line("pub fn new(%s) -> Result<Self, CanError> {", strings.Join(params, ", "))
+	w.in()
+	if len(params) == 0 {
+		w.line("Ok(Self { raw: [0u8; %d] })", c.msg.Length)
+	} else {
+		w.line("let mut res = Self { raw: [0u8; %d] };", c.msg.Length)
+		for _, sig := range c.signals {
+			if sig.IsMultiplexor() {
+				continue
+			}
+			w.line("res.set_%s(%s)?;", g.fnName(sig), g.fnName(sig))
+		}
+		w.line("Ok(res)")
+	}
+	w.out()
+	w.line("}")
+	w.newLine()
+}
+
+func (g *rustGenerator) signalDoc(sig *codec.Signal) []string {
+	lines := []string{sig.Name}
+	if desc := commentLines(sig.Model.Description); len(desc) > 0 {
+		lines = append(lines, "")
+		lines = append(lines, desc...)
+	}
+
+	rng := fmt.Sprintf("Range: %s..%s", formatFloat(sig.Model.Min), formatFloat(sig.Model.Max))
+	if len(sig.Model.Unit) > 0 {
+		rng += " (" + sig.Model.Unit + ")"
+	}
+	lines = append(lines,
+		"",
+		rng,
+		fmt.Sprintf("Scale: %s", formatFloat(sig.Model.Scale)),
+		fmt.Sprintf("Offset: %s", formatFloat(sig.Model.Offset)),
+	)
+
+	return lines
+}
+
+func (g *rustGenerator) writeRawGetter(w *codeWriter, sig *codec.Signal) {
+	fn := g.fnName(sig)
+	typeSize := rawTypeSize(sig)
+	uintType := rustUint(typeSize)
+
+	g.writeDoc(w, fmt.Sprintf("Raw value of %s.", sig.Name))
+	w.line("pub fn %s_raw(&self) -> %s {", fn, g.rawType(sig))
+	w.in()
+	w.line("let mut raw: %s = 0;", uintType)
+	for _, seg := range sig.Segments() {
+		w.line("raw |= (((self.raw[%d] >> %d) & 0x%x) as %s) << %d;", seg.Byte, seg.ByteBit, seg.Mask(), uintType, seg.ValueBit)
+	}
+	switch {
+	case !sig.Model.Signed:
+		w.line("raw")
+	case int(sig.Model.Size) < typeSize:
+		shift := typeSize - int(sig.Model.Size)
+		w.line("((raw << %d) as %s) >> %d", shift, g.rawType(sig), shift)
+	default:
+		w.line("raw as %s", g.rawType(sig))
+	}
+	w.out()
+	w.line("}")
+	w.newLine()
+}
+
+func (g *rustGenerator) writeRawSetter(w *codeWriter, sig *codec.Signal) {
+	fn := g.fnName(sig)
+	uintType := rustUint(rawTypeSize(sig))
+
+	w.line("fn set_%s_raw(&mut self, raw: %s) {", fn, g.rawType(sig))
+	w.in()
+	if sig.Model.Signed {
+		w.line("let raw = raw as %s;", uintType)
+	}
+	for _, seg := range sig.Segments() {
+		clearMask := ^(seg.Mask() << seg.ByteBit)
+		w.line("self.raw[%d] = (self.raw[%d] & 0x%x) | ((((raw >> %d) & 0x%x) as u8) << %d);", seg.Byte, seg.Byte, clearMask, seg.ValueBit, seg.Mask(), seg.ByteBit)
+	}
+	w.out()
+	w.line("}")
+	w.newLine()
+}
+
+func (g *rustGenerator) writeGetter(w *codeWriter, sig *codec.Signal) {
+	fn := g.fnName(sig)
+	physType := g.physType(sig)
+
+	g.writeDoc(w, g.signalDoc(sig)...)
+	w.line("pub fn %s(&self) -> %s {", fn, physType)
+	w.in()
+	if enum, ok := g.m.SignalEnum(sig); ok {
+		w.line("%s::from(self.%s_raw() as %s)", physType, fn, g.enumRawType(enum))
+	} else if isIntegerSignal(sig) {
+		w.line("self.%s_raw()", fn)
+	} else {
+		w.line("((self.%s_raw() as f64) * %s + %s) as f32", fn, cDouble(sig.Model.Scale), cDouble(sig.Model.Offset))
+	}
+	w.out()
+	w.line("}")
+	w.newLine()
+}
+
+func (g *rustGenerator) writeSetter(w *codeWriter, c *rustContainer, sig *codec.Signal) {
+	fn := g.fnName(sig)
+	physType := g.physType(sig)
+	rawMin, rawMax := rawRange(sig)
+	outOfRange := fmt.Sprintf("return Err(CanError::ParameterOutOfRange { message_id: %s::MESSAGE_ID });", pascalIdent(c.msg.Name))
+
+	g.writeDoc(w, fmt.Sprintf("Sets the value of %s.", sig.Name))
+	w.line("pub fn set_%s(&mut self, value: %s) -> Result<(), CanError> {", fn, physType)
+	w.in()
+
+	if g.hasPhysRange(sig) {
+		name := strings.ToUpper(fn)
+		w.line("if value < Self::%s_MIN || value > Self::%s_MAX {", name, name)
+		w.in()
+		w.line(outOfRange)
+		w.out()
+		w.line("}")
+	}
+
+	if enum, ok := g.m.SignalEnum(sig); ok {
+		w.line("let raw: %s = value.into();", g.enumRawType(enum))
+		w.line("if (raw as u128) > %s {", rawMax)
+		w.in()
+		w.line(outOfRange)
+		w.out()
+		w.line("}")
+		w.line("self.set_%s_raw(raw as %s);", fn, g.rawType(sig))
+	} else {
+		if isIntegerSignal(sig) {
+			w.line("let raw = value as i128;")
+		} else {
+			w.line("let raw = ((value as f64) - %s) / %s;", cDouble(sig.Model.Offset), cDouble(sig.Model.Scale))
+			w.line("let raw = (if raw < 0.0 { raw - 0.5 } else { raw + 0.5 }) as i128;")
+		}
+		w.line("if raw < %s || raw > %s {", rawMin, rawMax)
+		w.in()
+		w.line(outOfRange)
+		w.out()
+		w.line("}")
+		w.line("self.set_%s_raw(raw as %s);", fn, g.rawType(sig))
+	}
+
+	w.line("Ok(())")
+	w.out()
+	w.line("}")
+	w.newLine()
+}
+
+func (g *rustGenerator) writeMuxGetter(w *codeWriter, c *rustContainer, muxor *codec.Signal) {
+	fn := g.fnName(muxor)
+	indexType := g.muxIndexType(c, muxor)
+
+	g.writeDoc(w, fmt.Sprintf("Multiplexed signals selected by %s.", muxor.Name))
+	w.line("pub fn %s(&self) -> Result<%s, CanError> {", fn, indexType)
+	w.in()
+	w.line("match self.%s_raw() {", fn)
+	w.in()
+	for _, branch := range g.muxBranches(c, muxor) {
+		w.line("%d => Ok(%s::%s(%s { raw: self.raw })),", branch.muxSwitch, indexType, branch.variant, branch.container.typeName)
+	}
+	w.line("multiplexor => Err(CanError::InvalidMultiplexor {")
+	w.in()
+	w.line("message_id: %s::MESSAGE_ID,", pascalIdent(c.msg.Name))
+	w.line("multiplexor: multiplexor as u64,")
+	w.out()
+	w.line("}),")
+	w.out()
+	w.line("}")
+	w.out()
+	w.line("}")
+	w.newLine()
+}
+
+// branchMasks returns, for each byte of the payload, the mask of the bits
+// of the signals, including the ones selected by nested multiplexors.
+func branchMasks(length uint32, signals []*codec.Signal) []uint8 {
+	masks := make([]uint8, length)
+
+	var visit func(sigs []*codec.Signal)
+	visit = func(sigs []*codec.Signal) {
+		for _, sig := range sigs {
+			for _, pos := range sig.BitPositions() {
+				masks[pos/8] |= 1 << (pos % 8)
+			}
+			visit(sig.MuxGroup)
+		}
+	}
+	visit(signals)
+
+	return masks
+}
+
+func (g *rustGenerator) writeMuxSetter(w *codeWriter, muxor *codec.Signal, branch *rustMuxBranch) {
+	groupMasks := branchMasks(branch.container.msg.Length, muxor.MuxGroup)
+	masks := branchMasks(branch.container.msg.Length, branch.container.signals)
+
+	g.writeDoc(w, fmt.Sprintf("Sets %s to %d and the signals it selects.", muxor.Name, branch.muxSwitch))
+	w.line("pub fn set_%s(&mut self, value: %s) {", strings.ToLower(branch.variant), branch.container.typeName)
+	w.in()
+	for i, groupMask := range groupMasks {
+		if groupMask == 0 {
+			continue
+		}
+		w.line("self.raw[%d] = (self.raw[%d] & 0x%x) | (value.raw[%d] & 0x%x);", i, i, ^groupMask, i, masks[i])
+	}
+	w.line("self.set_%s_raw(%d);", g.fnName(muxor), branch.muxSwitch)
+	w.out()
+	w.line("}")
+	w.newLine()
+}
+
+// writeMuxIndex writes the enum of the branches of the multiplexor.
+func (g *rustGenerator) writeMuxIndex(w *codeWriter, c *rustContainer, muxor *codec.Signal) {
+	indexType := g.muxIndexType(c, muxor)
+
+	g.writeDoc(w, fmt.Sprintf("Branches of multiplexor %s of message %s.", muxor.Name, c.msg.Name))
+	w.line("#[derive(Clone, Copy, Debug)]")
+	w.line("pub enum %s {", indexType)
+	w.in()
+	for _, branch := range g.muxBranches(c, muxor) {
+		g.writeDoc(w, fmt.Sprintf("%s equal to %d.", muxor.Name, branch.muxSwitch))
+		w.line("%s(%s),", branch.variant, branch.container.typeName)
+	}
+	w.out()
+	w.line("}")
+	w.newLine()
+}
@@ -0,0 +1,67 @@
+package codegen
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// codeWriter writes indented lines of source code.
+type codeWriter struct {
+	b         strings.Builder
+	indent    int
+	indentStr string
+}
+
+func newCodeWriter(indentStr string) *codeWriter {
+	return &codeWriter{
+		indent:    0,
+		indentStr: indentStr,
+	}
+}
+
+// line writes a formatted line with the current indentation.
+// Empty lines are written without indentation.
+func (w *codeWriter) line(format string, a ...any) {
+	str := fmt.Sprintf(format, a...)
+	if len(str) > 0 {
+		w.b.WriteString(strings.Repeat(w.indentStr, w.indent))
+		w.b.WriteString(str)
+	}
+	w.b.WriteByte('\n')
+}
+
+func (w *codeWriter) newLine() {
+	w.b.WriteByte('\n')
+}
+
+func (w *codeWriter) in() {
+	w.indent++
+}
+
+func (w *codeWriter) out() {
+	if w.indent > 0 {
+		w.indent--
+	}
+}
+
+func (w *codeWriter) bytes() []byte {
+	return []byte(w.b.String())
+}
+
+// formatFloat returns the shortest representation of the float without exponent.
+func formatFloat(val float64) string {
+	return strconv.FormatFloat(val, 'f', -1, 64)
+}
+
+// commentLines splits a description into lines, removing the empty ones.
+func commentLines(desc string) []string {
+	lines := []string{}
+	for _, l := range strings.Split(desc, "\n") {
+		l = strings.TrimSpace(l)
+		if len(l) > 0 {
+			lines = append(lines, l)
+		}
+	}
+	return lines
+}
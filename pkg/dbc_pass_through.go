@@ -0,0 +1,628 @@
+package pkg
+
+import (
+	"sort"
+	"strconv"
+
+	"github.com/squadracorsepolito/jsondbc/pkg/cangoru/dbc"
+)
+
+// Kinds of the relation attributes.
+const (
+	relationKindNodeMessage = "node_message"
+	relationKindNodeSignal  = "node_signal"
+	relationKindNodeEnvVar  = "node_env_var"
+)
+
+// Types of the environment variables.
+const (
+	envVarTypeInt    = "int"
+	envVarTypeFloat  = "float"
+	envVarTypeString = "string"
+)
+
+// Extended value types of the signals.
+const (
+	signalValueTypeInteger = "integer"
+	signalValueTypeFloat   = "float"
+	signalValueTypeDouble  = "double"
+)
+
+// DBCPassThrough contains the elements of a DBC file that are not part of the CAN model.
+// They are read from the DBC file only to be written back when the model is converted to a DBC file.
+type DBCPassThrough struct {
+	Comments                []string                         `json:"comments,omitempty"`
+	GeneralAttributeValues  map[string]any                   `json:"general_attribute_values,omitempty"`
+	ValueTables             map[string]map[string]uint32     `json:"value_tables,omitempty"`
+	MessageTransmitters     map[string][]string              `json:"message_transmitters,omitempty"`
+	EnvVars                 map[string]*DBCEnvVar            `json:"env_vars,omitempty"`
+	EnvVarAttributes        map[string]*Attribute            `json:"env_var_attributes,omitempty"`
+	SignalTypes             map[string]*DBCSignalType        `json:"signal_types,omitempty"`
+	SignalTypeRefs          []*DBCSignalTypeRef              `json:"signal_type_refs,omitempty"`
+	SignalGroups            []*DBCSignalGroup                `json:"signal_groups,omitempty"`
+	SignalValueTypes        []*DBCSignalValueType            `json:"signal_value_types,omitempty"`
+	RelationAttributes      map[string]*DBCRelationAttribute `json:"relation_attributes,omitempty"`
+	RelationAttributeValues []*DBCRelationAttributeValue     `json:"relation_attribute_values,omitempty"`
+}
+
+func newDBCPassThrough() *DBCPassThrough {
+	return &DBCPassThrough{
+		GeneralAttributeValues: make(map[string]any),
+		ValueTables:            make(map[string]map[string]uint32),
+		MessageTransmitters:    make(map[string][]string),
+		EnvVars:                make(map[string]*DBCEnvVar),
+		EnvVarAttributes:       make(map[string]*Attribute),
+		SignalTypes:            make(map[string]*DBCSignalType),
+		RelationAttributes:     make(map[string]*DBCRelationAttribute),
+	}
+}
+
+// isEmpty returns true if the pass-through contains no element.
+func (pt *DBCPassThrough) isEmpty() bool {
+	return len(pt.Comments) == 0 && len(pt.GeneralAttributeValues) == 0 && len(pt.ValueTables) == 0 && len(pt.MessageTransmitters) == 0 && len(pt.EnvVars) == 0 &&
+		len(pt.EnvVarAttributes) == 0 && len(pt.SignalTypes) == 0 && len(pt.SignalTypeRefs) == 0 &&
+		len(pt.SignalGroups) == 0 && len(pt.SignalValueTypes) == 0 && len(pt.RelationAttributes) == 0 &&
+		len(pt.RelationAttributeValues) == 0
+}
+
+func (pt *DBCPassThrough) initPassThrough() {
+	for attName, att := range pt.EnvVarAttributes {
+		att.initAttribute(attName)
+	}
+	for attName, att := range pt.RelationAttributes {
+		if hasAttributeType(att.Attribute) {
+			att.initAttribute(attName)
+		}
+	}
+}
+
+// DBCEnvVar is an environment variable (EV_) with its data size (ENVVAR_DATA_), comment, value encoding and attributes.
+type DBCEnvVar struct {
+	Type         string            `json:"type"`
+	Min          float64           `json:"min"`
+	Max          float64           `json:"max"`
+	Unit         string            `json:"unit,omitempty"`
+	InitialValue float64           `json:"initial_value"`
+	ID           uint32            `json:"id"`
+	AccessType   string            `json:"access_type"`
+	AccessNodes  []string          `json:"access_nodes,omitempty"`
+	DataSize     uint32            `json:"data_size,omitempty"`
+	Description  string            `json:"description,omitempty"`
+	Enum         map[string]uint32 `json:"enum,omitempty"`
+
+	*AttributeAssignments
+}
+
+// DBCSignalType is a signal type (SGTYPE_) that can be referenced by the signals.
+type DBCSignalType struct {
+	Size         uint32  `json:"size"`
+	Endianness   string  `json:"endianness"`
+	Signed       bool    `json:"signed"`
+	Scale        float64 `json:"scale"`
+	Offset       float64 `json:"offset"`
+	Min          float64 `json:"min"`
+	Max          float64 `json:"max"`
+	Unit         string  `json:"unit,omitempty"`
+	DefaultValue float64 `json:"default_value"`
+	ValueTable   string  `json:"value_table,omitempty"`
+}
+
+// DBCSignalTypeRef is the reference of a signal to a signal type (SIG_TYPE_REF_).
+type DBCSignalTypeRef struct {
+	Message string `json:"message"`
+	Signal  string `json:"signal"`
+	Type    string `json:"type"`
+}
+
+// DBCSignalGroup is a group of signals of a message (SIG_GROUP_).
+type DBCSignalGroup struct {
+	Message     string   `json:"message"`
+	Name        string   `json:"name"`
+	Repetitions uint32   `json:"repetitions"`
+	Signals     []string `json:"signals"`
+}
+
+// DBCSignalValueType is the extended value type of a signal (SIG_VALTYPE_).
+type DBCSignalValueType struct {
+	Message string `json:"message"`
+	Signal  string `json:"signal"`
+	Type    string `json:"type"`
+}
+
+// DBCRelationAttribute is an attribute of the relation between a node and a message, a signal or an environment variable (BA_DEF_REL_).
+type DBCRelationAttribute struct {
+	Kind string `json:"kind"`
+
+	*Attribute
+}
+
+// DBCRelationAttributeValue is the value of a relation attribute (BA_REL_).
+type DBCRelationAttributeValue struct {
+	Attribute string `json:"attribute"`
+	Node      string `json:"node"`
+	Message   string `json:"message,omitempty"`
+	Signal    string `json:"signal,omitempty"`
+	EnvVar    string `json:"env_var,omitempty"`
+	Value     any    `json:"value"`
+}
+
+// sortedKeys returns the keys of a map in order.
+func sortedKeys[T any](m map[string]T) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// hasAttributeType returns true if the type of the attribute is set.
+func hasAttributeType(att *Attribute) bool {
+	return att != nil && (att.Int != nil || att.String != nil || att.Enum != nil || att.Float != nil)
+}
+
+// attributeIntValue returns the value of an int attribute, that is an int if read from a DBC file,
+// a float64 if read from a JSON file and an uint32 for the period of the messages.
+func attributeIntValue(val any) int {
+	switch v := val.(type) {
+	case int:
+		return v
+	case uint32:
+		return int(v)
+	case float64:
+		return int(v)
+	}
+	return 0
+}
+
+// toDBCValueDescriptions returns the value descriptions of an enum, sorted by value.
+func toDBCValueDescriptions(enum map[string]uint32) []*dbc.ValueDescription {
+	values := make([]*dbc.ValueDescription, 0, len(enum))
+	for name, val := range enum {
+		values = append(values, &dbc.ValueDescription{ID: int64(val), Name: name})
+	}
+	sort.Slice(values, func(i, j int) bool {
+		if values[i].ID != values[j].ID {
+			return values[i].ID < values[j].ID
+		}
+		return values[i].Name < values[j].Name
+	})
+	return values
+}
+
+// toDBCAttribute returns the definition and the default value of an attribute of the pass-through.
+func toDBCAttribute(attName string, kind dbc.AttributeKind, att *Attribute) (*dbc.Attribute, *dbc.AttributeDefault) {
+	dbcAtt := &dbc.Attribute{Kind: kind, Name: attName}
+	attDef := &dbc.AttributeDefault{AttributeName: attName}
+
+	switch att.attributeType {
+	case attributeTypeInt:
+		dbcAtt.Type = dbc.AttributeInt
+		dbcAtt.MinInt, dbcAtt.MaxInt = att.Int.From, att.Int.To
+		attDef.Type = dbc.AttributeDefaultInt
+		attDef.ValueInt = att.Int.Default
+
+	case attributeTypeFloat:
+		dbcAtt.Type = dbc.AttributeFloat
+		dbcAtt.MinFloat, dbcAtt.MaxFloat = att.Float.From, att.Float.To
+		attDef.Type = dbc.AttributeDefaultFloat
+		attDef.ValueFloat = att.Float.Default
+
+	case attributeTypeString:
+		dbcAtt.Type = dbc.AttributeString
+		attDef.Type = dbc.AttributeDefaultString
+		attDef.ValueString = att.String.Default
+
+	case attributeTypeEnum:
+		dbcAtt.Type = dbc.AttributeEnum
+		dbcAtt.EnumValues = att.Enum.Values
+		attDef.Type = dbc.AttributeDefaultString
+		attDef.ValueString = att.Enum.Default
+		if len(att.Enum.Values) > 0 {
+			attDef.ValueString = att.Enum.Values[att.Enum.defaultIdx]
+		}
+	}
+
+	return dbcAtt, attDef
+}
+
+// toDBCAttributeValue sets the value of an attribute assignment, the value of an enum attribute is written as index.
+func toDBCAttributeValue(att *Attribute, val any, attVal *dbc.AttributeValue) {
+	switch att.attributeType {
+	case attributeTypeInt:
+		attVal.Type = dbc.AttributeValueInt
+		attVal.ValueInt = attributeIntValue(val)
+
+	case attributeTypeFloat:
+		attVal.Type = dbc.AttributeValueFloat
+		switch v := val.(type) {
+		case float64:
+			attVal.ValueFloat = v
+		case int:
+			attVal.ValueFloat = float64(v)
+		}
+
+	case attributeTypeString:
+		attVal.Type = dbc.AttributeValueString
+		attVal.ValueString, _ = val.(string)
+
+	case attributeTypeEnum:
+		attVal.Type = dbc.AttributeValueInt
+		name, _ := val.(string)
+		for idx, enumVal := range att.Enum.Values {
+			if enumVal == name {
+				attVal.ValueInt = idx
+				break
+			}
+		}
+	}
+}
+
+// toAST returns the AST of the elements of the pass-through, the messages are referenced by id.
+// The elements referencing messages, signals or general attributes that are not in the model are skipped.
+func (pt *DBCPassThrough) toAST(messages map[string]*Message, generalAttributes map[string]*Attribute) *dbc.DBC {
+	ast := &dbc.DBC{}
+	if pt == nil {
+		return ast
+	}
+
+	getSignalMsgID := func(msgName, sigName string) (uint32, bool) {
+		msg, ok := messages[msgName]
+		if !ok {
+			return 0, false
+		}
+		if _, ok := msg.childSignals[sigName]; !ok {
+			return 0, false
+		}
+		return msg.ID, true
+	}
+
+	for _, text := range pt.Comments {
+		ast.Comments = append(ast.Comments, &dbc.Comment{Kind: dbc.CommentGeneral, Text: text})
+	}
+
+	for _, attName := range sortedKeys(pt.GeneralAttributeValues) {
+		att, ok := generalAttributes[attName]
+		if !ok || !hasAttributeType(att) {
+			continue
+		}
+		attVal := &dbc.AttributeValue{AttributeKind: dbc.AttributeGeneral, AttributeName: attName}
+		toDBCAttributeValue(att, pt.GeneralAttributeValues[attName], attVal)
+		ast.AttributeValues = append(ast.AttributeValues, attVal)
+	}
+
+	for _, name := range sortedKeys(pt.ValueTables) {
+		ast.ValueTables = append(ast.ValueTables, &dbc.ValueTable{
+			Name:   name,
+			Values: toDBCValueDescriptions(pt.ValueTables[name]),
+		})
+	}
+
+	for _, msgName := range sortedKeys(pt.MessageTransmitters) {
+		if msg, ok := messages[msgName]; ok {
+			ast.MessageTransmitters = append(ast.MessageTransmitters, &dbc.MessageTransmitter{
+				MessageID:    msg.ID,
+				Transmitters: pt.MessageTransmitters[msgName],
+			})
+		}
+	}
+	sort.SliceStable(ast.MessageTransmitters, func(i, j int) bool {
+		return ast.MessageTransmitters[i].MessageID < ast.MessageTransmitters[j].MessageID
+	})
+
+	for _, attName := range sortedKeys(pt.EnvVarAttributes) {
+		if !hasAttributeType(pt.EnvVarAttributes[attName]) {
+			continue
+		}
+		dbcAtt, attDef := toDBCAttribute(attName, dbc.AttributeEnvVar, pt.EnvVarAttributes[attName])
+		ast.Attributes = append(ast.Attributes, dbcAtt)
+		ast.AttributeDefaults = append(ast.AttributeDefaults, attDef)
+	}
+
+	for _, name := range sortedKeys(pt.EnvVars) {
+		envVar := pt.EnvVars[name]
+		ast.EnvVars = append(ast.EnvVars, envVar.toAST(name))
+
+		if envVar.DataSize > 0 {
+			ast.EnvVarDatas = append(ast.EnvVarDatas, &dbc.EnvVarData{EnvVarName: name, DataSize: envVar.DataSize})
+		}
+		if len(envVar.Description) > 0 {
+			ast.Comments = append(ast.Comments, &dbc.Comment{Kind: dbc.CommentEnvVar, EnvVarName: name, Text: envVar.Description})
+		}
+		if len(envVar.Enum) > 0 {
+			ast.ValueEncodings = append(ast.ValueEncodings, &dbc.ValueEncoding{
+				Kind:       dbc.ValueEncodingEnvVar,
+				EnvVarName: name,
+				Values:     toDBCValueDescriptions(envVar.Enum),
+			})
+		}
+
+		if envVar.AttributeAssignments == nil {
+			continue
+		}
+		for _, attName := range sortedKeys(envVar.Attributes) {
+			att, ok := pt.EnvVarAttributes[attName]
+			if !ok || !hasAttributeType(att) {
+				continue
+			}
+			attVal := &dbc.AttributeValue{AttributeKind: dbc.AttributeEnvVar, AttributeName: attName, EnvVarName: name}
+			toDBCAttributeValue(att, envVar.Attributes[attName], attVal)
+			ast.AttributeValues = append(ast.AttributeValues, attVal)
+		}
+	}
+
+	for _, name := range sortedKeys(pt.SignalTypes) {
+		ast.SignalTypes = append(ast.SignalTypes, pt.SignalTypes[name].toAST(name))
+	}
+
+	for _, ref := range pt.SignalTypeRefs {
+		if msgID, ok := getSignalMsgID(ref.Message, ref.Signal); ok {
+			ast.SignalTypeRefs = append(ast.SignalTypeRefs, &dbc.SignalTypeRef{MessageID: msgID, SignalName: ref.Signal, TypeName: ref.Type})
+		}
+	}
+
+	for _, group := range pt.SignalGroups {
+		if msg, ok := messages[group.Message]; ok {
+			ast.SignalGroups = append(ast.SignalGroups, &dbc.SignalGroup{
+				MessageID:   msg.ID,
+				GroupName:   group.Name,
+				Repetitions: group.Repetitions,
+				SignalNames: group.Signals,
+			})
+		}
+	}
+
+	for _, valType := range pt.SignalValueTypes {
+		msgID, ok := getSignalMsgID(valType.Message, valType.Signal)
+		if !ok {
+			continue
+		}
+
+		extValType := dbc.SignalExtValueTypeInteger
+		switch valType.Type {
+		case signalValueTypeFloat:
+			extValType = dbc.SignalExtValueTypeFloat
+		case signalValueTypeDouble:
+			extValType = dbc.SignalExtValueTypeDouble
+		}
+		ast.SignalExtValueTypes = append(ast.SignalExtValueTypes, &dbc.SignalExtValueType{
+			MessageID:    msgID,
+			SignalName:   valType.Signal,
+			ExtValueType: extValType,
+		})
+	}
+
+	for _, attName := range sortedKeys(pt.RelationAttributes) {
+		relAtt := pt.RelationAttributes[attName]
+		if !hasAttributeType(relAtt.Attribute) {
+			continue
+		}
+		dbcAtt, attDef := toDBCAttribute(attName, relationAttributeKind(relAtt.Kind), relAtt.Attribute)
+		ast.RelationAttributes = append(ast.RelationAttributes, dbcAtt)
+		ast.RelationAttributeDefaults = append(ast.RelationAttributeDefaults, attDef)
+	}
+
+	for _, relVal := range pt.RelationAttributeValues {
+		relAtt, ok := pt.RelationAttributes[relVal.Attribute]
+		if !ok || !hasAttributeType(relAtt.Attribute) {
+			continue
+		}
+
+		attVal := &dbc.AttributeValue{
+			AttributeKind: relationAttributeKind(relAtt.Kind),
+			AttributeName: relVal.Attribute,
+			NodeName:      relVal.Node,
+		}
+		switch relAtt.Kind {
+		case relationKindNodeMessage:
+			msg, ok := messages[relVal.Message]
+			if !ok {
+				continue
+			}
+			attVal.MessageID = msg.ID
+		case relationKindNodeSignal:
+			msgID, ok := getSignalMsgID(relVal.Message, relVal.Signal)
+			if !ok {
+				continue
+			}
+			attVal.MessageID = msgID
+			attVal.SignalName = relVal.Signal
+		default:
+			attVal.EnvVarName = relVal.EnvVar
+		}
+
+		toDBCAttributeValue(relAtt.Attribute, relVal.Value, attVal)
+		ast.RelationAttributeValues = append(ast.RelationAttributeValues, attVal)
+	}
+
+	return ast
+}
+
+// relationAttributeKind returns the kind of the AST of a relation attribute.
+func relationAttributeKind(kind string) dbc.AttributeKind {
+	switch kind {
+	case relationKindNodeSignal:
+		return dbc.AttributeNodeSignalRelation
+	case relationKindNodeEnvVar:
+		return dbc.AttributeNodeEnvVarRelation
+	}
+	return dbc.AttributeNodeMessageRelation
+}
+
+func (ev *DBCEnvVar) toAST(name string) *dbc.EnvVar {
+	envVar := &dbc.EnvVar{
+		Name:         name,
+		Min:          ev.Min,
+		Max:          ev.Max,
+		Unit:         ev.Unit,
+		InitialValue: ev.InitialValue,
+		ID:           ev.ID,
+		AccessType:   envVarAccessType(ev.AccessType),
+		AccessNodes:  ev.AccessNodes,
+	}
+
+	switch ev.Type {
+	case envVarTypeFloat:
+		envVar.Type = dbc.EnvVarFloat
+	case envVarTypeString:
+		envVar.Type = dbc.EnvVarString
+	}
+
+	if len(envVar.AccessNodes) == 0 {
+		envVar.AccessNodes = []string{dbcDefNode}
+	}
+
+	return envVar
+}
+
+// envVarAccessTypes are the access types of the environment variables by name, the ones with the 0x8000 flag
+// are the access types of the string environment variables.
+var envVarAccessTypes = []dbc.EnvVarAccessType{
+	dbc.EnvVarDummyNodeVector0,
+	dbc.EnvVarDummyNodeVector1,
+	dbc.EnvVarDummyNodeVector2,
+	dbc.EnvVarDummyNodeVector3,
+	dbc.EnvVarDummyNodeVector8000,
+	dbc.EnvVarDummyNodeVector8001,
+	dbc.EnvVarDummyNodeVector8002,
+	dbc.EnvVarDummyNodeVector8003,
+}
+
+// envVarAccessTypeNames are the names of the access types of the environment variables, as written in the DBC file.
+var envVarAccessTypeNames = []string{
+	"DUMMY_NODE_VECTOR0",
+	"DUMMY_NODE_VECTOR1",
+	"DUMMY_NODE_VECTOR2",
+	"DUMMY_NODE_VECTOR3",
+	"DUMMY_NODE_VECTOR8000",
+	"DUMMY_NODE_VECTOR8001",
+	"DUMMY_NODE_VECTOR8002",
+	"DUMMY_NODE_VECTOR8003",
+}
+
+func envVarAccessType(name string) dbc.EnvVarAccessType {
+	for idx, accName := range envVarAccessTypeNames {
+		if accName == name {
+			return envVarAccessTypes[idx]
+		}
+	}
+	return dbc.EnvVarDummyNodeVector0
+}
+
+func envVarAccessTypeName(accType dbc.EnvVarAccessType) string {
+	for idx, acc := range envVarAccessTypes {
+		if acc == accType {
+			return envVarAccessTypeNames[idx]
+		}
+	}
+	return envVarAccessTypeNames[0]
+}
+
+func (st *DBCSignalType) toAST(name string) *dbc.SignalType {
+	sigType := &dbc.SignalType{
+		TypeName:       name,
+		Size:           st.Size,
+		Factor:         st.Scale,
+		Offset:         st.Offset,
+		Min:            st.Min,
+		Max:            st.Max,
+		Unit:           st.Unit,
+		DefaultValue:   st.DefaultValue,
+		ValueTableName: st.ValueTable,
+	}
+	if st.Endianness == "big" {
+		sigType.ByteOrder = dbc.SignalBigEndian
+	}
+	if st.Signed {
+		sigType.ValueType = dbc.SignalSigned
+	}
+	return sigType
+}
+
+// validatePassThrough warns about the elements of the pass-through referencing messages, signals,
+// nodes or attributes that are not defined, they are not written in the DBC file.
+func (c *CanModel) validatePassThrough(diags *Diagnostics) {
+	pt := c.PassThrough
+	if pt == nil {
+		return
+	}
+	loc := location{path: "pass_through"}
+
+	checkMessage := func(loc location, msgName string) bool {
+		if _, ok := c.Messages[msgName]; !ok {
+			diags.warnf(loc, "message [%s] is not defined in the messages -> SKIPPED", msgName)
+			return false
+		}
+		return true
+	}
+	checkSignal := func(loc location, msgName, sigName string) {
+		if !checkMessage(loc, msgName) {
+			return
+		}
+		if _, ok := c.Messages[msgName].childSignals[sigName]; !ok {
+			diags.warnf(loc, "signal [%s] is not defined in message [%s] -> SKIPPED", sigName, msgName)
+		}
+	}
+
+	for _, attName := range sortedKeys(pt.GeneralAttributeValues) {
+		if _, ok := c.GeneralAttributes[attName]; !ok {
+			diags.warnf(loc.child("general_attribute_values", attName), "general attribute [%s] is not defined in the general attributes -> SKIPPED", attName)
+		}
+	}
+	for _, msgName := range sortedKeys(pt.MessageTransmitters) {
+		checkMessage(loc.child("message_transmitters", msgName), msgName)
+	}
+	for idx, ref := range pt.SignalTypeRefs {
+		refLoc := loc.child("signal_type_refs", strconv.Itoa(idx))
+		checkSignal(refLoc, ref.Message, ref.Signal)
+		if _, ok := pt.SignalTypes[ref.Type]; !ok {
+			diags.warnf(refLoc, "signal type [%s] is not defined in the signal types", ref.Type)
+		}
+	}
+	for idx, group := range pt.SignalGroups {
+		checkMessage(loc.child("signal_groups", strconv.Itoa(idx)), group.Message)
+	}
+	for idx, valType := range pt.SignalValueTypes {
+		checkSignal(loc.child("signal_value_types", strconv.Itoa(idx)), valType.Message, valType.Signal)
+	}
+
+	for _, attName := range sortedKeys(pt.RelationAttributes) {
+		relAtt := pt.RelationAttributes[attName]
+		attLoc := loc.child("relation_attributes", attName)
+		switch relAtt.Kind {
+		case relationKindNodeMessage, relationKindNodeSignal, relationKindNodeEnvVar:
+		default:
+			diags.errorf(attLoc.child("kind"), "relation attribute [%s] has an invalid kind [%s], expected %s, %s or %s",
+				attName, relAtt.Kind, relationKindNodeMessage, relationKindNodeSignal, relationKindNodeEnvVar)
+		}
+		if !hasAttributeType(relAtt.Attribute) {
+			diags.errorf(attLoc, "relation attribute [%s] has no type", attName)
+		}
+	}
+
+	for idx, relVal := range pt.RelationAttributeValues {
+		valLoc := loc.child("relation_attribute_values", strconv.Itoa(idx))
+		relAtt, ok := pt.RelationAttributes[relVal.Attribute]
+		if !ok {
+			diags.warnf(valLoc, "relation attribute [%s] is not defined in the relation attributes -> SKIPPED", relVal.Attribute)
+			continue
+		}
+		switch relAtt.Kind {
+		case relationKindNodeMessage:
+			checkMessage(valLoc, relVal.Message)
+		case relationKindNodeSignal:
+			checkSignal(valLoc, relVal.Message, relVal.Signal)
+		}
+	}
+}
+
+// relationKindName returns the name of the kind of a relation attribute of the AST.
+func relationKindName(kind dbc.AttributeKind) string {
+	switch kind {
+	case dbc.AttributeNodeSignalRelation:
+		return relationKindNodeSignal
+	case dbc.AttributeNodeEnvVarRelation:
+		return relationKindNodeEnvVar
+	}
+	return relationKindNodeMessage
+}
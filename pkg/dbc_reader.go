@@ -1,79 +1,776 @@
 package pkg
 
 import (
-	"bufio"
+	"errors"
+	"fmt"
+	"io"
+	"math"
 	"os"
-	"regexp"
+	"strconv"
+	"strings"
 
-	"github.com/squadracorsepolito/jsondbc/pkg/reg"
-	"github.com/squadracorsepolito/jsondbc/pkg/sym"
+	"github.com/squadracorsepolito/jsondbc/pkg/cangoru/dbc"
 )
 
+// DBCReader reads the CAN model from a DBC file, parsed by the cangoru DBC parser.
 type DBCReader struct {
-	currLine  int
-	fileLines []string
+	fileName string
+	canModel *CanModel
+
+	// messages are the messages of the model by id
+	messages map[uint32]*Message
+	// signals are the signals of the model, including the multiplexed ones, by message id and name
+	signals map[uint32]map[string]*Signal
+	// passThrough contains the elements of the DBC file that are not part of the model
+	passThrough *DBCPassThrough
 }
 
 func NewDBCReader() *DBCReader {
-	return &DBCReader{
-		currLine:  0,
-		fileLines: []string{},
-	}
+	return &DBCReader{}
 }
 
 func (r *DBCReader) Read(file *os.File) (*CanModel, error) {
-	fileScanner := bufio.NewScanner(file)
-	fileScanner.Split(bufio.ScanLines)
-	lines := []string{}
+	data, err := io.ReadAll(file)
+	if err != nil {
+		return nil, err
+	}
 
-	for fileScanner.Scan() {
-		lines = append(lines, fileScanner.Text())
+	r.fileName = file.Name()
+	r.canModel = &CanModel{
+		Nodes:             make(map[string]*Node),
+		GeneralAttributes: make(map[string]*Attribute),
+		NodeAttributes:    make(map[string]*NodeAttribute),
+		MessageAttributes: make(map[string]*MessageAttribute),
+		SignalAttributes:  make(map[string]*SignalAttribute),
+		Messages:          make(map[string]*Message),
+
+		source: sourceTypeDBC,
 	}
+	r.messages = make(map[uint32]*Message)
+	r.signals = make(map[uint32]map[string]*Signal)
+	r.passThrough = newDBCPassThrough()
 
-	cfg := &textReaderCfg{
-		varsionIdent:   sym.DBCVersion,
-		busSpeedIdent:  sym.DBCBusSpeed,
-		nodeIdent:      sym.DBCNode,
-		msgIdent:       sym.DBCMessage,
-		sigIdent:       sym.DBCSignal,
-		extMuxSigIdent: sym.DBCExtMuxValue,
-		bitmapDefIdent: sym.DBCValue,
-		commentIdent:   sym.DBCComment,
-		attIdent:       sym.DBCAttDef,
-		attDefIdent:    sym.DBCAttDefaultVal,
-		attAssIdent:    sym.DBCAttAssignment,
+	ast, err := dbc.NewParser(data).Parse()
+	if err != nil {
+		var syntaxErr *dbc.Error
+		if errors.As(err, &syntaxErr) {
+			return nil, r.errorf(syntaxErr.Pos, "%s, got %q", syntaxErr.Msg, syntaxErr.Token)
+		}
+		return nil, err
 	}
-	reader := &textReader{
-		cfg: cfg,
 
-		lines: lines,
+	r.canModel.Version = ast.Version
+	if ast.BitTiming != nil {
+		r.canModel.Baudrate = ast.BitTiming.Baudrate
+	}
+	if ast.Nodes != nil {
+		r.readNodes(ast.Nodes)
+	}
 
-		versionReg: reg.DBCVersion,
+	extMuxes := make(map[uint32][]*dbc.ExtendedMux)
+	for _, extMux := range ast.ExtendedMuxes {
+		extMuxes[extMux.MessageID] = append(extMuxes[extMux.MessageID], extMux)
+	}
+	for _, dbcMsg := range ast.Messages {
+		if err := r.readMessage(dbcMsg, extMuxes[dbcMsg.ID]); err != nil {
+			return nil, err
+		}
+	}
 
-		busSpeedReg: reg.DBCBusSpeed,
+	r.readPassThrough(ast)
+	r.readValueEncodings(ast.ValueEncodings)
+	r.readComments(ast.Comments)
 
-		nodeReg: reg.DBCNode,
+	r.readAttributes(ast.Attributes)
+	r.readRelationAttributes(ast.RelationAttributes)
+	for _, attDef := range append(ast.AttributeDefaults, ast.RelationAttributeDefaults...) {
+		if err := r.readAttributeDefault(attDef); err != nil {
+			return nil, err
+		}
+	}
+	for _, attVal := range ast.AttributeValues {
+		if err := r.readAttributeValue(attVal); err != nil {
+			return nil, err
+		}
+	}
+	for _, attVal := range ast.RelationAttributeValues {
+		if err := r.readRelationAttributeValue(attVal); err != nil {
+			return nil, err
+		}
+	}
 
-		msgStartReg: reg.DBCMessage,
-		msgEndReg:   regexp.MustCompile(`^$`),
+	if !r.passThrough.isEmpty() {
+		r.canModel.PassThrough = r.passThrough
+	}
 
-		sigReg:       reg.DBCSignal,
-		extMuxSigReg: reg.DBCExtMuxSignal,
+	return r.canModel, nil
+}
 
-		bitmapDefReg: reg.DBCBitmapDef,
+// getPosition returns the position in the DBC file of an element of the AST, used by the diagnostics.
+func (r *DBCReader) getPosition(pos dbc.Position) Position {
+	return Position{File: r.fileName, Line: pos.Line, Column: pos.Column}
+}
 
-		nodeCommentReg: reg.DBCNodeComment,
-		msgCommentReg:  reg.DBCMessageComment,
-		sigCommentReg:  reg.DBCSignalComment,
+func (r *DBCReader) errorf(pos dbc.Position, format string, a ...any) error {
+	return fmt.Errorf("%s: %s", r.getPosition(pos), fmt.Sprintf(format, a...))
+}
 
-		attReg: reg.DBCAttribute,
+func (r *DBCReader) readNodes(dbcNodes *dbc.Nodes) {
+	for _, name := range dbcNodes.Names {
+		r.canModel.Nodes[name] = &Node{
+			AttributeAssignments: &AttributeAssignments{
+				Attributes: make(map[string]any),
+			},
+		}
+	}
+}
 
-		attDefReg: reg.DBCAttributeDefault,
+func (r *DBCReader) readMessage(dbcMsg *dbc.Message, extMuxes []*dbc.ExtendedMux) error {
+	msg := &Message{
+		ID:      dbcMsg.ID,
+		Length:  dbcMsg.Size,
+		Sender:  dbcMsg.Transmitter,
+		Signals: make(map[string]*Signal),
+		AttributeAssignments: &AttributeAssignments{
+			Attributes: make(map[string]any),
+		},
 
-		nodeAttAssReg: reg.DBCNodeAttributeAssignment,
-		msgAttAssReg:  reg.DBCMessageAttributeAssignment,
-		sigAttAssReg:  reg.DBCSignalAttributeAssignment,
+		messageName: dbcMsg.Name,
+		loc:         location{position: r.getPosition(dbcMsg.Pos)},
+		fromDBC:     true,
 	}
+	r.canModel.Messages[dbcMsg.Name] = msg
+	r.messages[dbcMsg.ID] = msg
+
+	signals := make(map[string]*Signal, len(dbcMsg.Signals))
+	r.signals[dbcMsg.ID] = signals
+
+	splMuxSigs := []*Signal{}
+	isExtMux := false
+	extMuxSigs := make(map[string]*Signal)
+
+	for _, dbcSig := range dbcMsg.Signals {
+		sig := r.readSignal(dbcSig)
+		signals[sig.signalName] = sig
+
+		switch {
+		case !sig.isMultiplexed:
+			msg.Signals[sig.signalName] = sig
+
+		case !sig.isMultiplexor && !isExtMux:
+			splMuxSigs = append(splMuxSigs, sig)
+
+		default:
+			// a multiplexed multiplexor makes the message use the extended multiplexing,
+			// so the tree of the mux groups is defined by the SG_MUL_VAL_ entries
+			if !isExtMux {
+				isExtMux = true
+				for _, splSig := range splMuxSigs {
+					extMuxSigs[splSig.signalName] = splSig
+				}
+				splMuxSigs = nil
+			}
+			extMuxSigs[sig.signalName] = sig
+		}
+	}
+
+	if len(splMuxSigs) > 0 {
+		hasMultiplexor := false
+		for _, sig := range msg.Signals {
+			if !sig.isMultiplexor {
+				continue
+			}
+
+			hasMultiplexor = true
+			for _, splSig := range splMuxSigs {
+				sig.MuxGroup[splSig.signalName] = splSig
+			}
+		}
+
+		if !hasMultiplexor {
+			for _, splSig := range splMuxSigs {
+				r.canModel.readDiagnostics.warnf(splSig.loc, "signal [%s] is multiplexed but message [%s] has no multiplexor -> SKIPPED",
+					splSig.signalName, dbcMsg.Name)
+			}
+		}
+
+		return nil
+	}
+
+	if !isExtMux {
+		return nil
+	}
+
+	if len(extMuxes) == 0 {
+		return r.errorf(dbcMsg.Pos, "message [%s] has extended multiplexed signals but no SG_MUL_VAL_ definition", dbcMsg.Name)
+	}
+
+	// grouped are the multiplexed signals placed in a mux group by a SG_MUL_VAL_ entry
+	grouped := make(map[string]bool)
+	for _, extMux := range extMuxes {
+		muxedSig, ok := extMuxSigs[extMux.MultiplexedName]
+		if !ok {
+			return r.errorf(extMux.Pos, "signal [%s] is not a multiplexed signal of message [%s]", extMux.MultiplexedName, dbcMsg.Name)
+		}
+
+		muxSig, ok := signals[extMux.MultiplexorName]
+		if !ok || !muxSig.isMultiplexor {
+			return r.errorf(extMux.Pos, "signal [%s] is not a multiplexor of message [%s]", extMux.MultiplexorName, dbcMsg.Name)
+		}
+
+		muxSig.MuxGroup[muxedSig.signalName] = muxedSig
+		grouped[muxedSig.signalName] = true
+	}
+
+	for _, dbcSig := range dbcMsg.Signals {
+		if sig, ok := extMuxSigs[dbcSig.Name]; ok && !grouped[dbcSig.Name] {
+			r.canModel.readDiagnostics.warnf(sig.loc, "signal [%s] of message [%s] has no SG_MUL_VAL_ definition -> SKIPPED",
+				sig.signalName, dbcMsg.Name)
+		}
+	}
+
+	return nil
+}
 
-	return reader.read()
+func (r *DBCReader) readSignal(dbcSig *dbc.Signal) *Signal {
+	endianness := "little"
+	bigEndian := false
+	if dbcSig.ByteOrder == dbc.SignalBigEndian {
+		bigEndian = true
+		endianness = "big"
+	}
+
+	receivers := []string{}
+	for _, rec := range dbcSig.Receivers {
+		if rec != dbcDefNode {
+			receivers = append(receivers, rec)
+		}
+	}
+
+	return &Signal{
+		StartBit:   dbcSig.StartBit,
+		Size:       dbcSig.Size,
+		Signed:     dbcSig.ValueType == dbc.SignalSigned,
+		Unit:       dbcSig.Unit,
+		Endianness: endianness,
+		Receivers:  receivers,
+		Scale:      dbcSig.Factor,
+		Offset:     dbcSig.Offset,
+		Min:        dbcSig.Min,
+		Max:        dbcSig.Max,
+		Enum:       make(map[string]uint32),
+		MuxGroup:   make(map[string]*Signal),
+		MuxSwitch:  dbcSig.MuxSwitchValue,
+		AttributeAssignments: &AttributeAssignments{
+			Attributes: make(map[string]any),
+		},
+
+		isBigEndian:   bigEndian,
+		signalName:    dbcSig.Name,
+		loc:           location{position: r.getPosition(dbcSig.Pos)},
+		isMultiplexor: dbcSig.IsMultiplexor,
+		isMultiplexed: dbcSig.IsMultiplexed,
+	}
+}
+
+// getSignal returns the signal with the given name of the message with the given id.
+func (r *DBCReader) getSignal(msgID uint32, sigName string) (*Signal, bool) {
+	sig, ok := r.signals[msgID][sigName]
+	return sig, ok
+}
+
+// readEnum returns the enum of the value descriptions, with the labels as keys.
+// The negative values of a signed signal are mapped to their raw two's complement,
+// the values that cannot be mapped are skipped with a warning.
+func (r *DBCReader) readEnum(valDescs []*dbc.ValueDescription, sig *Signal) map[string]uint32 {
+	enum := make(map[string]uint32, len(valDescs))
+	for _, val := range valDescs {
+		raw, ok := enumRawValue(val.ID, sig)
+		if !ok {
+			loc := location{position: r.getPosition(val.Pos)}
+			r.canModel.readDiagnostics.warnf(loc, "value description [%s] %d is not a valid raw value -> SKIPPED", val.Name, val.ID)
+			continue
+		}
+		enum[val.Name] = raw
+	}
+	return enum
+}
+
+// enumRawValue returns the raw value of a value description of the signal, nil for the value tables
+// and the environment variables. A negative value is valid only for a signed signal up to 32 bits.
+func enumRawValue(id int64, sig *Signal) (uint32, bool) {
+	if id >= 0 {
+		return uint32(id), id <= math.MaxUint32
+	}
+	if sig == nil || !sig.Signed || sig.Size == 0 || sig.Size > 32 || id < -(int64(1)<<(sig.Size-1)) {
+		return 0, false
+	}
+	return uint32(id & (int64(1)<<sig.Size - 1)), true
+}
+
+func (r *DBCReader) readValueEncodings(valEncs []*dbc.ValueEncoding) {
+	for _, valEnc := range valEncs {
+		if valEnc.Kind == dbc.ValueEncodingEnvVar {
+			if envVar, ok := r.passThrough.EnvVars[valEnc.EnvVarName]; ok {
+				envVar.Enum = r.readEnum(valEnc.Values, nil)
+			}
+			continue
+		}
+
+		sig, ok := r.getSignal(valEnc.MessageID, valEnc.SignalName)
+		if !ok {
+			continue
+		}
+		sig.Enum = r.readEnum(valEnc.Values, sig)
+	}
+}
+
+func (r *DBCReader) readComments(comments []*dbc.Comment) {
+	for _, comment := range comments {
+		switch comment.Kind {
+		case dbc.CommentGeneral:
+			// the baud rate comment is written by the DBC writer from the baud rate of the model
+			if baudrate, ok := strings.CutPrefix(comment.Text, "Baudrate: "); ok {
+				if _, err := strconv.ParseUint(baudrate, 10, 32); err == nil {
+					continue
+				}
+			}
+			r.passThrough.Comments = append(r.passThrough.Comments, comment.Text)
+
+		case dbc.CommentNode:
+			if node, ok := r.canModel.Nodes[comment.NodeName]; ok {
+				node.Description = comment.Text
+			}
+
+		case dbc.CommentMessage:
+			if msg, ok := r.messages[comment.MessageID]; ok {
+				msg.Description = comment.Text
+			}
+
+		case dbc.CommentSignal:
+			if sig, ok := r.getSignal(comment.MessageID, comment.SignalName); ok {
+				sig.Description = comment.Text
+			}
+
+		case dbc.CommentEnvVar:
+			if envVar, ok := r.passThrough.EnvVars[comment.EnvVarName]; ok {
+				envVar.Description = comment.Text
+			}
+		}
+	}
+}
+
+// readAttribute returns the attribute of the model with the type of the attribute definition.
+func (r *DBCReader) readAttribute(dbcAtt *dbc.Attribute) *Attribute {
+	att := &Attribute{attributeName: dbcAtt.Name}
+
+	switch dbcAtt.Type {
+	case dbc.AttributeInt:
+		att.attributeType = attributeTypeInt
+		att.Int = &AttributeInt{From: dbcAtt.MinInt, To: dbcAtt.MaxInt}
+
+	case dbc.AttributeHex:
+		att.attributeType = attributeTypeInt
+		att.Int = &AttributeInt{From: dbcAtt.MinHex, To: dbcAtt.MaxHex}
+
+	case dbc.AttributeFloat:
+		att.attributeType = attributeTypeFloat
+		att.Float = &AttributeFloat{From: dbcAtt.MinFloat, To: dbcAtt.MaxFloat}
+
+	case dbc.AttributeString:
+		att.attributeType = attributeTypeString
+		att.String = &AttributeString{}
+
+	case dbc.AttributeEnum:
+		att.attributeType = attributeTypeEnum
+		att.Enum = &AttributeEnum{Values: dbcAtt.EnumValues}
+	}
+
+	return att
+}
+
+func (r *DBCReader) readAttributes(dbcAtts []*dbc.Attribute) {
+	for _, dbcAtt := range dbcAtts {
+		att := r.readAttribute(dbcAtt)
+
+		switch dbcAtt.Kind {
+		case dbc.AttributeGeneral:
+			att.attributeKind = attributeKindGeneral
+			r.canModel.GeneralAttributes[dbcAtt.Name] = att
+		case dbc.AttributeNode:
+			att.attributeKind = attributeKindNode
+			r.canModel.NodeAttributes[dbcAtt.Name] = &NodeAttribute{Attribute: att}
+		case dbc.AttributeMessage:
+			att.attributeKind = attributeKindMessage
+			r.canModel.MessageAttributes[dbcAtt.Name] = &MessageAttribute{Attribute: att}
+		case dbc.AttributeSignal:
+			att.attributeKind = attributeKindSignal
+			r.canModel.SignalAttributes[dbcAtt.Name] = &SignalAttribute{Attribute: att}
+		case dbc.AttributeEnvVar:
+			r.passThrough.EnvVarAttributes[dbcAtt.Name] = att
+		}
+	}
+}
+
+func (r *DBCReader) readRelationAttributes(dbcAtts []*dbc.Attribute) {
+	for _, dbcAtt := range dbcAtts {
+		r.passThrough.RelationAttributes[dbcAtt.Name] = &DBCRelationAttribute{
+			Kind:      relationKindName(dbcAtt.Kind),
+			Attribute: r.readAttribute(dbcAtt),
+		}
+	}
+}
+
+// getAttribute returns the attribute with the given name, of any kind, including the ones of the pass-through.
+func (r *DBCReader) getAttribute(attName string) (*Attribute, bool) {
+	if att, ok := r.canModel.GeneralAttributes[attName]; ok {
+		return att, true
+	}
+	if att, ok := r.canModel.NodeAttributes[attName]; ok {
+		return att.Attribute, true
+	}
+	if att, ok := r.canModel.MessageAttributes[attName]; ok {
+		return att.Attribute, true
+	}
+	if att, ok := r.canModel.SignalAttributes[attName]; ok {
+		return att.Attribute, true
+	}
+	if att, ok := r.passThrough.EnvVarAttributes[attName]; ok {
+		return att, true
+	}
+	if att, ok := r.passThrough.RelationAttributes[attName]; ok {
+		return att.Attribute, true
+	}
+	return nil, false
+}
+
+// dbcAttributeValue is the value of an attribute default or assignment in the DBC file.
+type dbcAttributeValue struct {
+	isString bool
+	isFloat  bool
+
+	valString string
+	valInt    int
+	valFloat  float64
+}
+
+// getAttributeValue converts the value from the DBC file to the type of the attribute.
+// The value of an enum attribute is the name of the enum value, referenced by index or by name.
+func (r *DBCReader) getAttributeValue(att *Attribute, val dbcAttributeValue) (any, error) {
+	switch att.attributeType {
+	case attributeTypeInt:
+		if val.isString || val.isFloat {
+			return nil, fmt.Errorf("attribute [%s] expects an integer value", att.attributeName)
+		}
+		return val.valInt, nil
+
+	case attributeTypeFloat:
+		if val.isString {
+			return nil, fmt.Errorf("attribute [%s] expects a float value", att.attributeName)
+		}
+		if val.isFloat {
+			return val.valFloat, nil
+		}
+		return float64(val.valInt), nil
+
+	case attributeTypeString:
+		if !val.isString {
+			return nil, fmt.Errorf("attribute [%s] expects a string value", att.attributeName)
+		}
+		return val.valString, nil
+
+	case attributeTypeEnum:
+		if val.isString {
+			for _, enumVal := range att.Enum.Values {
+				if enumVal == val.valString {
+					return enumVal, nil
+				}
+			}
+			return nil, fmt.Errorf("attribute [%s] has no enum value [%s]", att.attributeName, val.valString)
+		}
+		if val.isFloat || val.valInt < 0 || val.valInt >= len(att.Enum.Values) {
+			return nil, fmt.Errorf("attribute [%s] has no enum value with index %d", att.attributeName, val.valInt)
+		}
+		return att.Enum.Values[val.valInt], nil
+	}
+
+	return nil, nil
+}
+
+func (r *DBCReader) readAttributeDefault(attDef *dbc.AttributeDefault) error {
+	att, ok := r.getAttribute(attDef.AttributeName)
+	if !ok {
+		return nil
+	}
+
+	val := dbcAttributeValue{}
+	switch attDef.Type {
+	case dbc.AttributeDefaultString:
+		val.isString = true
+		val.valString = attDef.ValueString
+	case dbc.AttributeDefaultFloat:
+		val.isFloat = true
+		val.valFloat = attDef.ValueFloat
+	case dbc.AttributeDefaultHex:
+		val.valInt = attDef.ValueHex
+	default:
+		val.valInt = attDef.ValueInt
+	}
+
+	defVal, err := r.getAttributeValue(att, val)
+	if err != nil {
+		return r.errorf(attDef.Pos, "%v", err)
+	}
+
+	switch att.attributeType {
+	case attributeTypeInt:
+		att.Int.Default = defVal.(int)
+	case attributeTypeFloat:
+		att.Float.Default = defVal.(float64)
+	case attributeTypeString:
+		att.String.Default = defVal.(string)
+	case attributeTypeEnum:
+		att.Enum.Default = defVal.(string)
+		att.Enum.initAttributeEnum()
+	}
+
+	return nil
+}
+
+func (r *DBCReader) readAttributeValue(attVal *dbc.AttributeValue) error {
+	var assignments *AttributeAssignments
+	var att *Attribute
+
+	switch attVal.AttributeKind {
+	case dbc.AttributeGeneral:
+		att, ok := r.canModel.GeneralAttributes[attVal.AttributeName]
+		if !ok {
+			return nil
+		}
+		value, err := r.getAttributeValue(att, r.readValue(attVal))
+		if err != nil {
+			return r.errorf(attVal.Pos, "%v", err)
+		}
+		r.passThrough.GeneralAttributeValues[attVal.AttributeName] = value
+		return nil
+
+	case dbc.AttributeNode:
+		node, ok := r.canModel.Nodes[attVal.NodeName]
+		nodeAtt, isNodeAtt := r.canModel.NodeAttributes[attVal.AttributeName]
+		if !ok || !isNodeAtt {
+			return nil
+		}
+		assignments, att = node.AttributeAssignments, nodeAtt.Attribute
+
+	case dbc.AttributeMessage:
+		msg, ok := r.messages[attVal.MessageID]
+		msgAtt, isMsgAtt := r.canModel.MessageAttributes[attVal.AttributeName]
+		if !ok || !isMsgAtt {
+			return nil
+		}
+		assignments, att = msg.AttributeAssignments, msgAtt.Attribute
+
+	case dbc.AttributeSignal:
+		sig, ok := r.getSignal(attVal.MessageID, attVal.SignalName)
+		sigAtt, isSigAtt := r.canModel.SignalAttributes[attVal.AttributeName]
+		if !ok || !isSigAtt {
+			return nil
+		}
+		assignments, att = sig.AttributeAssignments, sigAtt.Attribute
+
+	case dbc.AttributeEnvVar:
+		envVar, ok := r.passThrough.EnvVars[attVal.EnvVarName]
+		envVarAtt, isEnvVarAtt := r.passThrough.EnvVarAttributes[attVal.AttributeName]
+		if !ok || !isEnvVarAtt {
+			return nil
+		}
+		assignments, att = envVar.AttributeAssignments, envVarAtt
+
+	default:
+		return nil
+	}
+
+	assVal, err := r.getAttributeValue(att, r.readValue(attVal))
+	if err != nil {
+		return r.errorf(attVal.Pos, "%v", err)
+	}
+	assignments.Attributes[attVal.AttributeName] = assVal
+
+	return nil
+}
+
+// readValue returns the value of an attribute assignment.
+func (r *DBCReader) readValue(attVal *dbc.AttributeValue) dbcAttributeValue {
+	val := dbcAttributeValue{}
+	switch attVal.Type {
+	case dbc.AttributeValueString:
+		val.isString = true
+		val.valString = attVal.ValueString
+	case dbc.AttributeValueFloat:
+		val.isFloat = true
+		val.valFloat = attVal.ValueFloat
+	case dbc.AttributeValueHex:
+		val.valInt = attVal.ValueHex
+	default:
+		val.valInt = attVal.ValueInt
+	}
+	return val
+}
+
+// readRelationAttributeValue reads a relation attribute assignment into the pass-through,
+// the ones referencing messages or signals that are not defined are skipped.
+func (r *DBCReader) readRelationAttributeValue(attVal *dbc.AttributeValue) error {
+	relAtt, ok := r.passThrough.RelationAttributes[attVal.AttributeName]
+	if !ok {
+		return nil
+	}
+
+	relVal := &DBCRelationAttributeValue{
+		Attribute: attVal.AttributeName,
+		Node:      attVal.NodeName,
+	}
+
+	switch attVal.AttributeKind {
+	case dbc.AttributeNodeMessageRelation:
+		msg, ok := r.messages[attVal.MessageID]
+		if !ok {
+			return nil
+		}
+		relVal.Message = msg.messageName
+
+	case dbc.AttributeNodeSignalRelation:
+		msg, ok := r.messages[attVal.MessageID]
+		if _, isSig := r.getSignal(attVal.MessageID, attVal.SignalName); !ok || !isSig {
+			return nil
+		}
+		relVal.Message = msg.messageName
+		relVal.Signal = attVal.SignalName
+
+	case dbc.AttributeNodeEnvVarRelation:
+		relVal.EnvVar = attVal.EnvVarName
+	}
+
+	val, err := r.getAttributeValue(relAtt.Attribute, r.readValue(attVal))
+	if err != nil {
+		return r.errorf(attVal.Pos, "%v", err)
+	}
+	relVal.Value = val
+	r.passThrough.RelationAttributeValues = append(r.passThrough.RelationAttributeValues, relVal)
+
+	return nil
+}
+
+// readPassThrough reads the elements of the DBC file that are not part of the model into the pass-through,
+// the ones referencing messages or signals that are not defined are skipped.
+func (r *DBCReader) readPassThrough(ast *dbc.DBC) {
+	pt := r.passThrough
+
+	for _, valTable := range ast.ValueTables {
+		pt.ValueTables[valTable.Name] = r.readEnum(valTable.Values, nil)
+	}
+
+	for _, msgTx := range ast.MessageTransmitters {
+		if msg, ok := r.messages[msgTx.MessageID]; ok {
+			pt.MessageTransmitters[msg.messageName] = append(pt.MessageTransmitters[msg.messageName], msgTx.Transmitters...)
+		}
+	}
+
+	for _, dbcEnvVar := range ast.EnvVars {
+		envVar := &DBCEnvVar{
+			Type:         envVarTypeInt,
+			Min:          dbcEnvVar.Min,
+			Max:          dbcEnvVar.Max,
+			Unit:         dbcEnvVar.Unit,
+			InitialValue: dbcEnvVar.InitialValue,
+			ID:           dbcEnvVar.ID,
+			AccessType:   envVarAccessTypeName(dbcEnvVar.AccessType),
+			AttributeAssignments: &AttributeAssignments{
+				Attributes: make(map[string]any),
+			},
+		}
+
+		switch dbcEnvVar.Type {
+		case dbc.EnvVarFloat:
+			envVar.Type = envVarTypeFloat
+		case dbc.EnvVarString:
+			envVar.Type = envVarTypeString
+		}
+
+		for _, node := range dbcEnvVar.AccessNodes {
+			if node != dbcDefNode {
+				envVar.AccessNodes = append(envVar.AccessNodes, node)
+			}
+		}
+
+		pt.EnvVars[dbcEnvVar.Name] = envVar
+	}
+
+	for _, envVarData := range ast.EnvVarDatas {
+		if envVar, ok := pt.EnvVars[envVarData.EnvVarName]; ok {
+			envVar.DataSize = envVarData.DataSize
+		}
+	}
+
+	for _, dbcSigType := range ast.SignalTypes {
+		sigType := &DBCSignalType{
+			Size:         dbcSigType.Size,
+			Endianness:   "little",
+			Signed:       dbcSigType.ValueType == dbc.SignalSigned,
+			Scale:        dbcSigType.Factor,
+			Offset:       dbcSigType.Offset,
+			Min:          dbcSigType.Min,
+			Max:          dbcSigType.Max,
+			Unit:         dbcSigType.Unit,
+			DefaultValue: dbcSigType.DefaultValue,
+			ValueTable:   dbcSigType.ValueTableName,
+		}
+		if dbcSigType.ByteOrder == dbc.SignalBigEndian {
+			sigType.Endianness = "big"
+		}
+
+		pt.SignalTypes[dbcSigType.TypeName] = sigType
+	}
+
+	for _, ref := range ast.SignalTypeRefs {
+		msg, ok := r.messages[ref.MessageID]
+		if _, isSig := r.getSignal(ref.MessageID, ref.SignalName); !ok || !isSig {
+			continue
+		}
+		pt.SignalTypeRefs = append(pt.SignalTypeRefs, &DBCSignalTypeRef{
+			Message: msg.messageName,
+			Signal:  ref.SignalName,
+			Type:    ref.TypeName,
+		})
+	}
+
+	for _, group := range ast.SignalGroups {
+		if msg, ok := r.messages[group.MessageID]; ok {
+			pt.SignalGroups = append(pt.SignalGroups, &DBCSignalGroup{
+				Message:     msg.messageName,
+				Name:        group.GroupName,
+				Repetitions: group.Repetitions,
+				Signals:     group.SignalNames,
+			})
+		}
+	}
+
+	for _, valType := range ast.SignalExtValueTypes {
+		msg, ok := r.messages[valType.MessageID]
+		if _, isSig := r.getSignal(valType.MessageID, valType.SignalName); !ok || !isSig {
+			continue
+		}
+
+		typ := signalValueTypeInteger
+		switch valType.ExtValueType {
+		case dbc.SignalExtValueTypeFloat:
+			typ = signalValueTypeFloat
+		case dbc.SignalExtValueTypeDouble:
+			typ = signalValueTypeDouble
+		}
+		pt.SignalValueTypes = append(pt.SignalValueTypes, &DBCSignalValueType{
+			Message: msg.messageName,
+			Signal:  valType.SignalName,
+			Type:    typ,
+		})
+	}
 }
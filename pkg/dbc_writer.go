@@ -4,6 +4,7 @@ import (
 	"fmt"
 	"os"
 
+	"github.com/squadracorsepolito/jsondbc/pkg/cangoru/dbc"
 	"github.com/squadracorsepolito/jsondbc/pkg/sym"
 )
 
@@ -49,39 +50,71 @@ func NewDBCWriter() *DBCWriter {
 
 func (w *DBCWriter) Write(file *os.File, canModel *CanModel) error {
 	f := newFile(file)
+	passThrough := canModel.PassThrough.toAST(canModel.Messages, canModel.GeneralAttributes)
 
 	f.print(sym.DBCVersion, formatString(canModel.Version))
 	f.print(dbcHeaders)
 
 	w.writeBitTiming(f)
 	w.writeNodes(f, canModel.Nodes)
+	w.writePassThrough(f, &dbc.DBC{ValueTables: passThrough.ValueTables})
 
 	for _, msg := range canModel.getMessages() {
 		w.writeMessage(f, msg)
 	}
+	w.writePassThrough(f, &dbc.DBC{
+		MessageTransmitters: passThrough.MessageTransmitters,
+		EnvVars:             passThrough.EnvVars,
+		EnvVarDatas:         passThrough.EnvVarDatas,
+		SignalTypes:         passThrough.SignalTypes,
+	})
 
 	w.writeComments(f, canModel)
+	w.writePassThrough(f, &dbc.DBC{Comments: passThrough.Comments})
 	f.newLine()
 
 	for _, att := range canModel.getAttributes() {
 		w.writeAttributeDefinition(f, att)
 	}
+	w.writePassThrough(f, &dbc.DBC{
+		Attributes:         passThrough.Attributes,
+		RelationAttributes: passThrough.RelationAttributes,
+	})
 	for _, att := range canModel.getAttributes() {
 		w.writeAttributeDefaultValue(f, att)
 	}
+	w.writePassThrough(f, &dbc.DBC{
+		AttributeDefaults:         passThrough.AttributeDefaults,
+		RelationAttributeDefaults: passThrough.RelationAttributeDefaults,
+	})
 
 	w.writeNodeAttributeAssignments(f, canModel.getNodeAttributes())
 	w.writeMessageAttributeAssignments(f, canModel.getMessageAttributes())
 	w.writeSignalAttributeAssignments(f, canModel.getSignalAttributes())
+	w.writePassThrough(f, &dbc.DBC{
+		AttributeValues:         passThrough.AttributeValues,
+		RelationAttributeValues: passThrough.RelationAttributeValues,
+	})
 
 	f.newLine()
 	w.writeBitmaps(f, canModel)
+	w.writePassThrough(f, &dbc.DBC{
+		ValueEncodings:      passThrough.ValueEncodings,
+		SignalTypeRefs:      passThrough.SignalTypeRefs,
+		SignalGroups:        passThrough.SignalGroups,
+		SignalExtValueTypes: passThrough.SignalExtValueTypes,
+	})
 
 	w.writeMuxGroup(f, canModel.Messages)
 
 	return nil
 }
 
+// writePassThrough writes the elements of the pass-through, in the DBC format of the cangoru writer.
+func (w *DBCWriter) writePassThrough(f *file, ast *dbc.DBC) {
+	f.write(dbc.NewWriter().Write(ast))
+}
+
 func (w *DBCWriter) writeBitTiming(f *file) {
 	f.print(sym.DBCBusSpeed, ":")
 	f.print()
@@ -127,7 +160,7 @@ func (w *DBCWriter) writeSignal(f *file, sig *Signal) {
 	byteDef := fmt.Sprintf("%d|%d@%d%s", sig.StartBit, sig.Size, byteOrder, valueType)
 	multiplier := fmt.Sprintf("(%s,%s)", formatFloat(sig.Scale), formatFloat(sig.Offset))
 	valueRange := fmt.Sprintf("[%s|%s]", formatFloat(sig.Min), formatFloat(sig.Max))
-	unit := fmt.Sprintf(`"%s"`, sig.Unit)
+	unit := formatString(sig.Unit)
 
 	receivers := ""
 	if len(sig.Receivers) == 0 {
@@ -197,11 +230,11 @@ func (w *DBCWriter) writeBitmaps(f *file, m *CanModel) {
 				first := true
 				for name, val := range sig.Enum {
 					if first {
-						bitmap += formatUint(val) + " " + formatString(name)
+						bitmap += formatEnumValue(val, sig) + " " + formatString(name)
 						first = false
 						continue
 					}
-					bitmap += " " + formatUint(val) + " " + formatString(name)
+					bitmap += " " + formatEnumValue(val, sig) + " " + formatString(name)
 				}
 				f.print(sym.DBCValue, msg.FormatID(), sigName, bitmap+";")
 			}
@@ -209,6 +242,14 @@ func (w *DBCWriter) writeBitmaps(f *file, m *CanModel) {
 	}
 }
 
+// formatEnumValue formats the raw value of an enum of the signal, negative if the signal is signed.
+func formatEnumValue(raw uint32, sig *Signal) string {
+	if sig.Signed && sig.Size > 0 && sig.Size <= 32 && raw >= uint32(1)<<(sig.Size-1) {
+		return formatInt(int(raw) - 1<<sig.Size)
+	}
+	return formatUint(raw)
+}
+
 func (w *DBCWriter) writeComments(f *file, m *CanModel) {
 	if m.Baudrate > 0 {
 		f.print(sym.DBCComment, formatString(fmt.Sprintf("Baudrate: %s", formatUint(m.Baudrate)))+";")
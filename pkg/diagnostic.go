@@ -0,0 +1,157 @@
+package pkg
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Severity is the severity of a diagnostic.
+type Severity int
+
+const (
+	SeverityError Severity = iota
+	SeverityWarning
+)
+
+func (s Severity) String() string {
+	if s == SeverityWarning {
+		return "warning"
+	}
+	return "error"
+}
+
+// Position is a position in the source file of the model, the line and the column start from 1.
+// A line equal to 0 means that the position is not known.
+type Position struct {
+	File   string
+	Line   int
+	Column int
+}
+
+// IsValid returns true if the line of the position is known.
+func (p Position) IsValid() bool {
+	return p.Line > 0
+}
+
+func (p Position) String() string {
+	str := p.File
+	if p.IsValid() {
+		if len(str) > 0 {
+			str += ":"
+		}
+		str += fmt.Sprintf("%d", p.Line)
+		if p.Column > 0 {
+			str += fmt.Sprintf(":%d", p.Column)
+		}
+	}
+	return str
+}
+
+// location is the path of an element of the model, for example messages.OBD2.signals.Service,
+// with its position in the source file.
+type location struct {
+	path     string
+	position Position
+}
+
+// child returns the location of a child element, with the position of the parent.
+func (l location) child(keys ...string) location {
+	if len(l.path) > 0 {
+		keys = append([]string{l.path}, keys...)
+	}
+	return location{path: strings.Join(keys, "."), position: l.position}
+}
+
+// Diagnostic is an error or a warning found in the model.
+type Diagnostic struct {
+	Severity Severity
+	// Path is the path of the element of the model, for example messages.OBD2.signals.Service
+	Path     string
+	Position Position
+	Message  string
+}
+
+func (d *Diagnostic) String() string {
+	str := ""
+	if pos := d.Position.String(); len(pos) > 0 {
+		str = pos + ": "
+	}
+	str += d.Severity.String() + ": "
+	if len(d.Path) > 0 {
+		str += d.Path + ": "
+	}
+	return str + d.Message
+}
+
+// Diagnostics is a list of errors and warnings found in the model.
+type Diagnostics []*Diagnostic
+
+func (d *Diagnostics) add(severity Severity, loc location, format string, a ...any) {
+	*d = append(*d, &Diagnostic{
+		Severity: severity,
+		Path:     loc.path,
+		Position: loc.position,
+		Message:  fmt.Sprintf(format, a...),
+	})
+}
+
+func (d *Diagnostics) errorf(loc location, format string, a ...any) {
+	d.add(SeverityError, loc, format, a...)
+}
+
+func (d *Diagnostics) warnf(loc location, format string, a ...any) {
+	d.add(SeverityWarning, loc, format, a...)
+}
+
+// sort sorts the diagnostics by position, then by path.
+func (d Diagnostics) sort() {
+	sort.SliceStable(d, func(i, j int) bool {
+		pi, pj := d[i].Position, d[j].Position
+		if pi.File != pj.File {
+			return pi.File < pj.File
+		}
+		if pi.Line != pj.Line {
+			return pi.Line < pj.Line
+		}
+		if pi.Column != pj.Column {
+			return pi.Column < pj.Column
+		}
+		return d[i].Path < d[j].Path
+	})
+}
+
+func (d Diagnostics) filter(severity Severity) Diagnostics {
+	filtered := Diagnostics{}
+	for _, diag := range d {
+		if diag.Severity == severity {
+			filtered = append(filtered, diag)
+		}
+	}
+	return filtered
+}
+
+// Errors returns the diagnostics with the error severity.
+func (d Diagnostics) Errors() Diagnostics {
+	return d.filter(SeverityError)
+}
+
+// Warnings returns the diagnostics with the warning severity.
+func (d Diagnostics) Warnings() Diagnostics {
+	return d.filter(SeverityWarning)
+}
+
+// HasErrors returns true if any diagnostic is an error.
+func (d Diagnostics) HasErrors() bool {
+	return len(d.Errors()) > 0
+}
+
+// Err returns an error containing all the errors, one per line, or nil if there are none.
+func (d Diagnostics) Err() error {
+	errs := []error{}
+	for _, diag := range d.Errors() {
+		errs = append(errs, errors.New(diag.String()))
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,183 @@
+// Package idassign assigns the ids of the messages of a CAN model that omit them.
+package idassign
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/squadracorsepolito/jsondbc/pkg"
+)
+
+// maxExtendedID is the highest extended id.
+const maxExtendedID = 0x1FFFFFFF
+
+// Range is an inclusive range of ids.
+type Range struct {
+	From uint32
+	To   uint32
+}
+
+// DefaultRange contains all the standard ids.
+var DefaultRange = Range{From: 0x001, To: 0x7FF}
+
+// ParseRange parses a range of ids with the form from-to, for example 0x100-0x1FF.
+// The ids are decimal or hexadecimal with the 0x prefix.
+func ParseRange(str string) (Range, error) {
+	fromStr, toStr, ok := strings.Cut(str, "-")
+	if !ok {
+		return Range{}, fmt.Errorf("invalid id range %q, expected from-to", str)
+	}
+
+	from, err := strconv.ParseUint(strings.TrimSpace(fromStr), 0, 32)
+	if err != nil {
+		return Range{}, fmt.Errorf("invalid id range %q: %w", str, err)
+	}
+	to, err := strconv.ParseUint(strings.TrimSpace(toStr), 0, 32)
+	if err != nil {
+		return Range{}, fmt.Errorf("invalid id range %q: %w", str, err)
+	}
+
+	if from > to || to > maxExtendedID {
+		return Range{}, fmt.Errorf("invalid id range %q, it must be increasing and up to 0x%X", str, maxExtendedID)
+	}
+
+	return Range{From: uint32(from), To: uint32(to)}, nil
+}
+
+// Contains returns true if the id is inside the range.
+func (r Range) Contains(id uint32) bool {
+	return id >= r.From && id <= r.To
+}
+
+func (r Range) String() string {
+	return fmt.Sprintf("0x%X-0x%X", r.From, r.To)
+}
+
+// Config contains the ranges of the assigned ids. The range of a message is the one of its priority class,
+// then the one of its sender node, otherwise the default one.
+type Config struct {
+	Default Range
+	Nodes   map[string]Range
+	Classes map[string]Range
+	// Step is the distance between the assigned ids, to leave room for the messages added later
+	Step uint32
+}
+
+// NewConfig returns a configuration with the default range and no ranges for the nodes and classes.
+func NewConfig() *Config {
+	return &Config{
+		Default: DefaultRange,
+		Nodes:   make(map[string]Range),
+		Classes: make(map[string]Range),
+		Step:    1,
+	}
+}
+
+// rangeOf returns the range of the ids of the message.
+func (cfg *Config) rangeOf(msg *pkg.Message) Range {
+	if r, ok := cfg.Classes[msg.Priority]; ok && len(msg.Priority) > 0 {
+		return r
+	}
+	if r, ok := cfg.Nodes[msg.Sender]; ok && len(msg.Sender) > 0 {
+		return r
+	}
+	return cfg.Default
+}
+
+// Assignment is the id assigned to a message.
+type Assignment struct {
+	Message string
+	ID      uint32
+	Range   Range
+	// Deadline is the deadline in ms used to sort the messages, 0 if the message has none
+	Deadline int
+}
+
+// deadline returns the deadline of the message in ms: deadline_ms, cycle_time or period_ms.
+// The messages without a deadline get the lowest priority.
+func deadline(msg *pkg.Message) int {
+	switch {
+	case msg.Deadline > 0:
+		return msg.Deadline
+	case msg.CycleTime > 0:
+		return msg.CycleTime
+	case msg.Period > 0:
+		return int(msg.Period)
+	}
+	return math.MaxInt
+}
+
+// Assign assigns the ids of the messages without one, the ids of the other messages are kept.
+// The messages are sorted by deadline (deadline monotonic), so in each range the messages
+// with a shorter deadline get the lower ids, i.e. the higher priority.
+// The assigned ids are set into the model, which is then validated.
+func Assign(canModel *pkg.CanModel, cfg *Config) ([]*Assignment, error) {
+	if cfg.Step == 0 {
+		return nil, fmt.Errorf("id step cannot be 0")
+	}
+
+	used := make(map[uint32]string)
+	for msgName, msg := range canModel.Messages {
+		if !msg.HasID() {
+			continue
+		}
+		used[msg.ID] = msgName
+
+		if r := cfg.rangeOf(msg); !r.Contains(msg.ID) {
+			log.Printf("WARNING: message [%s] id 0x%X is outside its range %s -> KEPT", msgName, msg.ID, r)
+		}
+	}
+
+	assignments := []*Assignment{}
+	for _, msgName := range canModel.UnassignedMessages() {
+		msg := canModel.Messages[msgName]
+		assignments = append(assignments, &Assignment{
+			Message:  msgName,
+			Range:    cfg.rangeOf(msg),
+			Deadline: deadline(msg),
+		})
+	}
+
+	// the messages with the same deadline are sorted by name, so the assignment is deterministic
+	sort.SliceStable(assignments, func(i, j int) bool {
+		return assignments[i].Deadline < assignments[j].Deadline
+	})
+
+	// next is the next candidate id of each range
+	next := make(map[Range]uint64)
+	for _, a := range assignments {
+		id, ok := next[a.Range]
+		if !ok {
+			id = uint64(a.Range.From)
+		}
+
+		for ; id <= uint64(a.Range.To); id += uint64(cfg.Step) {
+			if _, taken := used[uint32(id)]; !taken {
+				break
+			}
+		}
+		if id > uint64(a.Range.To) {
+			return nil, fmt.Errorf("message [%s] cannot get an id, range %s is full", a.Message, a.Range)
+		}
+
+		a.ID = uint32(id)
+		used[a.ID] = a.Message
+		next[a.Range] = id + uint64(cfg.Step)
+
+		canModel.Messages[a.Message].SetID(a.ID)
+
+		if a.Deadline == math.MaxInt {
+			a.Deadline = 0
+		}
+	}
+
+	if err := canModel.Validate(); err != nil {
+		return nil, err
+	}
+
+	return assignments, nil
+}
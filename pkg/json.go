@@ -1,10 +1,12 @@
 package pkg
 
 import (
+	"bytes"
 	"encoding/json"
 	"fmt"
 	"io/ioutil"
 	"os"
+	"reflect"
 )
 
 type JsonWriter struct{}
@@ -23,30 +25,23 @@ func (w *JsonWriter) Write(file *os.File, canModel *CanModel) error {
 	return err
 }
 
-type JsonReader struct{}
+type JsonReader struct {
+	// Strict rejects the fields of the model that are not known
+	Strict bool
+
+	fileName string
+}
 
 func NewJsonReader() *JsonReader {
 	return &JsonReader{}
 }
 
 func (r *JsonReader) getLineErr(input []byte, offset int, jsonErr error) error {
-	lf := rune(0x0A)
-
 	if offset > len(input) || offset < 0 {
 		return fmt.Errorf("couldn't find offset %d within the input", offset)
 	}
 
-	line := 1
-	for i, b := range input {
-		if b == byte(lf) {
-			line++
-		}
-		if i == offset {
-			break
-		}
-	}
-
-	return fmt.Errorf("line %d: %v", line, jsonErr)
+	return fmt.Errorf("%s: %v", newJSONLines(input).position(r.fileName, offset), jsonErr)
 }
 
 func (r *JsonReader) Read(file *os.File) (*CanModel, error) {
@@ -54,6 +49,7 @@ func (r *JsonReader) Read(file *os.File) (*CanModel, error) {
 	if err != nil {
 		return nil, err
 	}
+	r.fileName = file.Name()
 
 	canModel := &CanModel{}
 	err = json.Unmarshal(jsonFile, canModel)
@@ -68,7 +64,136 @@ func (r *JsonReader) Read(file *os.File) (*CanModel, error) {
 		return nil, err
 	}
 
+	canModel.positions, err = r.readPositions(jsonFile)
+	if err != nil {
+		return nil, err
+	}
+
+	if r.Strict {
+		diags := Diagnostics{}
+		if err := r.checkUnknownFields(jsonFile, reflect.TypeOf(canModel), location{}, canModel, &diags); err != nil {
+			return nil, err
+		}
+		diags.sort()
+		if err := diags.Err(); err != nil {
+			return nil, err
+		}
+	}
+
+	if err := r.readLayout(jsonFile, canModel); err != nil {
+		return nil, err
+	}
+
 	canModel.source = sourceTypeJSON
 
 	return canModel, nil
 }
+
+// jsonObjectFields returns the keys of a JSON object in declaration order and their raw values.
+func jsonObjectFields(raw json.RawMessage) ([]string, map[string]json.RawMessage, error) {
+	keys := []string{}
+	values := make(map[string]json.RawMessage)
+	if len(raw) == 0 {
+		return keys, values, nil
+	}
+
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, nil, err
+	}
+	if tok != json.Delim('{') {
+		return keys, values, nil
+	}
+
+	for dec.More() {
+		tok, err := dec.Token()
+		if err != nil {
+			return nil, nil, err
+		}
+		key := tok.(string)
+
+		var value json.RawMessage
+		if err := dec.Decode(&value); err != nil {
+			return nil, nil, err
+		}
+
+		if _, ok := values[key]; !ok {
+			keys = append(keys, key)
+		}
+		values[key] = value
+	}
+
+	return keys, values, nil
+}
+
+// readLayoutSignals returns the signals of a JSON signals or mux_group object in declaration order.
+func (r *JsonReader) readLayoutSignals(raw json.RawMessage, path []string) ([]*layoutSignal, error) {
+	sigNames, sigValues, err := jsonObjectFields(raw)
+	if err != nil {
+		return nil, err
+	}
+
+	signals := make([]*layoutSignal, 0, len(sigNames))
+	for _, sigName := range sigNames {
+		sigPath := append(append([]string{}, path...), sigName)
+
+		_, fields, err := jsonObjectFields(sigValues[sigName])
+		if err != nil {
+			return nil, err
+		}
+		_, hasStartBit := fields["start_bit"]
+
+		sig := &layoutSignal{name: sigName, path: sigPath, hasStartBit: hasStartBit}
+		if muxGroup, ok := fields["mux_group"]; ok {
+			sig.muxGroup, err = r.readLayoutSignals(muxGroup, append(sigPath, "mux_group"))
+			if err != nil {
+				return nil, err
+			}
+		}
+		signals = append(signals, sig)
+	}
+
+	return signals, nil
+}
+
+// readLayout flags the messages without the id field, which have to be assigned,
+// and places the signals without the start_bit field.
+func (r *JsonReader) readLayout(input []byte, canModel *CanModel) error {
+	_, modelFields, err := jsonObjectFields(input)
+	if err != nil {
+		return err
+	}
+
+	msgNames, msgValues, err := jsonObjectFields(modelFields["messages"])
+	if err != nil {
+		return err
+	}
+
+	canModel.placedSignals = make(map[string]uint32)
+	for _, msgName := range msgNames {
+		msg, ok := canModel.Messages[msgName]
+		if !ok || msg == nil {
+			continue
+		}
+
+		_, fields, err := jsonObjectFields(msgValues[msgName])
+		if err != nil {
+			return err
+		}
+
+		if _, ok := fields["id"]; !ok {
+			msg.missingID = true
+		}
+
+		signals, err := r.readLayoutSignals(fields["signals"], []string{"messages", msgName, "signals"})
+		if err != nil {
+			return err
+		}
+		if err := canModel.layoutSignals(msgName, msg, signals); err != nil {
+			return fmt.Errorf("%s: %w", canModel.positionOf(location{path: "messages"}.child(msgName).path), err)
+		}
+	}
+
+	return nil
+}
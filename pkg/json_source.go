@@ -0,0 +1,222 @@
+package pkg
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// jsonPath returns the key of the object at the given path of a JSON document.
+func jsonPath(keys ...string) string {
+	return strings.Join(keys, "\x00")
+}
+
+// jsonContainer is an object or an array found while scanning a JSON document.
+type jsonContainer struct {
+	object    bool
+	key       string
+	expectKey bool
+}
+
+// containerPath returns the keys of the given containers, it is false if any of them is an array.
+func containerPath(stack []*jsonContainer) ([]string, bool) {
+	keys := make([]string, 0, len(stack))
+	for _, container := range stack {
+		if !container.object {
+			return nil, false
+		}
+		keys = append(keys, container.key)
+	}
+	return keys, true
+}
+
+// keyStart returns the offset of the opening quote of the JSON string ending at the given offset.
+func keyStart(input []byte, end int) int {
+	for i := end - 2; i >= 0; i-- {
+		if input[i] != '"' {
+			continue
+		}
+
+		backslashes := 0
+		for j := i - 1; j >= 0 && input[j] == '\\'; j-- {
+			backslashes++
+		}
+		if backslashes%2 == 0 {
+			return i
+		}
+	}
+	return 0
+}
+
+// walkJSON scans a JSON document calling onKey for each key of the objects, with the path of the key
+// and the offset of its opening quote, and onObject for each object, with its path and the offset
+// after its opening brace. The objects inside arrays are skipped.
+func walkJSON(input []byte, onKey, onObject func(path []string, offset int)) error {
+	dec := json.NewDecoder(bytes.NewReader(input))
+
+	stack := []*jsonContainer{}
+	endValue := func() {
+		if len(stack) > 0 && stack[len(stack)-1].object {
+			stack[len(stack)-1].expectKey = true
+		}
+	}
+
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+
+		if len(stack) > 0 {
+			if top := stack[len(stack)-1]; top.object && top.expectKey {
+				if key, ok := tok.(string); ok {
+					top.key = key
+					top.expectKey = false
+					if path, ok := containerPath(stack); ok && onKey != nil {
+						onKey(path, keyStart(input, int(dec.InputOffset())))
+					}
+					continue
+				}
+			}
+		}
+
+		switch tok {
+		case json.Delim('{'):
+			if path, ok := containerPath(stack); ok && onObject != nil {
+				onObject(path, int(dec.InputOffset()))
+			}
+			stack = append(stack, &jsonContainer{object: true, expectKey: true})
+
+		case json.Delim('['):
+			stack = append(stack, &jsonContainer{object: false})
+
+		case json.Delim('}'), json.Delim(']'):
+			stack = stack[:len(stack)-1]
+			endValue()
+
+		default:
+			endValue()
+		}
+	}
+}
+
+// InsertJSONMessageIDs adds the id field to the given messages of a JSON model, leaving the rest of the input untouched.
+// The id is inserted as the first field of the message, with the indentation of the following field.
+func InsertJSONMessageIDs(input []byte, ids map[string]uint32) ([]byte, error) {
+	fields := make(map[string]string, len(ids))
+	for msgName, id := range ids {
+		fields[jsonPath("messages", msgName)] = fmt.Sprintf("\"id\": %d", id)
+	}
+	return insertJSONFields(input, fields)
+}
+
+// InsertJSONStartBits adds the start_bit field to the signals of a JSON model placed by the layout,
+// leaving the rest of the input untouched, so the layout can be frozen.
+func InsertJSONStartBits(input []byte, canModel *CanModel) ([]byte, error) {
+	fields := make(map[string]string, len(canModel.placedSignals))
+	for path, startBit := range canModel.placedSignals {
+		fields[path] = fmt.Sprintf("\"start_bit\": %d", startBit)
+	}
+	return insertJSONFields(input, fields)
+}
+
+// insertJSONFields adds a field to the objects of a JSON document, by path of the object.
+// The field is inserted as the first one of the object, with the indentation of the following field.
+func insertJSONFields(input []byte, fields map[string]string) ([]byte, error) {
+	// offsets after the opening braces of the objects to update
+	offsets := make(map[int]string)
+
+	err := walkJSON(input, nil, func(keys []string, offset int) {
+		if path := jsonPath(keys...); len(fields[path]) > 0 {
+			offsets[offset] = path
+		}
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if len(offsets) != len(fields) {
+		return nil, fmt.Errorf("cannot find all the objects to update in the JSON model")
+	}
+
+	sorted := make([]int, 0, len(offsets))
+	for offset := range offsets {
+		sorted = append(sorted, offset)
+	}
+	sort.Ints(sorted)
+
+	var buf bytes.Buffer
+	prev := 0
+	for _, offset := range sorted {
+		buf.Write(input[prev:offset])
+
+		// the white space before the next field is used as indentation
+		rest := input[offset:]
+		indent := rest[:len(rest)-len(bytes.TrimLeft(rest, " \t\r\n"))]
+		buf.Write(indent)
+
+		buf.WriteString(fields[offsets[offset]])
+		if next := rest[len(indent):]; len(next) > 0 && next[0] != '}' {
+			buf.WriteString(",")
+			// on a single line, the id is followed by the existing white space only if it is a new line
+			if !bytes.ContainsRune(indent, '\n') {
+				buf.WriteString(" ")
+				offset += len(indent)
+			}
+		}
+
+		prev = offset
+	}
+	buf.Write(input[prev:])
+
+	return buf.Bytes(), nil
+}
+
+// jsonLines converts the offsets of a JSON document into positions.
+type jsonLines struct {
+	input []byte
+	// starts are the offsets of the beginning of the lines
+	starts []int
+}
+
+func newJSONLines(input []byte) *jsonLines {
+	starts := []int{0}
+	for i, b := range input {
+		if b == '\n' {
+			starts = append(starts, i+1)
+		}
+	}
+	return &jsonLines{input: input, starts: starts}
+}
+
+// position returns the line and the column (in characters) of the given offset.
+func (l *jsonLines) position(fileName string, offset int) Position {
+	line := sort.Search(len(l.starts), func(i int) bool { return l.starts[i] > offset }) - 1
+	return Position{
+		File:   fileName,
+		Line:   line + 1,
+		Column: utf8.RuneCount(l.input[l.starts[line]:offset]) + 1,
+	}
+}
+
+// readPositions returns the positions of the keys of the JSON model, by path of the element of the model.
+func (r *JsonReader) readPositions(input []byte) (map[string]Position, error) {
+	lines := newJSONLines(input)
+	positions := make(map[string]Position)
+
+	err := walkJSON(input, func(keys []string, offset int) {
+		positions[strings.Join(keys, ".")] = lines.position(r.fileName, offset)
+	}, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	return positions, nil
+}
@@ -0,0 +1,147 @@
+package pkg
+
+import (
+	"encoding/json"
+	"reflect"
+	"sort"
+	"strings"
+)
+
+// JsonField is a field of a struct of the model with its JSON name.
+type JsonField struct {
+	Name      string
+	Type      reflect.Type
+	OmitEmpty bool
+}
+
+// JsonFields returns the fields of a struct with their JSON names in order, including the ones of the embedded structs.
+// It is shared by the strict reader and the JSON Schema, so that they accept the same fields.
+func JsonFields(t reflect.Type) []*JsonField {
+	result := []*JsonField{}
+	for i := 0; i < t.NumField(); i++ {
+		f := t.Field(i)
+
+		name, opts, _ := strings.Cut(f.Tag.Get("json"), ",")
+		if name == "-" {
+			continue
+		}
+
+		if f.Anonymous && len(name) == 0 {
+			embedded := f.Type
+			if embedded.Kind() == reflect.Pointer {
+				embedded = embedded.Elem()
+			}
+			if embedded.Kind() == reflect.Struct {
+				result = append(result, JsonFields(embedded)...)
+				continue
+			}
+		}
+
+		if !f.IsExported() {
+			continue
+		}
+		if len(name) == 0 {
+			name = f.Name
+		}
+		result = append(result, &JsonField{Name: name, Type: f.Type, OmitEmpty: strings.Contains(opts, "omitempty")})
+	}
+	return result
+}
+
+// levenshtein returns the edit distance between two strings.
+func levenshtein(a, b string) int {
+	prev := make([]int, len(b)+1)
+	curr := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev, curr = curr, prev
+	}
+
+	return prev[len(b)]
+}
+
+// closestField returns the valid field closest to the unknown key, if it is close enough to be a typo.
+func closestField(key string, fields map[string]reflect.Type) (string, bool) {
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	best, bestDist := "", -1
+	for _, name := range names {
+		dist := levenshtein(strings.ToLower(key), strings.ToLower(name))
+		if bestDist < 0 || dist < bestDist {
+			best, bestDist = name, dist
+		}
+	}
+
+	if bestDist < 0 || bestDist > max(2, len(key)/3) {
+		return "", false
+	}
+	return best, true
+}
+
+// checkUnknownFields reports the keys of the JSON value that are not fields of the given type,
+// suggesting the closest valid field.
+func (r *JsonReader) checkUnknownFields(raw json.RawMessage, t reflect.Type, loc location, canModel *CanModel, diags *Diagnostics) error {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch t.Kind() {
+	case reflect.Struct:
+		keys, values, err := jsonObjectFields(raw)
+		if err != nil {
+			return err
+		}
+
+		fields := make(map[string]reflect.Type)
+		for _, f := range JsonFields(t) {
+			fields[f.Name] = f.Type
+		}
+		for _, key := range keys {
+			keyLoc := loc.child(key)
+			keyLoc.position = canModel.positionOf(keyLoc.path)
+
+			fieldType, ok := fields[key]
+			if !ok {
+				if name, ok := closestField(key, fields); ok {
+					diags.errorf(keyLoc, "unknown field [%s], did you mean [%s]?", key, name)
+				} else {
+					diags.errorf(keyLoc, "unknown field [%s]", key)
+				}
+				continue
+			}
+
+			if err := r.checkUnknownFields(values[key], fieldType, keyLoc, canModel, diags); err != nil {
+				return err
+			}
+		}
+
+	case reflect.Map:
+		keys, values, err := jsonObjectFields(raw)
+		if err != nil {
+			return err
+		}
+
+		for _, key := range keys {
+			if err := r.checkUnknownFields(values[key], t.Elem(), loc.child(key), canModel, diags); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
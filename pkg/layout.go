@@ -0,0 +1,236 @@
+package pkg
+
+import (
+	"fmt"
+	"sort"
+)
+
+// Layout policies of the signals without a start bit.
+const (
+	// LayoutTight packs the signals one after the other
+	LayoutTight = "tight"
+	// LayoutNibble starts each signal at the beginning of a nibble
+	LayoutNibble = "nibble"
+	// LayoutByte starts each signal at the beginning of a byte
+	LayoutByte = "byte"
+)
+
+// layoutAlignment returns the alignment in bits of the given layout policy, tight if empty.
+func layoutAlignment(policy string) (uint32, error) {
+	switch policy {
+	case "", LayoutTight:
+		return 1, nil
+	case LayoutNibble:
+		return 4, nil
+	case LayoutByte:
+		return 8, nil
+	}
+	return 0, fmt.Errorf("invalid layout %q, expected %s, %s or %s", policy, LayoutTight, LayoutNibble, LayoutByte)
+}
+
+// layoutSignal is a signal of the JSON model, in declaration order.
+type layoutSignal struct {
+	name string
+	// path is the path of the signal object in the JSON model
+	path        []string
+	hasStartBit bool
+	muxGroup    []*layoutSignal
+}
+
+// bitmap contains the used bits of a message payload.
+type bitmap []bool
+
+func (b bitmap) clone() bitmap {
+	return append(bitmap{}, b...)
+}
+
+func (b bitmap) merge(other bitmap) {
+	for i, used := range other {
+		b[i] = b[i] || used
+	}
+}
+
+// fits returns true if all the positions are inside the payload and not used.
+func (b bitmap) fits(positions []uint32) bool {
+	for _, pos := range positions {
+		if pos >= uint32(len(b)) || b[pos] {
+			return false
+		}
+	}
+	return true
+}
+
+func (b bitmap) reserve(positions []uint32) {
+	for _, pos := range positions {
+		if pos < uint32(len(b)) {
+			b[pos] = true
+		}
+	}
+}
+
+// signalBitPositions returns the payload bit positions of a signal. For little endian signals the start bit is the LSB,
+// for big endian signals it is the MSB following the DBC (Motorola) sawtooth bit numbering.
+func signalBitPositions(startBit, size uint32, isBigEndian bool) []uint32 {
+	positions := make([]uint32, size)
+	pos := startBit
+	for i := range positions {
+		positions[i] = pos
+		switch {
+		case !isBigEndian:
+			pos++
+		case pos%8 == 0:
+			pos += 15
+		default:
+			pos--
+		}
+	}
+	return positions
+}
+
+// fitsPayload returns true if a signal is at most 64 bits and inside a payload of the given bits.
+// The bit positions of the other signals are not computed, they are reported by the validation.
+func fitsPayload(startBit, size uint32, isBigEndian bool, bits uint32) bool {
+	if size > maxSignalSize {
+		return false
+	}
+	if isBigEndian {
+		return uint64(bigEndianPosition(startBit))+uint64(size) <= uint64(bits)
+	}
+	return uint64(startBit)+uint64(size) <= uint64(bits)
+}
+
+// bigEndianPosition returns the position of the MSB of a big endian signal with the given start bit,
+// counting the payload bits from the MSB of the first byte.
+func bigEndianPosition(startBit uint32) uint32 {
+	return startBit/8*8 + 7 - startBit%8
+}
+
+// bigEndianStartBit returns the start bit of a big endian signal whose MSB is at the given position,
+// counting the payload bits from the MSB of the first byte.
+func bigEndianStartBit(pos uint32) uint32 {
+	// the conversion is symmetric
+	return bigEndianPosition(pos)
+}
+
+// messageLayout places the signals of a message without a start bit.
+type messageLayout struct {
+	msgName   string
+	alignment uint32
+	bits      uint32
+	// placed are the start bits of the placed signals by JSON path
+	placed map[string]uint32
+}
+
+// reserveFixed reserves the bits of the signals with a start bit, in every mux group.
+func (ml *messageLayout) reserveFixed(infos []*layoutSignal, signals map[string]*Signal, used bitmap) {
+	for _, info := range infos {
+		sig, ok := signals[info.name]
+		if !ok || sig == nil {
+			continue
+		}
+		isBigEndian := sig.Endianness == "big"
+		if info.hasStartBit && fitsPayload(sig.StartBit, sig.Size, isBigEndian, ml.bits) {
+			used.reserve(signalBitPositions(sig.StartBit, sig.Size, isBigEndian))
+		}
+		ml.reserveFixed(info.muxGroup, sig.MuxGroup, used)
+	}
+}
+
+// place assigns the start bit of each signal without one at the first free aligned position,
+// in declaration order. The signals of each mux switch value are placed after the bits used so far,
+// then the bits of all the mux group are reserved.
+func (ml *messageLayout) place(infos []*layoutSignal, signals map[string]*Signal, used bitmap) error {
+	for _, info := range infos {
+		sig, ok := signals[info.name]
+		if !ok || sig == nil {
+			continue
+		}
+
+		// a signal larger than 64 bits is not placed, it is reported by the validation
+		if !info.hasStartBit && sig.Size > 0 && sig.Size <= maxSignalSize {
+			if err := ml.placeSignal(info, sig, used); err != nil {
+				return err
+			}
+		}
+
+		if len(info.muxGroup) == 0 {
+			continue
+		}
+
+		branches := make(map[uint32][]*layoutSignal)
+		for _, muxedInfo := range info.muxGroup {
+			if muxedSig, ok := sig.MuxGroup[muxedInfo.name]; ok && muxedSig != nil {
+				branches[muxedSig.MuxSwitch] = append(branches[muxedSig.MuxSwitch], muxedInfo)
+			}
+		}
+		switchValues := make([]uint32, 0, len(branches))
+		for switchValue := range branches {
+			switchValues = append(switchValues, switchValue)
+		}
+		sort.Slice(switchValues, func(i, j int) bool { return switchValues[i] < switchValues[j] })
+
+		groupUsed := used.clone()
+		for _, switchValue := range switchValues {
+			branchUsed := used.clone()
+			if err := ml.place(branches[switchValue], sig.MuxGroup, branchUsed); err != nil {
+				return err
+			}
+			groupUsed.merge(branchUsed)
+		}
+		used.merge(groupUsed)
+	}
+
+	return nil
+}
+
+func (ml *messageLayout) placeSignal(info *layoutSignal, sig *Signal, used bitmap) error {
+	isBigEndian := sig.Endianness == "big"
+
+	for pos := uint32(0); pos+sig.Size <= ml.bits; pos += ml.alignment {
+		startBit := pos
+		if isBigEndian {
+			startBit = bigEndianStartBit(pos)
+		}
+
+		positions := signalBitPositions(startBit, sig.Size, isBigEndian)
+		if !used.fits(positions) {
+			continue
+		}
+
+		used.reserve(positions)
+		sig.StartBit = startBit
+		ml.placed[jsonPath(info.path...)] = startBit
+		return nil
+	}
+
+	return fmt.Errorf("message [%s] has no room for signal [%s] of size %d", ml.msgName, info.name, sig.Size)
+}
+
+// layoutSignals assigns the start bits of the signals of the message that omit them.
+func (c *CanModel) layoutSignals(msgName string, msg *Message, infos []*layoutSignal) error {
+	policy := msg.Layout
+	if len(policy) == 0 {
+		policy = c.Layout
+	}
+	alignment, err := layoutAlignment(policy)
+	if err != nil {
+		return fmt.Errorf("message [%s]: %w", msgName, err)
+	}
+
+	ml := &messageLayout{
+		msgName:   msgName,
+		alignment: alignment,
+		bits:      msg.Length * 8,
+		placed:    c.placedSignals,
+	}
+
+	used := make(bitmap, ml.bits)
+	ml.reserveFixed(infos, msg.Signals, used)
+
+	return ml.place(infos, msg.Signals, used)
+}
+
+// PlacedSignals returns the number of signals whose start bit has been assigned by the layout.
+func (c *CanModel) PlacedSignals() int {
+	return len(c.placedSignals)
+}
@@ -0,0 +1,225 @@
+// Package mdf contains a writer of ASAM MDF 4 measurement files (.mf4).
+package mdf
+
+import (
+	"encoding/binary"
+	"fmt"
+	"math"
+	"strings"
+)
+
+const (
+	idBlockSize     = 64
+	blockHeaderSize = 24
+
+	mdfVersion       = "4.10"
+	mdfVersionNumber = 410
+	programID        = "jsondbc"
+)
+
+// Channel types, data types and flags of the CN block.
+const (
+	cnTypeFixedLength = 0
+	cnTypeMaster      = 2
+
+	cnSyncNone = 0
+	cnSyncTime = 1
+
+	cnDataUintLE  = 0
+	cnDataIntLE   = 2
+	cnDataFloatLE = 4
+
+	cnFlagInvalBitValid  = 0x02
+	cnFlagLimitRangeOkay = 0x10
+)
+
+// Conversion types of the CC block.
+const (
+	ccTypeLinear    = 1
+	ccTypeValueText = 7
+)
+
+// block represents an MDF block with its links and data.
+type block struct {
+	id    string
+	links []int64
+	data  []byte
+}
+
+func newBlock(id string, linkCount int, dataSize int) *block {
+	return &block{
+		id:    id,
+		links: make([]int64, linkCount),
+		data:  make([]byte, dataSize),
+	}
+}
+
+// newTextBlock returns a TX block containing the zero terminated text.
+func newTextBlock(text string) *block {
+	data := []byte(text)
+	// zero terminated and padded to 8 bytes
+	data = append(data, make([]byte, 8-len(data)%8)...)
+	return &block{id: "TX", data: data}
+}
+
+// newFileHistoryComment returns the MD block of the file history.
+func newFileHistoryComment(text string) *block {
+	xml := fmt.Sprintf("<FHcomment><TX>%s</TX><tool_id>%s</tool_id><tool_vendor></tool_vendor><tool_version></tool_version></FHcomment>", escapeXML(text), programID)
+	b := newTextBlock(xml)
+	b.id = "MD"
+	return b
+}
+
+func escapeXML(str string) string {
+	return strings.NewReplacer("&", "&amp;", "<", "&lt;", ">", "&gt;").Replace(str)
+}
+
+// bytes returns the encoded block, padded to 8 bytes.
+func (b *block) bytes() []byte {
+	length := blockHeaderSize + 8*len(b.links) + len(b.data)
+
+	buf := make([]byte, length, length+7)
+	copy(buf, "##"+b.id)
+	binary.LittleEndian.PutUint64(buf[8:], uint64(length))
+	binary.LittleEndian.PutUint64(buf[16:], uint64(len(b.links)))
+	for i, link := range b.links {
+		binary.LittleEndian.PutUint64(buf[blockHeaderSize+8*i:], uint64(link))
+	}
+	copy(buf[blockHeaderSize+8*len(b.links):], b.data)
+
+	if pad := length % 8; pad > 0 {
+		buf = append(buf, make([]byte, 8-pad)...)
+	}
+	return buf
+}
+
+// putFloat writes a REAL value.
+func putFloat(b []byte, val float64) {
+	binary.LittleEndian.PutUint64(b, math.Float64bits(val))
+}
+
+// idBlock returns the identification block at the start of the file.
+func idBlock() []byte {
+	buf := make([]byte, idBlockSize)
+	copy(buf, fmt.Sprintf("%-8s%-8s%-8s", "MDF", mdfVersion, programID))
+	binary.LittleEndian.PutUint16(buf[28:], mdfVersionNumber)
+	return buf
+}
+
+// hdBlock returns the header block linking the first data group and the file history.
+func hdBlock(startNs int64, dgFirst, fhFirst int64) *block {
+	hd := newBlock("HD", 6, 32)
+	// dg_first, fh_first, ch_first, at_first, ev_first, md_comment
+	hd.links[0] = dgFirst
+	hd.links[1] = fhFirst
+	binary.LittleEndian.PutUint64(hd.data, uint64(startNs))
+	return hd
+}
+
+// fhBlock returns a file history block created at the given time.
+func fhBlock(timeNs int64, mdComment int64) *block {
+	fh := newBlock("FH", 2, 16)
+	// fh_next, md_comment
+	fh.links[1] = mdComment
+	binary.LittleEndian.PutUint64(fh.data, uint64(timeNs))
+	return fh
+}
+
+// dgBlock returns a data group block without record ids.
+func dgBlock(dgNext, cgFirst, data int64) *block {
+	dg := newBlock("DG", 4, 8)
+	// dg_next, cg_first, data, md_comment
+	dg.links[0] = dgNext
+	dg.links[1] = cgFirst
+	dg.links[2] = data
+	return dg
+}
+
+// cgBlock returns a channel group block.
+func cgBlock(cnFirst, acqName, mdComment int64, cycleCount uint64, dataBytes, invalBytes uint32) *block {
+	cg := newBlock("CG", 6, 32)
+	// cg_next, cn_first, acq_name, acq_source, sr_first, md_comment
+	cg.links[1] = cnFirst
+	cg.links[2] = acqName
+	cg.links[5] = mdComment
+	binary.LittleEndian.PutUint64(cg.data[8:], cycleCount)
+	binary.LittleEndian.PutUint32(cg.data[24:], dataBytes)
+	binary.LittleEndian.PutUint32(cg.data[28:], invalBytes)
+	return cg
+}
+
+// dlBlock returns a data list block of the given data blocks.
+func dlBlock(dataBlocks []int64, offsets []uint64) *block {
+	dl := newBlock("DL", 1+len(dataBlocks), 8+8*len(offsets))
+	// dl_next, data blocks
+	copy(dl.links[1:], dataBlocks)
+	binary.LittleEndian.PutUint32(dl.data[4:], uint32(len(dataBlocks)))
+	for i, offset := range offsets {
+		binary.LittleEndian.PutUint64(dl.data[8+8*i:], offset)
+	}
+	return dl
+}
+
+// channel describes a channel of a record.
+type channel struct {
+	name       string
+	unit       string
+	comment    string
+	channelTyp uint8
+	syncType   uint8
+	dataType   uint8
+	byteOffset uint32
+	bitCount   uint32
+	flags      uint32
+	invalBit   uint32
+	limitMin   float64
+	limitMax   float64
+}
+
+// cnBlock returns the channel block of the given channel.
+func (c *channel) cnBlock(cnNext, txName, cc, mdUnit, mdComment int64) *block {
+	cn := newBlock("CN", 8, 72)
+	// cn_next, composition, tx_name, si_source, cc_conversion, data, md_unit, md_comment
+	cn.links[0] = cnNext
+	cn.links[2] = txName
+	cn.links[4] = cc
+	cn.links[6] = mdUnit
+	cn.links[7] = mdComment
+
+	cn.data[0] = c.channelTyp
+	cn.data[1] = c.syncType
+	cn.data[2] = c.dataType
+	binary.LittleEndian.PutUint32(cn.data[4:], c.byteOffset)
+	binary.LittleEndian.PutUint32(cn.data[8:], c.bitCount)
+	binary.LittleEndian.PutUint32(cn.data[12:], c.flags)
+	binary.LittleEndian.PutUint32(cn.data[16:], c.invalBit)
+	// val_range_min, val_range_max, limit_min, limit_max, limit_ext_min, limit_ext_max
+	putFloat(cn.data[40:], c.limitMin)
+	putFloat(cn.data[48:], c.limitMax)
+	return cn
+}
+
+// linearCCBlock returns a linear conversion block (phys = factor * raw + offset).
+func linearCCBlock(factor, offset float64) *block {
+	cc := newBlock("CC", 4, 24+2*8)
+	// tx_name, md_unit, md_comment, cc_inverse
+	cc.data[0] = ccTypeLinear
+	binary.LittleEndian.PutUint16(cc.data[6:], 2)
+	putFloat(cc.data[24:], offset)
+	putFloat(cc.data[32:], factor)
+	return cc
+}
+
+// valueTextCCBlock returns a value to text conversion block,
+// the texts are the TX blocks of the keys and the last one is the default.
+func valueTextCCBlock(keys []float64, texts []int64) *block {
+	cc := newBlock("CC", 4+len(texts), 24+8*len(keys))
+	copy(cc.links[4:], texts)
+	cc.data[0] = ccTypeValueText
+	binary.LittleEndian.PutUint16(cc.data[4:], uint16(len(texts)))
+	binary.LittleEndian.PutUint16(cc.data[6:], uint16(len(keys)))
+	for i, key := range keys {
+		putFloat(cc.data[24+8*i:], key)
+	}
+	return cc
+}
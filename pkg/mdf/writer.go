@@ -0,0 +1,440 @@
+package mdf
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"sort"
+	"time"
+
+	"github.com/squadracorsepolito/jsondbc/pkg/codec"
+)
+
+// maxDataBlockSize is the size after which the records of a channel group are written into a DT block.
+const maxDataBlockSize = 64 * 1024
+
+// group represents the channel group of a message, stored in its own data group.
+// The record contains the time master channel followed by the raw value of each signal,
+// multiplexed signals are flagged as invalid when they are not selected.
+type group struct {
+	msg      *codec.Message
+	signals  []*codec.Signal
+	channels []*channel
+	// indexes maps the signals to their channel index
+	indexes map[*codec.Signal]int
+
+	dataBytes  uint32
+	invalBytes uint32
+	cycleCount uint64
+	records    bytes.Buffer
+
+	dataBlocks []int64
+	dataSizes  []uint64
+}
+
+func newGroup(msg *codec.Message) *group {
+	g := &group{
+		msg:     msg,
+		indexes: make(map[*codec.Signal]int),
+	}
+
+	var flatten func(signals []*codec.Signal)
+	flatten = func(signals []*codec.Signal) {
+		for _, sig := range signals {
+			g.signals = append(g.signals, sig)
+			flatten(sig.MuxGroup)
+		}
+	}
+	flatten(msg.Signals)
+
+	g.channels = append(g.channels, &channel{
+		name:       "time",
+		unit:       "s",
+		channelTyp: cnTypeMaster,
+		syncType:   cnSyncTime,
+		dataType:   cnDataFloatLE,
+		bitCount:   64,
+	})
+	g.dataBytes = 8
+
+	invalCount := uint32(0)
+	for _, sig := range g.signals {
+		ch := &channel{
+			name:       sig.Name,
+			unit:       sig.Model.Unit,
+			comment:    sig.Model.Description,
+			channelTyp: cnTypeFixedLength,
+			syncType:   cnSyncNone,
+			dataType:   cnDataUintLE,
+			byteOffset: g.dataBytes,
+			bitCount:   sig.Model.Size,
+		}
+		if sig.Model.Signed {
+			ch.dataType = cnDataIntLE
+		}
+		if sig.IsMultiplexed() {
+			ch.flags |= cnFlagInvalBitValid
+			ch.invalBit = invalCount
+			invalCount++
+		}
+		if sig.Model.Min != 0 || sig.Model.Max != 0 {
+			ch.flags |= cnFlagLimitRangeOkay
+			ch.limitMin = sig.Model.Min
+			ch.limitMax = sig.Model.Max
+		}
+
+		g.indexes[sig] = len(g.channels)
+		g.channels = append(g.channels, ch)
+		g.dataBytes += slotSize(sig.Model.Size)
+	}
+	g.invalBytes = (invalCount + 7) / 8
+
+	return g
+}
+
+// slotSize returns the bytes used to store a raw value of the given size.
+func slotSize(size uint32) uint32 {
+	switch {
+	case size <= 8:
+		return 1
+	case size <= 16:
+		return 2
+	case size <= 32:
+		return 4
+	}
+	return 8
+}
+
+// addRecord appends the record of the decoded values.
+func (g *group) addRecord(seconds float64, values []*codec.SignalValue) {
+	record := make([]byte, g.dataBytes+g.invalBytes)
+	putFloat(record, seconds)
+
+	// all the multiplexed signals are invalid until they are found in the values
+	for _, ch := range g.channels {
+		if ch.flags&cnFlagInvalBitValid != 0 {
+			record[g.dataBytes+ch.invalBit/8] |= 1 << (ch.invalBit % 8)
+		}
+	}
+
+	raw := make([]byte, 8)
+	for _, val := range values {
+		idx, ok := g.indexes[val.Signal]
+		if !ok {
+			continue
+		}
+		ch := g.channels[idx]
+
+		if val.Signal.Model.Signed {
+			binary.LittleEndian.PutUint64(raw, uint64(val.Signal.Signed(val.Raw)))
+		} else {
+			binary.LittleEndian.PutUint64(raw, val.Raw)
+		}
+		copy(record[ch.byteOffset:ch.byteOffset+slotSize(ch.bitCount)], raw)
+
+		if ch.flags&cnFlagInvalBitValid != 0 {
+			record[g.dataBytes+ch.invalBit/8] &^= 1 << (ch.invalBit % 8)
+		}
+	}
+
+	g.records.Write(record)
+	g.cycleCount++
+}
+
+// Writer writes the decoded signals into an MDF 4.1 file, with a channel group for each message.
+// The signals are stored as raw values with the conversions of the model:
+// a linear conversion from scale and offset, or a value to text conversion from the enum.
+// The file header is rewritten on Close, so the underlying writer must be seekable.
+type Writer struct {
+	w io.WriteSeeker
+
+	groups         []*group
+	groupsByMsg    map[*codec.Message]*group
+	started        bool
+	start          time.Time
+	offset         int64
+	textBlockCache map[string]int64
+}
+
+// NewWriter returns a new MDF writer for the messages of the codec.
+func NewWriter(w io.WriteSeeker, c *codec.Codec) *Writer {
+	mw := &Writer{
+		w: w,
+
+		groups:         []*group{},
+		groupsByMsg:    make(map[*codec.Message]*group),
+		started:        false,
+		offset:         0,
+		textBlockCache: make(map[string]int64),
+	}
+
+	for _, msg := range c.Messages() {
+		g := newGroup(msg)
+		mw.groups = append(mw.groups, g)
+		mw.groupsByMsg[msg] = g
+	}
+
+	return mw
+}
+
+func (w *Writer) write(data []byte) error {
+	n, err := w.w.Write(data)
+	w.offset += int64(n)
+	return err
+}
+
+// writeBlock writes the block and returns its position in the file.
+func (w *Writer) writeBlock(b *block) (int64, error) {
+	pos := w.offset
+	if err := w.write(b.bytes()); err != nil {
+		return 0, err
+	}
+	return pos, nil
+}
+
+// writeText writes a TX block, the same texts share the same block.
+// It returns a nil link for empty texts.
+func (w *Writer) writeText(text string) (int64, error) {
+	if len(text) == 0 {
+		return 0, nil
+	}
+	if pos, ok := w.textBlockCache[text]; ok {
+		return pos, nil
+	}
+
+	pos, err := w.writeBlock(newTextBlock(text))
+	if err != nil {
+		return 0, err
+	}
+	w.textBlockCache[text] = pos
+	return pos, nil
+}
+
+func (w *Writer) begin(start time.Time) error {
+	w.started = true
+	w.start = start
+
+	if err := w.write(idBlock()); err != nil {
+		return err
+	}
+	// the header is completed on close with the links to the data groups
+	_, err := w.writeBlock(hdBlock(start.UnixNano(), 0, 0))
+	return err
+}
+
+// Write writes the decoded values of a message received at the given time.
+// The time channels are relative to the timestamp of the first message.
+func (w *Writer) Write(timestamp time.Time, msg *codec.Message, values []*codec.SignalValue) error {
+	g, ok := w.groupsByMsg[msg]
+	if !ok {
+		return fmt.Errorf("message [%s] is not part of the codec", msg.Name)
+	}
+
+	if !w.started {
+		if err := w.begin(timestamp); err != nil {
+			return err
+		}
+	}
+
+	g.addRecord(timestamp.Sub(w.start).Seconds(), values)
+
+	if g.records.Len() >= maxDataBlockSize {
+		return w.flushRecords(g)
+	}
+	return nil
+}
+
+// flushRecords writes the pending records of the group into a DT block.
+func (w *Writer) flushRecords(g *group) error {
+	if g.records.Len() == 0 {
+		return nil
+	}
+
+	pos, err := w.writeBlock(&block{id: "DT", data: g.records.Bytes()})
+	if err != nil {
+		return err
+	}
+
+	g.dataBlocks = append(g.dataBlocks, pos)
+	g.dataSizes = append(g.dataSizes, uint64(g.records.Len()))
+	g.records.Reset()
+
+	return nil
+}
+
+// writeConversion writes the conversion of the signal,
+// it returns a nil link if the raw value is the physical one.
+func (w *Writer) writeConversion(sig *codec.Signal) (int64, error) {
+	scale := sig.Model.Scale
+	if scale == 0 {
+		scale = 1
+	}
+
+	if len(sig.Model.Enum) == 0 {
+		if scale == 1 && sig.Model.Offset == 0 {
+			return 0, nil
+		}
+		return w.writeBlock(linearCCBlock(scale, sig.Model.Offset))
+	}
+
+	rawValues := []uint64{}
+	found := make(map[uint64]bool)
+	for _, value := range sig.Model.Enum {
+		if !found[uint64(value)] {
+			found[uint64(value)] = true
+			rawValues = append(rawValues, uint64(value))
+		}
+	}
+
+	// the signed channels are stored sign-extended, so the keys of their labels are the signed raw values
+	key := func(raw uint64) float64 {
+		if sig.Model.Signed {
+			return float64(sig.Signed(raw))
+		}
+		return float64(raw)
+	}
+	sort.Slice(rawValues, func(i, j int) bool {
+		return key(rawValues[i]) < key(rawValues[j])
+	})
+
+	keys := make([]float64, 0, len(rawValues))
+	texts := make([]int64, 0, len(rawValues)+1)
+	for _, raw := range rawValues {
+		text, err := w.writeText(sig.Label(raw))
+		if err != nil {
+			return 0, err
+		}
+		keys = append(keys, key(raw))
+		texts = append(texts, text)
+	}
+
+	// the values without a label are converted with scale and offset
+	defaultConversion := int64(0)
+	if scale != 1 || sig.Model.Offset != 0 {
+		var err error
+		defaultConversion, err = w.writeBlock(linearCCBlock(scale, sig.Model.Offset))
+		if err != nil {
+			return 0, err
+		}
+	}
+	texts = append(texts, defaultConversion)
+
+	return w.writeBlock(valueTextCCBlock(keys, texts))
+}
+
+// writeGroup writes the blocks describing the group and returns the position of its data group.
+func (w *Writer) writeGroup(g *group, dgNext int64) (int64, error) {
+	if err := w.flushRecords(g); err != nil {
+		return 0, err
+	}
+
+	data := int64(0)
+	switch len(g.dataBlocks) {
+	case 0:
+	case 1:
+		data = g.dataBlocks[0]
+	default:
+		offsets := make([]uint64, len(g.dataSizes))
+		for i := 1; i < len(offsets); i++ {
+			offsets[i] = offsets[i-1] + g.dataSizes[i-1]
+		}
+		pos, err := w.writeBlock(dlBlock(g.dataBlocks, offsets))
+		if err != nil {
+			return 0, err
+		}
+		data = pos
+	}
+
+	// the channels are linked from the last one
+	cnNext := int64(0)
+	for i := len(g.channels) - 1; i >= 0; i-- {
+		ch := g.channels[i]
+
+		cc := int64(0)
+		if i > 0 {
+			var err error
+			cc, err = w.writeConversion(g.signals[i-1])
+			if err != nil {
+				return 0, err
+			}
+		}
+
+		txName, err := w.writeText(ch.name)
+		if err != nil {
+			return 0, err
+		}
+		mdUnit, err := w.writeText(ch.unit)
+		if err != nil {
+			return 0, err
+		}
+		mdComment, err := w.writeText(ch.comment)
+		if err != nil {
+			return 0, err
+		}
+
+		cnNext, err = w.writeBlock(ch.cnBlock(cnNext, txName, cc, mdUnit, mdComment))
+		if err != nil {
+			return 0, err
+		}
+	}
+
+	acqName, err := w.writeText(g.msg.Name)
+	if err != nil {
+		return 0, err
+	}
+	mdComment, err := w.writeText(g.msg.Model.Description)
+	if err != nil {
+		return 0, err
+	}
+
+	cg, err := w.writeBlock(cgBlock(cnNext, acqName, mdComment, g.cycleCount, g.dataBytes, g.invalBytes))
+	if err != nil {
+		return 0, err
+	}
+
+	return w.writeBlock(dgBlock(dgNext, cg, data))
+}
+
+// Close writes the channel groups of the received messages and completes the file header,
+// the underlying writer is not closed.
+func (w *Writer) Close() error {
+	if !w.started {
+		if err := w.begin(time.Now()); err != nil {
+			return err
+		}
+	}
+
+	// the data groups are linked from the last one
+	dgFirst := int64(0)
+	for i := len(w.groups) - 1; i >= 0; i-- {
+		g := w.groups[i]
+		if g.cycleCount == 0 {
+			continue
+		}
+
+		pos, err := w.writeGroup(g, dgFirst)
+		if err != nil {
+			return err
+		}
+		dgFirst = pos
+	}
+
+	mdComment, err := w.writeBlock(newFileHistoryComment("created by jsondbc"))
+	if err != nil {
+		return err
+	}
+	fhFirst, err := w.writeBlock(fhBlock(time.Now().UnixNano(), mdComment))
+	if err != nil {
+		return err
+	}
+
+	if _, err := w.w.Seek(idBlockSize, io.SeekStart); err != nil {
+		return err
+	}
+	if _, err := w.w.Write(hdBlock(w.start.UnixNano(), dgFirst, fhFirst).bytes()); err != nil {
+		return err
+	}
+	_, err = w.w.Seek(0, io.SeekEnd)
+	return err
+}
@@ -23,14 +23,23 @@ type Message struct {
 	Sender  string             `json:"sender,omitempty"`
 	Signals map[string]*Signal `json:"signals"`
 
+	// Used by the assign-ids command when the id is omitted
+	Priority string `json:"priority,omitempty"`
+	Deadline int    `json:"deadline_ms,omitempty"`
+
+	// Layout is the layout policy of the signals without start bit, it overrides the one of the model
+	Layout string `json:"layout,omitempty"`
+
 	messageName  string
+	loc          location
+	missingID    bool
 	childSignals map[string]*Signal
 	fromDBC      bool
 	source       sourceType
 }
 
-func (m *Message) initSignalRec(sigName string, sig *Signal) {
-	sig.initSignal(sigName, m.source)
+func (m *Message) initSignalRec(sigName string, sig *Signal, muxSig *Signal, diags *Diagnostics) {
+	sig.initSignal(sigName, muxSig.loc.child("mux_group", sigName).path, m.source, diags)
 	m.childSignals[sigName] = sig
 	sig.isMultiplexed = true
 	if !sig.isMultiplexor {
@@ -38,13 +47,14 @@ func (m *Message) initSignalRec(sigName string, sig *Signal) {
 	}
 
 	for muxedSigName, muxedSig := range sig.MuxGroup {
-		m.initSignalRec(muxedSigName, muxedSig)
+		m.initSignalRec(muxedSigName, muxedSig, sig, diags)
 	}
 }
 
-func (m *Message) initMessage(msgName string, source sourceType) {
+func (m *Message) initMessage(msgName string, source sourceType, diags *Diagnostics) {
 	m.messageName = msgName
 	m.source = source
+	m.loc.path = location{path: "messages"}.child(msgName).path
 
 	if m.AttributeAssignments == nil {
 		m.AttributeAssignments = &AttributeAssignments{
@@ -67,19 +77,19 @@ func (m *Message) initMessage(msgName string, source sourceType) {
 		delete(m.AttributeAssignments.Attributes, sym.MsgPeriodAttribute)
 	}
 
-	m.handleCustomAttributes()
+	m.handleCustomAttributes(diags)
 
 	m.childSignals = make(map[string]*Signal)
 
 	for sigName, sig := range m.Signals {
-		sig.initSignal(sigName, m.source)
+		sig.initSignal(sigName, m.loc.child("signals", sigName).path, m.source, diags)
 		m.childSignals[sigName] = sig
 		if !sig.isMultiplexor {
 			continue
 		}
 
 		for muxedSigName, muxedSig := range sig.MuxGroup {
-			m.initSignalRec(muxedSigName, muxedSig)
+			m.initSignalRec(muxedSigName, muxedSig, sig, diags)
 		}
 	}
 
@@ -89,8 +99,7 @@ func (m *Message) appendDescription(format string, a ...any) {
 	m.Description = appendString(m.Description, format, a...)
 }
 
-func (m *Message) handleCustomAttributes() {
-	location := fmt.Sprintf("message '%s'", m.messageName)
+func (m *Message) handleCustomAttributes(diags *Diagnostics) {
 
 	switch m.source {
 	case sourceTypeJSON:
@@ -102,7 +111,7 @@ func (m *Message) handleCustomAttributes() {
 
 		// MsgSendType
 		if len(m.SendType) > 0 {
-			tmpST := checkCustomEnumAttribute(m.SendType, "message.send_type", sym.MsgSendTypeValues, location)
+			tmpST := checkCustomEnumAttribute(m.SendType, "message.send_type", sym.MsgSendTypeValues, m.loc.child("send_type"), diags)
 			m.AttributeAssignments.Attributes[sym.MsgSendType] = tmpST
 			m.appendDescription("(send_type: %s)", tmpST)
 		}
@@ -118,9 +127,21 @@ func (m *Message) handleCustomAttributes() {
 		// MsgSendType
 		stAtt, hasST := m.AttributeAssignments.Attributes[sym.MsgSendType]
 		if hasST {
-			m.SendType = checkCustomEnumAttribute(stAtt.(string), sym.MsgSendType, sym.MsgSendTypeValues, location)
+			m.SendType = checkCustomEnumAttribute(stAtt.(string), sym.MsgSendType, sym.MsgSendTypeValues, m.loc, diags)
 			delete(m.AttributeAssignments.Attributes, sym.MsgSendType)
 		}
+
+		// the notes appended to the description by the JSON model are removed in reverse order,
+		// otherwise they are appended again at every conversion
+		if hasST {
+			m.Description = trimAppendedString(m.Description, "(send_type: %s)", m.SendType)
+		}
+		if hasCT {
+			m.Description = trimAppendedString(m.Description, "(cycle_time: %d)", m.CycleTime)
+		}
+		if m.Period > 0 {
+			m.Description = trimAppendedString(m.Description, "(period: %d ms)", m.Period)
+		}
 	}
 }
 
@@ -129,26 +150,126 @@ func (m *Message) HasDescription() bool {
 	return len(m.Description) > 0
 }
 
+// HasID returns false if the id is omitted in the JSON model, so it has to be assigned.
+func (m *Message) HasID() bool {
+	return !m.missingID
+}
+
+// SetID assigns the id of the message.
+func (m *Message) SetID(id uint32) {
+	m.ID = id
+	m.missingID = false
+}
+
 // FormatID returns the message ID as a string.
 func (m *Message) FormatID() string {
 	return strconv.FormatUint(uint64(m.ID), 10)
 }
 
-func (m *Message) validate() error {
+func (m *Message) validate(diags *Diagnostics) {
 	if m.Length == 0 {
-		return fmt.Errorf("message [%s] length cannot be 0", m.messageName)
+		diags.errorf(m.loc.child("length"), "message [%s] length cannot be 0", m.messageName)
+		return
 	}
 	/*if len(m.childSignals) == 0 {
 		return fmt.Errorf("message [%s] has no signals", m.messageName)
 	}*/
 
-	for _, sig := range m.childSignals {
-		if err := sig.validate(); err != nil {
-			return err
+	signals := muxedSignals(m.Signals, nil, nil)
+	for _, ms := range signals {
+		ms.signal.validate(diags, m.Length)
+	}
+
+	// the signals are compared in order of start bit, so an overlap is reported on the following signal
+	sort.SliceStable(signals, func(i, j int) bool {
+		return signals[i].signal.StartBit < signals[j].signal.StartBit
+	})
+	for i, ms := range signals {
+		for _, prev := range signals[:i] {
+			if ms.isExclusive(prev) {
+				continue
+			}
+			if bit, ok := ms.overlap(prev, m.Length*8); ok {
+				diags.errorf(ms.signal.loc, "signal [%s] overlaps signal [%s] at bit %d", ms.signal.signalName, prev.signal.signalName, bit)
+			}
 		}
 	}
+}
+
+// muxBranch is a value of a multiplexor signal.
+type muxBranch struct {
+	multiplexor *Signal
+	value       uint32
+}
+
+// muxedSignal is a signal with the multiplexor values that make it present in the payload.
+type muxedSignal struct {
+	signal   *Signal
+	branches []muxBranch
+}
 
-	return nil
+// muxedSignals returns the given signals, sorted by name, each followed by the signals of its mux group.
+func muxedSignals(signals map[string]*Signal, multiplexor *Signal, branches []muxBranch) []*muxedSignal {
+	sigNames := make([]string, 0, len(signals))
+	for sigName := range signals {
+		sigNames = append(sigNames, sigName)
+	}
+	sort.Strings(sigNames)
+
+	result := []*muxedSignal{}
+	for _, sigName := range sigNames {
+		sig := signals[sigName]
+		if sig == nil {
+			continue
+		}
+
+		sigBranches := branches
+		if multiplexor != nil {
+			sigBranches = append(append([]muxBranch{}, branches...), muxBranch{multiplexor: multiplexor, value: sig.MuxSwitch})
+		}
+
+		result = append(result, &muxedSignal{signal: sig, branches: sigBranches})
+		result = append(result, muxedSignals(sig.MuxGroup, sig, sigBranches)...)
+	}
+
+	return result
+}
+
+// isExclusive returns true if the signals cannot be in the same payload,
+// because they belong to different values of the same multiplexor.
+func (ms *muxedSignal) isExclusive(other *muxedSignal) bool {
+	for i := 0; i < len(ms.branches) && i < len(other.branches); i++ {
+		if ms.branches[i].multiplexor != other.branches[i].multiplexor {
+			return false
+		}
+		if ms.branches[i].value != other.branches[i].value {
+			return true
+		}
+	}
+	return false
+}
+
+// overlap returns the first payload bit used by both the signals.
+// The signals outside a payload of the given bits never overlap, they are reported by the validation.
+func (ms *muxedSignal) overlap(other *muxedSignal, bits uint32) (uint32, bool) {
+	if !fitsPayload(ms.signal.StartBit, ms.signal.Size, ms.signal.isBigEndian, bits) ||
+		!fitsPayload(other.signal.StartBit, other.signal.Size, other.signal.isBigEndian, bits) {
+		return 0, false
+	}
+
+	used := make(map[uint32]bool)
+	for _, pos := range signalBitPositions(other.signal.StartBit, other.signal.Size, other.signal.isBigEndian) {
+		used[pos] = true
+	}
+
+	positions := signalBitPositions(ms.signal.StartBit, ms.signal.Size, ms.signal.isBigEndian)
+	sort.Slice(positions, func(i, j int) bool { return positions[i] < positions[j] })
+	for _, pos := range positions {
+		if used[pos] {
+			return pos, true
+		}
+	}
+	return 0, false
 }
 
 func (m *Message) getSignals() []*Signal {
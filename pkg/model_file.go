@@ -0,0 +1,57 @@
+package pkg
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+)
+
+const (
+	dbcExt  = ".dbc"
+	jsonExt = ".json"
+)
+
+// NewReaderFromFileName returns the reader matching the extension of the given file.
+func NewReaderFromFileName(fileName string) (Reader, error) {
+	ext := filepath.Ext(fileName)
+	switch ext {
+	case jsonExt:
+		return NewJsonReader(), nil
+	case dbcExt:
+		return NewDBCReader(), nil
+	}
+
+	return nil, fmt.Errorf("%s extension is not supported as model file", ext)
+}
+
+// ReadCanModel reads the CAN model defined in the given .json or .dbc file,
+// then it initializes and validates it. The warnings are logged and the error contains all the errors found.
+func ReadCanModel(fileName string) (*CanModel, error) {
+	reader, err := NewReaderFromFileName(fileName)
+	if err != nil {
+		return nil, err
+	}
+
+	file, err := os.Open(fileName)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	canModel, err := reader.Read(file)
+	if err != nil {
+		return nil, err
+	}
+
+	canModel.Init()
+	diags := canModel.Diagnose()
+	for _, diag := range diags.Warnings() {
+		log.Print(diag)
+	}
+	if err := diags.Err(); err != nil {
+		return nil, err
+	}
+
+	return canModel, nil
+}
@@ -6,10 +6,12 @@ type Node struct {
 	Description string `json:"description,omitempty"`
 
 	nodeName string
+	loc      location
 }
 
 func (n *Node) initNode(nodeName string) {
 	n.nodeName = nodeName
+	n.loc.path = location{path: "nodes"}.child(nodeName).path
 
 	if n.AttributeAssignments == nil {
 		n.AttributeAssignments = &AttributeAssignments{
@@ -0,0 +1,303 @@
+// Package restbus simulates the transmission of the messages of a set of nodes (restbus simulation).
+package restbus
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/squadracorsepolito/jsondbc/pkg/canlog"
+	"github.com/squadracorsepolito/jsondbc/pkg/codec"
+)
+
+// sendMode represents when a message is transmitted, derived from its send type and cycle time.
+type sendMode int
+
+const (
+	// sendModeCyclic transmits the message every cycle time.
+	sendModeCyclic sendMode = iota
+	// sendModeCyclicIfActive transmits the message every cycle time while it is active.
+	sendModeCyclicIfActive
+	// sendModeEvent transmits the message when its signals are written.
+	sendModeEvent
+	// sendModeIfActive transmits the message when its signals are written while it is active.
+	sendModeIfActive
+	// sendModeNotUsed never transmits the message.
+	sendModeNotUsed
+)
+
+func getSendMode(msg *codec.Message) sendMode {
+	cyclic := msg.CycleTime() > 0
+
+	switch msg.Model.SendType {
+	case "NotUsed":
+		return sendModeNotUsed
+	case "IfActive":
+		return sendModeIfActive
+	case "CyclicIfActive":
+		if cyclic {
+			return sendModeCyclicIfActive
+		}
+		return sendModeIfActive
+	}
+
+	if cyclic {
+		return sendModeCyclic
+	}
+	return sendModeEvent
+}
+
+func (sm sendMode) String() string {
+	switch sm {
+	case sendModeCyclic:
+		return "cyclic"
+	case sendModeCyclicIfActive:
+		return "cyclic if active"
+	case sendModeEvent:
+		return "on event"
+	case sendModeIfActive:
+		return "if active"
+	}
+	return "not used"
+}
+
+// simMessage is a message transmitted by the simulation with its current signal values.
+type simMessage struct {
+	msg    *codec.Message
+	mode   sendMode
+	values map[string]float64
+
+	next    time.Time
+	pending bool
+	// failing is true while the message cannot be encoded, its error is reported once
+	failing bool
+}
+
+// isActive returns true if any signal has a value different from its start value.
+func (sm *simMessage) isActive() bool {
+	for sigName, value := range sm.values {
+		sig, _ := sm.msg.Signal(sigName)
+		if value != sig.StartValue() {
+			return true
+		}
+	}
+	return false
+}
+
+// frame encodes the current values into a frame of the message.
+func (sm *simMessage) frame(timestamp time.Time) (*canlog.Frame, error) {
+	data, err := sm.msg.Encode(sm.values)
+	if err != nil {
+		return nil, err
+	}
+
+	return &canlog.Frame{
+		Timestamp: timestamp,
+		Channel:   1,
+		Direction: canlog.DirectionTx,
+		ID:        sm.msg.FrameID(),
+		Extended:  sm.msg.IsExtended(),
+		FD:        len(data) > canlog.MaxClassicLength,
+		DLC:       canlog.LengthToDLC(len(data)),
+		Data:      data,
+		Name:      sm.msg.Name,
+	}, nil
+}
+
+// Simulation transmits the messages sent by a set of nodes, following their send type and cycle time.
+// The signals start from their start value and they can be overridden while the simulation is running.
+//
+// The messages are transmitted as follows:
+//   - cyclic messages (Cyclic, NoMsgSendType or no send type with a cycle time) every cycle time;
+//   - CyclicIfActive messages every cycle time while any signal differs from its start value;
+//   - messages without a cycle time when their signals are written or a transmission is requested;
+//   - IfActive messages when their signals are written while any signal differs from its start value;
+//   - NotUsed messages are never transmitted.
+//
+// A Simulation is driven by the caller with the times returned by NextDue, so it is not safe for concurrent use.
+type Simulation struct {
+	messages       []*simMessage
+	messagesByName map[string]*simMessage
+}
+
+// New returns a simulation of the messages sent by the given nodes.
+// It returns an error if the nodes do not send any message.
+func New(c *codec.Codec, nodes []string) (*Simulation, error) {
+	s := &Simulation{
+		messages:       []*simMessage{},
+		messagesByName: make(map[string]*simMessage),
+	}
+
+	for _, msg := range c.Messages() {
+		if !slices.Contains(nodes, msg.Model.Sender) {
+			continue
+		}
+
+		sm := &simMessage{
+			msg:    msg,
+			mode:   getSendMode(msg),
+			values: make(map[string]float64),
+		}
+		s.messages = append(s.messages, sm)
+		s.messagesByName[msg.Name] = sm
+	}
+
+	if len(s.messages) == 0 {
+		return nil, fmt.Errorf("nodes %v do not send any message", nodes)
+	}
+
+	return s, nil
+}
+
+// Messages returns the simulated messages sorted by id.
+func (s *Simulation) Messages() []*codec.Message {
+	messages := make([]*codec.Message, len(s.messages))
+	for i, sm := range s.messages {
+		messages[i] = sm.msg
+	}
+	return messages
+}
+
+// SendMode returns a description of when the simulated message is transmitted.
+func (s *Simulation) SendMode(msgName string) string {
+	sm, ok := s.messagesByName[msgName]
+	if !ok {
+		return ""
+	}
+	return sm.mode.String()
+}
+
+// Start schedules the first transmission of the cyclic messages at the given time.
+func (s *Simulation) Start(now time.Time) {
+	for _, sm := range s.messages {
+		sm.next = now
+	}
+}
+
+// isScheduled returns true if the message is transmitted at its next time.
+func (sm *simMessage) isScheduled() bool {
+	switch sm.mode {
+	case sendModeCyclic:
+		return true
+	case sendModeCyclicIfActive:
+		return sm.isActive()
+	}
+	return false
+}
+
+// NextDue returns the time of the next transmission, it returns false if no message is scheduled.
+func (s *Simulation) NextDue() (time.Time, bool) {
+	var next time.Time
+	found := false
+
+	for _, sm := range s.messages {
+		var due time.Time
+		switch {
+		case sm.pending:
+			// a pending message is transmitted as soon as possible
+			due = time.Time{}
+		case sm.isScheduled():
+			due = sm.next
+		default:
+			continue
+		}
+
+		if !found || due.Before(next) {
+			next = due
+			found = true
+		}
+	}
+
+	return next, found
+}
+
+// Due returns the frames to transmit at the given time, sorted by id.
+// A message that cannot be encoded is skipped while the others are still returned, with the error of the skipped
+// messages. The error of a message is returned once, until the message is encoded again.
+func (s *Simulation) Due(now time.Time) ([]*canlog.Frame, error) {
+	frames := []*canlog.Frame{}
+	errs := []error{}
+
+	for _, sm := range s.messages {
+		scheduled := sm.isScheduled() && !sm.next.After(now)
+		if !scheduled && !sm.pending {
+			continue
+		}
+
+		frame, err := sm.frame(now)
+		switch {
+		case err == nil:
+			frames = append(frames, frame)
+			sm.failing = false
+		case !sm.failing:
+			errs = append(errs, err)
+			sm.failing = true
+		}
+		sm.pending = false
+
+		if !scheduled {
+			continue
+		}
+
+		// the cycle keeps its phase, unless the simulation fell behind by a whole cycle
+		cycleTime := sm.msg.CycleTime()
+		sm.next = sm.next.Add(cycleTime)
+		if !sm.next.After(now) {
+			sm.next = now.Add(cycleTime)
+		}
+	}
+
+	return frames, errors.Join(errs...)
+}
+
+// Set overrides the physical values of the signals of a message.
+// The values are checked by encoding the message, then the event messages are requested for transmission.
+func (s *Simulation) Set(msgName string, values map[string]float64) error {
+	sm, ok := s.messagesByName[msgName]
+	if !ok {
+		return fmt.Errorf("message [%s] is not simulated", msgName)
+	}
+
+	newValues := make(map[string]float64, len(sm.values)+len(values))
+	for sigName, value := range sm.values {
+		newValues[sigName] = value
+	}
+	for sigName, value := range values {
+		newValues[sigName] = value
+	}
+
+	if _, err := sm.msg.Encode(newValues); err != nil {
+		return err
+	}
+	sm.values = newValues
+
+	switch sm.mode {
+	case sendModeEvent:
+		sm.pending = true
+	case sendModeIfActive:
+		sm.pending = sm.isActive()
+	}
+
+	return nil
+}
+
+// Reset restores the start values of the signals of a message.
+func (s *Simulation) Reset(msgName string) error {
+	sm, ok := s.messagesByName[msgName]
+	if !ok {
+		return fmt.Errorf("message [%s] is not simulated", msgName)
+	}
+	sm.values = make(map[string]float64)
+	return nil
+}
+
+// Send requests the transmission of a message with its current values, regardless of its send type.
+func (s *Simulation) Send(msgName string) error {
+	sm, ok := s.messagesByName[msgName]
+	if !ok {
+		return fmt.Errorf("message [%s] is not simulated", msgName)
+	}
+	sm.pending = true
+	return nil
+}
@@ -0,0 +1,433 @@
+// Package roundtrip checks that a DBC file converted to the JSON model and back to DBC keeps its meaning.
+package roundtrip
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/squadracorsepolito/jsondbc/pkg"
+	"github.com/squadracorsepolito/jsondbc/pkg/cangoru/dbc"
+)
+
+// DiffKind is the kind of a difference between the original DBC file and the round-tripped one.
+type DiffKind int
+
+const (
+	// DiffRemoved is an element of the original file missing after the round trip
+	DiffRemoved DiffKind = iota
+	// DiffAdded is an element added by the round trip
+	DiffAdded
+	// DiffChanged is an element whose value is changed by the round trip
+	DiffChanged
+)
+
+func (k DiffKind) String() string {
+	switch k {
+	case DiffAdded:
+		return "added"
+	case DiffChanged:
+		return "changed"
+	}
+	return "removed"
+}
+
+// Difference is a semantic difference between the original DBC file and the round-tripped one.
+type Difference struct {
+	Kind DiffKind
+	// Key identifies the element, for example SG_ 256 Rpm for a signal of the message with id 256
+	Key       string
+	Original  string
+	RoundTrip string
+}
+
+func (d *Difference) String() string {
+	switch d.Kind {
+	case DiffAdded:
+		return fmt.Sprintf("%s: %s %s", d.Kind, d.Key, d.RoundTrip)
+	case DiffChanged:
+		return fmt.Sprintf("%s: %s %s -> %s", d.Kind, d.Key, d.Original, d.RoundTrip)
+	}
+	return fmt.Sprintf("%s: %s %s", d.Kind, d.Key, d.Original)
+}
+
+// Check converts the DBC file to the JSON model and back to DBC, in a temporary directory,
+// and returns the semantic differences between the original file and the round-tripped one.
+func Check(dbcFileName string) ([]*Difference, error) {
+	original, err := parseFile(dbcFileName)
+	if err != nil {
+		return nil, err
+	}
+
+	tmpDir, err := os.MkdirTemp("", "jsondbc-roundtrip")
+	if err != nil {
+		return nil, err
+	}
+	defer os.RemoveAll(tmpDir)
+
+	baseName := strings.TrimSuffix(filepath.Base(dbcFileName), filepath.Ext(dbcFileName))
+	jsonFileName := filepath.Join(tmpDir, baseName+".json")
+	outFileName := filepath.Join(tmpDir, baseName+".dbc")
+
+	if err := convert(dbcFileName, jsonFileName, pkg.NewJsonWriter()); err != nil {
+		return nil, err
+	}
+	if err := convert(jsonFileName, outFileName, pkg.NewDBCWriter()); err != nil {
+		return nil, err
+	}
+
+	roundTrip, err := parseFile(outFileName)
+	if err != nil {
+		return nil, fmt.Errorf("the round-tripped dbc file is not valid: %w", err)
+	}
+
+	return Compare(original, roundTrip), nil
+}
+
+// convert reads the model from the input file and writes it into the output file.
+func convert(inFileName, outFileName string, writer pkg.Writer) error {
+	canModel, err := pkg.ReadCanModel(inFileName)
+	if err != nil {
+		return err
+	}
+
+	outFile, err := os.Create(outFileName)
+	if err != nil {
+		return err
+	}
+	defer outFile.Close()
+
+	return writer.Write(outFile, canModel)
+}
+
+func parseFile(fileName string) (*dbc.DBC, error) {
+	data, err := os.ReadFile(fileName)
+	if err != nil {
+		return nil, err
+	}
+
+	ast, err := dbc.NewParser(data).Parse()
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", fileName, err)
+	}
+	return ast, nil
+}
+
+// Compare returns the semantic differences between two DBC files, sorted by key.
+// The order of the elements, the positions and the new symbols (NS_) are not compared.
+func Compare(original, roundTrip *dbc.DBC) []*Difference {
+	origElems := flatten(original)
+	rtElems := flatten(roundTrip)
+
+	keys := make([]string, 0, len(origElems)+len(rtElems))
+	for key := range origElems {
+		keys = append(keys, key)
+	}
+	for key := range rtElems {
+		if _, ok := origElems[key]; !ok {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	diffs := []*Difference{}
+	for _, key := range keys {
+		origVal, inOrig := origElems[key]
+		rtVal, inRT := rtElems[key]
+
+		switch {
+		case !inRT:
+			diffs = append(diffs, &Difference{Kind: DiffRemoved, Key: key, Original: origVal})
+		case !inOrig:
+			diffs = append(diffs, &Difference{Kind: DiffAdded, Key: key, RoundTrip: rtVal})
+		case origVal != rtVal:
+			diffs = append(diffs, &Difference{Kind: DiffChanged, Key: key, Original: origVal, RoundTrip: rtVal})
+		}
+	}
+
+	return diffs
+}
+
+// elements are the elements of a DBC file by key, with their values in a canonical form.
+type elements map[string]string
+
+func (e elements) add(value string, keys ...string) {
+	e[strings.Join(keys, " ")] = value
+}
+
+func formatFloat(val float64) string {
+	return strconv.FormatFloat(val, 'g', -1, 64)
+}
+
+func formatUint(val uint32) string {
+	return strconv.FormatUint(uint64(val), 10)
+}
+
+// formatValues returns the value descriptions sorted by value.
+func formatValues(valDescs []*dbc.ValueDescription) string {
+	values := make([]string, 0, len(valDescs))
+	sorted := append([]*dbc.ValueDescription{}, valDescs...)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].ID < sorted[j].ID })
+	for _, val := range sorted {
+		values = append(values, fmt.Sprintf("%d=%q", val.ID, val.Name))
+	}
+	return "{" + strings.Join(values, " ") + "}"
+}
+
+// formatNodes returns the sorted names of the nodes without the default one.
+func formatNodes(nodes []string) string {
+	names := []string{}
+	for _, node := range nodes {
+		if node != "Vector__XXX" {
+			names = append(names, node)
+		}
+	}
+	sort.Strings(names)
+	return "[" + strings.Join(names, ",") + "]"
+}
+
+func formatByteOrder(byteOrder dbc.SignalByteOrder) string {
+	if byteOrder == dbc.SignalBigEndian {
+		return "big"
+	}
+	return "little"
+}
+
+func formatValueType(valueType dbc.SignalValueType) string {
+	if valueType == dbc.SignalSigned {
+		return "signed"
+	}
+	return "unsigned"
+}
+
+// attributeKindKeys returns the keys of the object of an attribute assignment.
+func attributeKindKeys(attVal *dbc.AttributeValue) []string {
+	switch attVal.AttributeKind {
+	case dbc.AttributeNode:
+		return []string{"BU_", attVal.NodeName}
+	case dbc.AttributeMessage:
+		return []string{"BO_", formatUint(attVal.MessageID)}
+	case dbc.AttributeSignal:
+		return []string{"SG_", formatUint(attVal.MessageID), attVal.SignalName}
+	case dbc.AttributeEnvVar:
+		return []string{"EV_", attVal.EnvVarName}
+	case dbc.AttributeNodeMessageRelation:
+		return []string{"BU_BO_REL_", attVal.NodeName, formatUint(attVal.MessageID)}
+	case dbc.AttributeNodeSignalRelation:
+		return []string{"BU_SG_REL_", attVal.NodeName, "SG_", formatUint(attVal.MessageID), attVal.SignalName}
+	case dbc.AttributeNodeEnvVarRelation:
+		return []string{"BU_EV_REL_", attVal.NodeName, attVal.EnvVarName}
+	}
+	return nil
+}
+
+func formatAttributeKind(kind dbc.AttributeKind) string {
+	switch kind {
+	case dbc.AttributeNode:
+		return "BU_"
+	case dbc.AttributeMessage:
+		return "BO_"
+	case dbc.AttributeSignal:
+		return "SG_"
+	case dbc.AttributeEnvVar:
+		return "EV_"
+	case dbc.AttributeNodeMessageRelation:
+		return "BU_BO_REL_"
+	case dbc.AttributeNodeSignalRelation:
+		return "BU_SG_REL_"
+	case dbc.AttributeNodeEnvVarRelation:
+		return "BU_EV_REL_"
+	}
+	return "general"
+}
+
+func formatAttribute(att *dbc.Attribute) string {
+	switch att.Type {
+	case dbc.AttributeInt:
+		return fmt.Sprintf("%s INT %d %d", formatAttributeKind(att.Kind), att.MinInt, att.MaxInt)
+	case dbc.AttributeHex:
+		return fmt.Sprintf("%s HEX %d %d", formatAttributeKind(att.Kind), att.MinHex, att.MaxHex)
+	case dbc.AttributeFloat:
+		return fmt.Sprintf("%s FLOAT %s %s", formatAttributeKind(att.Kind), formatFloat(att.MinFloat), formatFloat(att.MaxFloat))
+	case dbc.AttributeString:
+		return fmt.Sprintf("%s STRING", formatAttributeKind(att.Kind))
+	}
+
+	values := make([]string, 0, len(att.EnumValues))
+	for _, val := range att.EnumValues {
+		values = append(values, strconv.Quote(val))
+	}
+	return fmt.Sprintf("%s ENUM %s", formatAttributeKind(att.Kind), strings.Join(values, ","))
+}
+
+// formatAttributeValue returns the value of an attribute default or assignment,
+// the values of the enum attributes are written as the name of the enum value.
+func formatAttributeValue(att *dbc.Attribute, isString bool, valString string, valNumber float64) string {
+	if att != nil && att.Type == dbc.AttributeEnum && !isString {
+		idx := int(valNumber)
+		if float64(idx) == valNumber && idx >= 0 && idx < len(att.EnumValues) {
+			return strconv.Quote(att.EnumValues[idx])
+		}
+	}
+	if isString {
+		return strconv.Quote(valString)
+	}
+	return formatFloat(valNumber)
+}
+
+func formatAttributeDefault(att *dbc.Attribute, attDef *dbc.AttributeDefault) string {
+	switch attDef.Type {
+	case dbc.AttributeDefaultString:
+		return formatAttributeValue(att, true, attDef.ValueString, 0)
+	case dbc.AttributeDefaultFloat:
+		return formatAttributeValue(att, false, "", attDef.ValueFloat)
+	case dbc.AttributeDefaultHex:
+		return formatAttributeValue(att, false, "", float64(attDef.ValueHex))
+	}
+	return formatAttributeValue(att, false, "", float64(attDef.ValueInt))
+}
+
+func formatAttributeAssignment(att *dbc.Attribute, attVal *dbc.AttributeValue) string {
+	switch attVal.Type {
+	case dbc.AttributeValueString:
+		return formatAttributeValue(att, true, attVal.ValueString, 0)
+	case dbc.AttributeValueFloat:
+		return formatAttributeValue(att, false, "", attVal.ValueFloat)
+	case dbc.AttributeValueHex:
+		return formatAttributeValue(att, false, "", float64(attVal.ValueHex))
+	}
+	return formatAttributeValue(att, false, "", float64(attVal.ValueInt))
+}
+
+// concat returns a new slice with the elements of both slices.
+func concat[T any](a, b []T) []T {
+	return append(append(make([]T, 0, len(a)+len(b)), a...), b...)
+}
+
+// flatten returns the elements of the DBC file by key, the messages and the signals are referenced by id.
+func flatten(ast *dbc.DBC) elements {
+	elems := make(elements)
+
+	elems.add(strconv.Quote(ast.Version), "VERSION")
+	if ast.BitTiming != nil && ast.BitTiming.Baudrate > 0 {
+		elems.add(fmt.Sprintf("%d : %d,%d", ast.BitTiming.Baudrate, ast.BitTiming.BitTimingReg1, ast.BitTiming.BitTimingReg2), "BS_")
+	}
+	if ast.Nodes != nil {
+		for _, node := range ast.Nodes.Names {
+			elems.add("", "BU_", node)
+		}
+	}
+
+	for _, valTable := range ast.ValueTables {
+		elems.add(formatValues(valTable.Values), "VAL_TABLE_", valTable.Name)
+	}
+
+	for _, msg := range ast.Messages {
+		msgID := formatUint(msg.ID)
+		transmitter := msg.Transmitter
+		if transmitter == "" {
+			transmitter = "Vector__XXX"
+		}
+		elems.add(fmt.Sprintf("%s : %d %s", msg.Name, msg.Size, transmitter), "BO_", msgID)
+
+		for _, sig := range msg.Signals {
+			mux := ""
+			if sig.IsMultiplexed {
+				mux = "m" + formatUint(sig.MuxSwitchValue)
+			}
+			if sig.IsMultiplexor {
+				mux += "M"
+			}
+			elems.add(fmt.Sprintf("%s : %d|%d %s %s (%s,%s) [%s|%s] %q %s",
+				mux, sig.StartBit, sig.Size, formatByteOrder(sig.ByteOrder), formatValueType(sig.ValueType),
+				formatFloat(sig.Factor), formatFloat(sig.Offset), formatFloat(sig.Min), formatFloat(sig.Max),
+				sig.Unit, formatNodes(sig.Receivers),
+			), "SG_", msgID, sig.Name)
+		}
+	}
+
+	for _, msgTx := range ast.MessageTransmitters {
+		elems.add(formatNodes(msgTx.Transmitters), "BO_TX_BU_", formatUint(msgTx.MessageID))
+	}
+
+	for _, envVar := range ast.EnvVars {
+		elems.add(fmt.Sprintf("%d [%s|%s] %q %s %d %d %s",
+			envVar.Type, formatFloat(envVar.Min), formatFloat(envVar.Max), envVar.Unit,
+			formatFloat(envVar.InitialValue), envVar.ID, envVar.AccessType, formatNodes(envVar.AccessNodes),
+		), "EV_", envVar.Name)
+	}
+	for _, envVarData := range ast.EnvVarDatas {
+		elems.add(formatUint(envVarData.DataSize), "ENVVAR_DATA_", envVarData.EnvVarName)
+	}
+
+	for _, sigType := range ast.SignalTypes {
+		elems.add(fmt.Sprintf("%d %s %s (%s,%s) [%s|%s] %q %s %s",
+			sigType.Size, formatByteOrder(sigType.ByteOrder), formatValueType(sigType.ValueType),
+			formatFloat(sigType.Factor), formatFloat(sigType.Offset), formatFloat(sigType.Min), formatFloat(sigType.Max),
+			sigType.Unit, formatFloat(sigType.DefaultValue), sigType.ValueTableName,
+		), "SGTYPE_", sigType.TypeName)
+	}
+
+	for _, comment := range ast.Comments {
+		text := strconv.Quote(comment.Text)
+		switch comment.Kind {
+		case dbc.CommentNode:
+			elems.add(text, "CM_", "BU_", comment.NodeName)
+		case dbc.CommentMessage:
+			elems.add(text, "CM_", "BO_", formatUint(comment.MessageID))
+		case dbc.CommentSignal:
+			elems.add(text, "CM_", "SG_", formatUint(comment.MessageID), comment.SignalName)
+		case dbc.CommentEnvVar:
+			elems.add(text, "CM_", "EV_", comment.EnvVarName)
+		default:
+			// the general comments have no key, so they are compared by text
+			elems.add("", "CM_", text)
+		}
+	}
+
+	attributes := make(map[string]*dbc.Attribute)
+	for _, att := range concat(ast.Attributes, ast.RelationAttributes) {
+		attributes[att.Name] = att
+		elems.add(formatAttribute(att), "BA_DEF_", strconv.Quote(att.Name))
+	}
+	for _, attDef := range concat(ast.AttributeDefaults, ast.RelationAttributeDefaults) {
+		elems.add(formatAttributeDefault(attributes[attDef.AttributeName], attDef), "BA_DEF_DEF_", strconv.Quote(attDef.AttributeName))
+	}
+	for _, attVal := range concat(ast.AttributeValues, ast.RelationAttributeValues) {
+		keys := append([]string{"BA_", strconv.Quote(attVal.AttributeName)}, attributeKindKeys(attVal)...)
+		elems.add(formatAttributeAssignment(attributes[attVal.AttributeName], attVal), keys...)
+	}
+
+	for _, valEnc := range ast.ValueEncodings {
+		if valEnc.Kind == dbc.ValueEncodingEnvVar {
+			elems.add(formatValues(valEnc.Values), "VAL_", valEnc.EnvVarName)
+			continue
+		}
+		elems.add(formatValues(valEnc.Values), "VAL_", formatUint(valEnc.MessageID), valEnc.SignalName)
+	}
+
+	for _, ref := range ast.SignalTypeRefs {
+		elems.add(ref.TypeName, "SIG_TYPE_REF_", formatUint(ref.MessageID), ref.SignalName)
+	}
+	for _, group := range ast.SignalGroups {
+		elems.add(fmt.Sprintf("%d : %s", group.Repetitions, strings.Join(group.SignalNames, " ")),
+			"SIG_GROUP_", formatUint(group.MessageID), group.GroupName)
+	}
+	for _, valType := range ast.SignalExtValueTypes {
+		elems.add(fmt.Sprintf("%d", valType.ExtValueType), "SIG_VALTYPE_", formatUint(valType.MessageID), valType.SignalName)
+	}
+
+	for _, extMux := range ast.ExtendedMuxes {
+		ranges := make([]string, 0, len(extMux.Ranges))
+		for _, r := range extMux.Ranges {
+			ranges = append(ranges, fmt.Sprintf("%d-%d", r.From, r.To))
+		}
+		elems.add(fmt.Sprintf("%s %s", extMux.MultiplexorName, strings.Join(ranges, ",")),
+			"SG_MUL_VAL_", formatUint(extMux.MessageID), extMux.MultiplexedName)
+	}
+
+	return elems
+}
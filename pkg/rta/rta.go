@@ -0,0 +1,179 @@
+// Package rta computes the worst-case response times of the messages of a CAN model (schedulability analysis).
+//
+// The analysis is the one of Davis, Burns, Bril and Lukkien, "Controller Area Network (CAN) schedulability
+// analysis: Refuted, revisited and revised" (2007): the messages are queued every cycle time, they are
+// transmitted in the order of their ids and a transmission cannot be interrupted, so a message can be
+// blocked by a lower priority one and delayed by all the higher priority ones.
+// The frame lengths are the ones with the worst-case stuffing.
+package rta
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"github.com/squadracorsepolito/jsondbc/pkg/busload"
+	"github.com/squadracorsepolito/jsondbc/pkg/canlog"
+	"github.com/squadracorsepolito/jsondbc/pkg/cantime"
+	"github.com/squadracorsepolito/jsondbc/pkg/codec"
+)
+
+// Result is the response time analysis of a message.
+type Result struct {
+	Message *codec.Message
+	// Priority is the position of the message in the arbitration, starting from 1 for the highest priority
+	Priority int
+
+	// TransmissionTime is the longest transmission time of the message frames
+	TransmissionTime time.Duration
+	// Period is the cycle time of the message, Deadline is deadline_ms or the cycle time
+	Period   time.Duration
+	Deadline time.Duration
+	// Blocking is the longest transmission time of the lower priority messages
+	Blocking time.Duration
+	// ResponseTime is the worst-case time between the queuing of the message and the end of its transmission,
+	// it is not valid if the message is not schedulable
+	ResponseTime time.Duration
+	// Schedulable is true if the response time does not exceed the deadline
+	Schedulable bool
+}
+
+// Slack returns the time between the response time and the deadline.
+func (r *Result) Slack() time.Duration {
+	return r.Deadline - r.ResponseTime
+}
+
+// Report contains the results of the cyclic messages sorted by priority.
+type Report struct {
+	Bitrate cantime.Bitrate
+	Results []*Result
+	// Utilization is the fraction of the bus time used by the cyclic messages with the worst-case stuffing
+	Utilization float64
+	// NonCyclic are the messages without a cycle time or not used, excluded from the analysis
+	NonCyclic []*codec.Message
+}
+
+// Unschedulable returns the results of the messages that can miss their deadline.
+func (r *Report) Unschedulable() []*Result {
+	results := []*Result{}
+	for _, res := range r.Results {
+		if !res.Schedulable {
+			results = append(results, res)
+		}
+	}
+	return results
+}
+
+// arbitrationKey returns the arbitration key of the data frames of the message.
+func arbitrationKey(msg *codec.Message) uint64 {
+	return cantime.ArbitrationKey(&canlog.Frame{ID: msg.FrameID(), Extended: msg.IsExtended()})
+}
+
+// Analyze returns the worst-case response times of the cyclic messages of the codec at the given bitrate.
+func Analyze(c *codec.Codec, bitrate cantime.Bitrate) *Report {
+	load := busload.Compute(c, bitrate)
+
+	r := &Report{
+		Bitrate:     bitrate,
+		Results:     make([]*Result, 0, len(load.Messages)),
+		Utilization: load.Total.WorstCase,
+		NonCyclic:   load.NonCyclic,
+	}
+
+	for _, ml := range load.Messages {
+		r.Results = append(r.Results, &Result{
+			Message:          ml.Message,
+			TransmissionTime: bitrate.Duration(ml.WorstCaseBits),
+			Period:           ml.CycleTime,
+			Deadline:         ml.Message.Deadline(),
+		})
+	}
+
+	sort.Slice(r.Results, func(i, j int) bool {
+		return arbitrationKey(r.Results[i].Message) < arbitrationKey(r.Results[j].Message)
+	})
+
+	bitTime := time.Second / time.Duration(bitrate.Nominal)
+	for idx, res := range r.Results {
+		res.Priority = idx + 1
+		for _, lower := range r.Results[idx+1:] {
+			res.Blocking = max(res.Blocking, lower.TransmissionTime)
+		}
+		analyze(res, r.Results[:idx], bitTime)
+	}
+
+	return r
+}
+
+// interference returns the time taken by the transmissions of the given messages queued in a window.
+func interference(messages []*Result, window time.Duration) time.Duration {
+	total := time.Duration(0)
+	for _, msg := range messages {
+		total += time.Duration(ceilDiv(window, msg.Period)) * msg.TransmissionTime
+	}
+	return total
+}
+
+func ceilDiv(a, b time.Duration) int64 {
+	return int64((a + b - 1) / b)
+}
+
+// utilization returns the fraction of the bus time used by the given messages.
+func utilization(messages []*Result) float64 {
+	u := 0.0
+	for _, msg := range messages {
+		u += float64(msg.TransmissionTime) / float64(msg.Period)
+	}
+	return u
+}
+
+// analyze computes the response time of the message, delayed by the higher priority ones.
+func analyze(res *Result, higher []*Result, bitTime time.Duration) {
+	hep := append(append([]*Result{}, higher...), res)
+
+	// the busy period does not end if the bus is fully used by the messages with a priority higher or equal
+	if utilization(hep) >= 1 {
+		res.ResponseTime = time.Duration(math.MaxInt64)
+		res.Schedulable = false
+		return
+	}
+
+	// length of the busy period of the priority level of the message
+	busy := res.Blocking + res.TransmissionTime
+	for {
+		next := res.Blocking + interference(hep, busy)
+		if next == busy {
+			break
+		}
+		busy = next
+	}
+
+	// every instance of the message queued in the busy period is analyzed
+	instances := ceilDiv(busy, res.Period)
+	res.Schedulable = true
+	for q := int64(0); q < instances; q++ {
+		// queuing delay of the instance q, a higher priority message queued up to a bit time
+		// after the start of the transmission still wins the arbitration
+		queued := time.Duration(q) * res.Period
+		wait := res.Blocking + time.Duration(q)*res.TransmissionTime
+		for {
+			next := res.Blocking + time.Duration(q)*res.TransmissionTime + interference(higher, wait+bitTime)
+			if next == wait {
+				break
+			}
+			wait = next
+
+			// the instance already misses the deadline
+			if wait-queued+res.TransmissionTime > res.Deadline {
+				break
+			}
+		}
+
+		responseTime := wait - queued + res.TransmissionTime
+		res.ResponseTime = max(res.ResponseTime, responseTime)
+		if responseTime > res.Deadline {
+			res.Schedulable = false
+			return
+		}
+	}
+}
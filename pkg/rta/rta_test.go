@@ -0,0 +1,73 @@
+package rta
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestAnalyze(t *testing.T) {
+	const us = time.Microsecond
+	bitTime := 1 * us
+
+	tests := []struct {
+		name   string
+		res    *Result
+		higher []*Result
+
+		wantResponseTime time.Duration
+		wantSchedulable  bool
+	}{
+		{
+			// blocked by the lower priority message: 135 + 135
+			name:             "highest priority",
+			res:              &Result{TransmissionTime: 135 * us, Period: 1000 * us, Deadline: 1000 * us, Blocking: 135 * us},
+			wantResponseTime: 270 * us,
+			wantSchedulable:  true,
+		},
+		{
+			// delayed by one instance of the higher priority message: 135 + 135
+			name:             "lowest priority",
+			res:              &Result{TransmissionTime: 135 * us, Period: 2000 * us, Deadline: 2000 * us},
+			higher:           []*Result{{TransmissionTime: 135 * us, Period: 1000 * us}},
+			wantResponseTime: 270 * us,
+			wantSchedulable:  true,
+		},
+		{
+			// the queuing delay 60 + 100 reaches the second instance of the higher priority message,
+			// so the wait is 60 + 2*100 and the response time is 260 + 100
+			name:             "blocking and two higher priority instances",
+			res:              &Result{TransmissionTime: 100 * us, Period: 1000 * us, Deadline: 1000 * us, Blocking: 60 * us},
+			higher:           []*Result{{TransmissionTime: 100 * us, Period: 150 * us}},
+			wantResponseTime: 360 * us,
+			wantSchedulable:  true,
+		},
+		{
+			name:             "deadline missed",
+			res:              &Result{TransmissionTime: 135 * us, Period: 2000 * us, Deadline: 200 * us},
+			higher:           []*Result{{TransmissionTime: 135 * us, Period: 1000 * us}},
+			wantResponseTime: 270 * us,
+			wantSchedulable:  false,
+		},
+		{
+			name:             "bus fully used",
+			res:              &Result{TransmissionTime: 135 * us, Period: 270 * us, Deadline: 270 * us},
+			higher:           []*Result{{TransmissionTime: 135 * us, Period: 270 * us}},
+			wantResponseTime: time.Duration(math.MaxInt64),
+			wantSchedulable:  false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			analyze(tt.res, tt.higher, bitTime)
+
+			if tt.res.ResponseTime != tt.wantResponseTime {
+				t.Errorf("ResponseTime = %v, want %v", tt.res.ResponseTime, tt.wantResponseTime)
+			}
+			if tt.res.Schedulable != tt.wantSchedulable {
+				t.Errorf("Schedulable = %v, want %v", tt.res.Schedulable, tt.wantSchedulable)
+			}
+		})
+	}
+}
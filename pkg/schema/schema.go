@@ -0,0 +1,435 @@
+// Package schema generates the JSON Schema of the CAN model, so the editors can autocomplete
+// and check the JSON models with a $schema reference.
+package schema
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"reflect"
+
+	"github.com/squadracorsepolito/jsondbc/pkg"
+	"github.com/squadracorsepolito/jsondbc/pkg/sym"
+)
+
+// Draft is the JSON Schema version of the generated schema.
+const Draft = "https://json-schema.org/draft/2020-12/schema"
+
+// Schema is a JSON Schema.
+type Schema struct {
+	Schema      string `json:"$schema,omitempty"`
+	Ref         string `json:"$ref,omitempty"`
+	Title       string `json:"title,omitempty"`
+	Description string `json:"description,omitempty"`
+	// Type is a string or a list of strings
+	Type       any      `json:"type,omitempty"`
+	Enum       []string `json:"enum,omitempty"`
+	Minimum    *float64 `json:"minimum,omitempty"`
+	Default    any      `json:"default,omitempty"`
+	Deprecated bool     `json:"deprecated,omitempty"`
+
+	Items                *Schema     `json:"items,omitempty"`
+	Properties           *Properties `json:"properties,omitempty"`
+	AdditionalProperties any         `json:"additionalProperties,omitempty"`
+	Required             []string    `json:"required,omitempty"`
+	MinProperties        *int        `json:"minProperties,omitempty"`
+	MaxProperties        *int        `json:"maxProperties,omitempty"`
+
+	Defs *Properties `json:"$defs,omitempty"`
+}
+
+// Property is a named schema of an object.
+type Property struct {
+	Name   string
+	Schema *Schema
+}
+
+// Properties are the schemas of the properties of an object, marshaled in order.
+type Properties []*Property
+
+func (p *Properties) add(name string, schema *Schema) {
+	*p = append(*p, &Property{Name: name, Schema: schema})
+}
+
+// Get returns the schema of the property with the given name.
+func (p Properties) Get(name string) (*Schema, bool) {
+	for _, prop := range p {
+		if prop.Name == name {
+			return prop.Schema, true
+		}
+	}
+	return nil, false
+}
+
+func (p Properties) MarshalJSON() ([]byte, error) {
+	var buf bytes.Buffer
+	buf.WriteByte('{')
+	for idx, prop := range p {
+		if idx > 0 {
+			buf.WriteByte(',')
+		}
+
+		name, err := json.Marshal(prop.Name)
+		if err != nil {
+			return nil, err
+		}
+		schema, err := json.Marshal(prop.Schema)
+		if err != nil {
+			return nil, err
+		}
+
+		buf.Write(name)
+		buf.WriteByte(':')
+		buf.Write(schema)
+	}
+	buf.WriteByte('}')
+	return buf.Bytes(), nil
+}
+
+// field describes a field of the model, every field of the model types must be described.
+type field struct {
+	description string
+	enum        []string
+	def         any
+	required    bool
+	deprecated  bool
+}
+
+// fields contains the description of the fields of the model types.
+var fields = map[string]map[string]field{
+	"CanModel": {
+		"$schema":            {description: "The reference to the JSON Schema of the CAN model"},
+		"version":            {description: "The version of the CAN model"},
+		"baudrate":           {description: "The baud rate of the CAN model"},
+		"nodes":              {description: "The nodes, by name"},
+		"general_attributes": {description: "The general attributes, by name"},
+		"node_attributes":    {description: "The node attributes, by name"},
+		"message_attributes": {description: "The message attributes, by name"},
+		"signal_attributes":  {description: "The signal attributes, by name"},
+		"messages":           {description: "The messages, by name"},
+		"signal_enums":       {description: "The global signal enums that can be referenced by the signals with enum_ref, by name"},
+		"layout": {
+			description: "The layout policy of the signals without a start bit",
+			enum:        []string{pkg.LayoutTight, pkg.LayoutNibble, pkg.LayoutByte},
+			def:         pkg.LayoutTight,
+		},
+		"pass_through": {description: "The elements of the dbc file that are not part of the model, read from the dbc file and written back to it"},
+	},
+	"Node": {
+		"description": {description: "The node's description"},
+		"attributes":  {description: "The values of the node attributes, by attribute name"},
+	},
+	"Attribute": {
+		"int":    {description: "Sets the attribute as int"},
+		"string": {description: "Sets the attribute as string"},
+		"enum":   {description: "Sets the attribute as enum"},
+		"float":  {description: "Sets the attribute as float"},
+	},
+	"AttributeInt": {
+		"default": {description: "The attribute's default value"},
+		"from":    {description: "The attribute's lower bound value"},
+		"to":      {description: "The attribute's upper bound value"},
+	},
+	"AttributeString": {
+		"default": {description: "The attribute's default value"},
+	},
+	"AttributeEnum": {
+		"default": {description: "The attribute's default value"},
+		"values":  {description: "The list of the attribute's values"},
+	},
+	"AttributeFloat": {
+		"default": {description: "The attribute's default value"},
+		"from":    {description: "The attribute's lower bound value"},
+		"to":      {description: "The attribute's upper bound value"},
+	},
+	"Message": {
+		"id":          {description: "The message's id in decimal. If omitted, it can be assigned with the assign-ids command"},
+		"description": {description: "The message's description"},
+		"cycle_time":  {description: "The message's cycle time in ms, written as the GenMsgCycleTime attribute in the dbc file"},
+		"send_type": {
+			description: "The message's send type, written as the GenMsgSendType attribute in the dbc file",
+			enum:        sym.MsgSendTypeValues,
+		},
+		"period_ms":   {description: "The message's period in ms, written as the MsgPeriodMS attribute in the dbc file", deprecated: true},
+		"length":      {description: "The message's length (bytes count)", required: true},
+		"sender":      {description: "The message's sender node"},
+		"signals":     {description: "The message's signals, by name", required: true},
+		"priority":    {description: "The message's priority class, used by the assign-ids command to choose the range of the id"},
+		"deadline_ms": {description: "The message's deadline in ms, used by the assign-ids and rta commands. If not set, the cycle time is the deadline"},
+		"layout": {
+			description: "The layout policy of the message's signals without a start bit. If not set, the one of the model is used",
+			enum:        []string{pkg.LayoutTight, pkg.LayoutNibble, pkg.LayoutByte},
+		},
+		"attributes": {description: "The values of the message attributes, by attribute name"},
+	},
+	"Signal": {
+		"description": {description: "The signal's description"},
+		"send_type": {
+			description: "The signal's send type, written as the GenSigSendType attribute in the dbc file",
+			enum:        sym.SigSendTypeValues,
+		},
+		"start_value": {description: "The signal's start value (physical), used to encode the signals without a value. It is not written in the dbc file. If omitted, it is the physical value of the raw 0, that is the offset"},
+		"mux_switch":  {description: "The value of the multiplexor that selects the signal, if it is part of a mux_group"},
+		"start_bit":   {description: "The signal's start bit, the LSB for little endian signals and the MSB for big endian ones. If omitted, the signal is placed automatically"},
+		"size":        {description: "The signal's size (bits count)", required: true},
+		"endianness": {
+			description: "The signal's byte order",
+			enum:        []string{"little", "big"},
+			def:         "little",
+		},
+		"signed":     {description: "The signal's value type", def: false},
+		"unit":       {description: "The signal's unit"},
+		"receivers":  {description: "The signal's receiver nodes"},
+		"scale":      {description: "The signal's scale", def: 1},
+		"offset":     {description: "The signal's offset", def: 0},
+		"min":        {description: "The signal's minimum value", def: 0},
+		"max":        {description: "The signal's maximum value", required: true},
+		"enum":       {description: "The signal's enum, with the labels as keys and the raw values as values"},
+		"enum_ref":   {description: "The name of a global signal enum defined in signal_enums, used if enum is not set"},
+		"mux_group":  {description: "The multiplexed signals, by name. If set, the signal is a multiplexor"},
+		"attributes": {description: "The values of the signal attributes, by attribute name"},
+	},
+	"DBCPassThrough": {
+		"comments":                  {description: "The general comments of the network (CM_ without an object)"},
+		"general_attribute_values":  {description: "The values of the general attributes (BA_ without an object), by attribute name"},
+		"value_tables":              {description: "The value tables (VAL_TABLE_), with the labels as keys and the raw values as values, by name"},
+		"message_transmitters":      {description: "The additional transmitter nodes of the messages (BO_TX_BU_), by message name"},
+		"env_vars":                  {description: "The environment variables (EV_), by name"},
+		"env_var_attributes":        {description: "The environment variable attributes, by name"},
+		"signal_types":              {description: "The signal types (SGTYPE_), by name"},
+		"signal_type_refs":          {description: "The references of the signals to the signal types (SIG_TYPE_REF_)"},
+		"signal_groups":             {description: "The signal groups (SIG_GROUP_)"},
+		"signal_value_types":        {description: "The extended value types of the signals (SIG_VALTYPE_)"},
+		"relation_attributes":       {description: "The attributes of the relations between the nodes and the messages, the signals or the environment variables (BA_DEF_REL_), by name"},
+		"relation_attribute_values": {description: "The values of the relation attributes (BA_REL_)"},
+	},
+	"DBCEnvVar": {
+		"type":          {description: "The environment variable's type", enum: []string{"int", "float", "string"}, required: true},
+		"min":           {description: "The environment variable's minimum value"},
+		"max":           {description: "The environment variable's maximum value"},
+		"unit":          {description: "The environment variable's unit"},
+		"initial_value": {description: "The environment variable's initial value"},
+		"id":            {description: "The environment variable's id"},
+		"access_type": {
+			description: "The environment variable's access type, as written in the dbc file",
+			enum: []string{
+				"DUMMY_NODE_VECTOR0", "DUMMY_NODE_VECTOR1", "DUMMY_NODE_VECTOR2", "DUMMY_NODE_VECTOR3",
+				"DUMMY_NODE_VECTOR8000", "DUMMY_NODE_VECTOR8001", "DUMMY_NODE_VECTOR8002", "DUMMY_NODE_VECTOR8003",
+			},
+		},
+		"access_nodes": {description: "The nodes that access the environment variable"},
+		"data_size":    {description: "The environment variable's data size (ENVVAR_DATA_)"},
+		"description":  {description: "The environment variable's description"},
+		"enum":         {description: "The environment variable's enum, with the labels as keys and the raw values as values"},
+		"attributes":   {description: "The values of the environment variable attributes, by attribute name"},
+	},
+	"DBCSignalType": {
+		"size": {description: "The signal type's size (bits count)", required: true},
+		"endianness": {
+			description: "The signal type's byte order",
+			enum:        []string{"little", "big"},
+		},
+		"signed":        {description: "The signal type's value type"},
+		"scale":         {description: "The signal type's scale"},
+		"offset":        {description: "The signal type's offset"},
+		"min":           {description: "The signal type's minimum value"},
+		"max":           {description: "The signal type's maximum value"},
+		"unit":          {description: "The signal type's unit"},
+		"default_value": {description: "The signal type's default value"},
+		"value_table":   {description: "The name of the value table of the signal type"},
+	},
+	"DBCSignalTypeRef": {
+		"message": {description: "The name of the message of the signal", required: true},
+		"signal":  {description: "The name of the signal", required: true},
+		"type":    {description: "The name of the signal type", required: true},
+	},
+	"DBCSignalGroup": {
+		"message":     {description: "The name of the message of the signal group", required: true},
+		"name":        {description: "The signal group's name", required: true},
+		"repetitions": {description: "The signal group's repetitions"},
+		"signals":     {description: "The names of the signals of the group"},
+	},
+	"DBCSignalValueType": {
+		"message": {description: "The name of the message of the signal", required: true},
+		"signal":  {description: "The name of the signal", required: true},
+		"type": {
+			description: "The signal's extended value type",
+			enum:        []string{"integer", "float", "double"},
+			required:    true,
+		},
+	},
+	"DBCRelationAttribute": {
+		"kind": {
+			description: "The kind of the relation, between a node and a message, a signal or an environment variable",
+			enum:        []string{"node_message", "node_signal", "node_env_var"},
+			required:    true,
+		},
+		"int":    {description: "Sets the attribute as int"},
+		"string": {description: "Sets the attribute as string"},
+		"enum":   {description: "Sets the attribute as enum"},
+		"float":  {description: "Sets the attribute as float"},
+	},
+	"DBCRelationAttributeValue": {
+		"attribute": {description: "The name of the relation attribute", required: true},
+		"node":      {description: "The name of the node", required: true},
+		"message":   {description: "The name of the message, for the node_message and node_signal relations"},
+		"signal":    {description: "The name of the signal, for the node_signal relations"},
+		"env_var":   {description: "The name of the environment variable, for the node_env_var relations"},
+		"value":     {description: "The attribute's value, the name of the value for the enum attributes"},
+	},
+}
+
+// generator builds the definitions of the struct types of the model.
+type generator struct {
+	defs *Properties
+}
+
+// defName returns the name of the definition of a struct type.
+// The structs that only embed another struct, like the attributes, share its definition.
+func defName(t reflect.Type) string {
+	if t.NumField() > 0 {
+		first := t.Field(0)
+		if first.Anonymous && first.Type.Kind() == reflect.Pointer && first.Type.Elem().Kind() == reflect.Struct {
+			exported := 0
+			for i := 0; i < t.NumField(); i++ {
+				if t.Field(i).IsExported() {
+					exported++
+				}
+			}
+			if exported == 1 {
+				return defName(first.Type.Elem())
+			}
+		}
+	}
+	return t.Name()
+}
+
+func (g *generator) typeSchema(t reflect.Type) (*Schema, error) {
+	for t.Kind() == reflect.Pointer {
+		t = t.Elem()
+	}
+
+	switch t.Kind() {
+	case reflect.String:
+		return &Schema{Type: "string"}, nil
+
+	case reflect.Bool:
+		return &Schema{Type: "boolean"}, nil
+
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return &Schema{Type: "integer"}, nil
+
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		minimum := 0.0
+		return &Schema{Type: "integer", Minimum: &minimum}, nil
+
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: "number"}, nil
+
+	case reflect.Interface:
+		return &Schema{}, nil
+
+	case reflect.Slice:
+		items, err := g.typeSchema(t.Elem())
+		if err != nil {
+			return nil, err
+		}
+		return &Schema{Type: "array", Items: items}, nil
+
+	case reflect.Map:
+		values, err := g.typeSchema(t.Elem())
+		if err != nil {
+			return nil, err
+		}
+		schema := &Schema{Type: "object"}
+		if values.Type != nil || values.Ref != "" {
+			schema.AdditionalProperties = values
+		}
+		return schema, nil
+
+	case reflect.Struct:
+		name := defName(t)
+		if _, ok := g.defs.Get(name); !ok {
+			// the definition is added before the fields, so the recursive types are referenced
+			def := &Schema{}
+			g.defs.add(name, def)
+			if err := g.structSchema(t, name, def); err != nil {
+				return nil, err
+			}
+		}
+		return &Schema{Ref: "#/$defs/" + name}, nil
+	}
+
+	return nil, fmt.Errorf("type %s is not supported in the schema", t)
+}
+
+// structSchema fills the schema of an object with the described fields of the struct.
+func (g *generator) structSchema(t reflect.Type, name string, schema *Schema) error {
+	descriptions, ok := fields[name]
+	if !ok {
+		return fmt.Errorf("type %s has no description in the schema", name)
+	}
+
+	schema.Type = "object"
+	schema.Properties = &Properties{}
+	schema.AdditionalProperties = false
+
+	for _, f := range pkg.JsonFields(t) {
+		desc, ok := descriptions[f.Name]
+		if !ok {
+			return fmt.Errorf("field %s.%s has no description in the schema", name, f.Name)
+		}
+
+		prop, err := g.typeSchema(f.Type)
+		if err != nil {
+			return err
+		}
+		// the empty maps and lists are written as null if they are not omitted
+		if kind := f.Type.Kind(); !f.OmitEmpty && (kind == reflect.Map || kind == reflect.Slice) {
+			prop.Type = []string{prop.Type.(string), "null"}
+		}
+		prop.Description = desc.description
+		prop.Enum = desc.enum
+		prop.Default = desc.def
+		prop.Deprecated = desc.deprecated
+
+		schema.Properties.add(f.Name, prop)
+		if desc.required {
+			schema.Required = append(schema.Required, f.Name)
+		}
+	}
+
+	return nil
+}
+
+// New returns the JSON Schema of the CAN model, with the model as root and the other types as definitions.
+func New() (*Schema, error) {
+	g := &generator{defs: &Properties{}}
+
+	root := &Schema{
+		Schema: Draft,
+		Title:  "jsondbc CAN model",
+	}
+	if err := g.structSchema(reflect.TypeOf(pkg.CanModel{}), "CanModel", root); err != nil {
+		return nil, err
+	}
+
+	// exactly one type can be set for an attribute
+	if attribute, ok := g.defs.Get("Attribute"); ok {
+		one := 1
+		attribute.MinProperties = &one
+		attribute.MaxProperties = &one
+	}
+
+	root.Defs = g.defs
+	return root, nil
+}
+
+// Generate returns the indented JSON Schema of the CAN model.
+func Generate() ([]byte, error) {
+	schema, err := New()
+	if err != nil {
+		return nil, err
+	}
+	return json.MarshalIndent(schema, "", "\t")
+}
@@ -1,8 +1,6 @@
 package pkg
 
 import (
-	"fmt"
-
 	"github.com/squadracorsepolito/jsondbc/pkg/sym"
 )
 
@@ -13,6 +11,9 @@ type Signal struct {
 
 	// Custom attributes
 	SendType string `json:"send_type,omitempty"`
+	// StartValue is the physical start value used by the codec, it is not written in the DBC file.
+	// It is nil if the start value is not set, in that case the start value is the raw 0
+	StartValue *float64 `json:"start_value,omitempty"`
 
 	MuxSwitch  uint32             `json:"mux_switch,omitempty"`
 	StartBit   uint32             `json:"start_bit"`
@@ -30,14 +31,16 @@ type Signal struct {
 	MuxGroup   map[string]*Signal `json:"mux_group,omitempty"`
 
 	signalName    string
+	loc           location
 	isMultiplexor bool
 	isMultiplexed bool
 	isBigEndian   bool
 	source        sourceType
 }
 
-func (s *Signal) initSignal(sigName string, source sourceType) {
+func (s *Signal) initSignal(sigName, path string, source sourceType, diags *Diagnostics) {
 	s.signalName = sigName
+	s.loc.path = path
 	s.source = source
 
 	if s.AttributeAssignments == nil {
@@ -46,12 +49,12 @@ func (s *Signal) initSignal(sigName string, source sourceType) {
 		}
 	}
 
-	s.handleCustomAttributes()
-
 	if s.Scale == 0 {
 		s.Scale = 1
 	}
 
+	s.handleCustomAttributes(diags)
+
 	if len(s.Endianness) > 0 {
 		switch s.Endianness {
 		case "big":
@@ -74,14 +77,13 @@ func (s *Signal) appendDescription(format string, a ...any) {
 	s.Description = appendString(s.Description, format, a...)
 }
 
-func (s *Signal) handleCustomAttributes() {
-	location := fmt.Sprintf("signal '%s'", s.signalName)
+func (s *Signal) handleCustomAttributes(diags *Diagnostics) {
 
 	switch s.source {
 	case sourceTypeJSON:
 		// SigSendType
 		if len(s.SendType) > 0 {
-			tmpST := checkCustomEnumAttribute(s.SendType, "signal.send_type", sym.SigSendTypeValues, location)
+			tmpST := checkCustomEnumAttribute(s.SendType, "signal.send_type", sym.SigSendTypeValues, s.loc.child("send_type"), diags)
 			s.AttributeAssignments.Attributes[sym.SigSendType] = tmpST
 			s.appendDescription("(send_type: %s)", tmpST)
 		}
@@ -90,8 +92,10 @@ func (s *Signal) handleCustomAttributes() {
 		// SigSendType
 		stAtt, hasST := s.AttributeAssignments.Attributes[sym.SigSendType]
 		if hasST {
-			s.SendType = checkCustomEnumAttribute(stAtt.(string), sym.SigSendType, sym.SigSendTypeValues, location)
+			s.SendType = checkCustomEnumAttribute(stAtt.(string), sym.SigSendType, sym.SigSendTypeValues, s.loc, diags)
 			delete(s.AttributeAssignments.Attributes, sym.SigSendType)
+			// the note appended to the description by the JSON model is removed, otherwise it is appended again
+			s.Description = trimAppendedString(s.Description, "(send_type: %s)", s.SendType)
 		}
 	}
 }
@@ -111,10 +115,32 @@ func (s *Signal) HasDescription() bool {
 	return len(s.Description) > 0
 }
 
-func (s *Signal) validate() error {
+// maxSignalSize is the maximum size in bits of a signal.
+const maxSignalSize = 64
+
+func (s *Signal) validate(diags *Diagnostics, msgLength uint32) {
 	if s.Size == 0 {
-		return fmt.Errorf("signal [%s] size cannot be 0", s.signalName)
+		diags.errorf(s.loc.child("size"), "signal [%s] size cannot be 0", s.signalName)
+		return
+	}
+	if s.Size > maxSignalSize {
+		diags.errorf(s.loc.child("size"), "signal [%s] size %d exceeds %d bits", s.signalName, s.Size, maxSignalSize)
 	}
 
-	return nil
+	// the bits are summed as 64 bit integers, so a huge start bit or size cannot wrap around
+	msgBits := uint64(msgLength) * 8
+	if !s.isBigEndian {
+		if uint64(s.StartBit)+uint64(s.Size) > msgBits {
+			diags.errorf(s.loc.child("start_bit"), "signal [%s] exceeds message length %d: start bit %d, size %d",
+				s.signalName, msgLength, s.StartBit, s.Size)
+		}
+		return
+	}
+
+	// the start bit of a big endian signal is its MSB and the following bits go towards the last byte,
+	// a signal that should wrap past byte 0 usually has the LSB as start bit
+	if uint64(bigEndianPosition(s.StartBit))+uint64(s.Size) > msgBits {
+		diags.errorf(s.loc.child("start_bit"), "big endian signal [%s] exceeds message length %d: start bit %d (MSB), size %d, the start bit must be the MSB",
+			s.signalName, msgLength, s.StartBit, s.Size)
+	}
 }
@@ -0,0 +1,132 @@
+//go:build linux
+
+package socketcan
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"syscall"
+	"time"
+	"unsafe"
+
+	"github.com/squadracorsepolito/jsondbc/pkg/canlog"
+)
+
+// Constants of the raw CAN sockets, not defined by the syscall package.
+const (
+	afCAN          = 29
+	canRaw         = 1
+	solCANRaw      = 101
+	canRawFDFrames = 5
+	siocGStamp     = 0x8906
+)
+
+// sockaddrCAN is the struct sockaddr_can used to bind the socket to an interface.
+type sockaddrCAN struct {
+	family  uint16
+	_       uint16
+	ifindex int32
+	addr    [8]byte
+}
+
+// Conn is a raw CAN socket bound to a network interface (e.g. can0 or vcan0).
+// It receives and sends both CAN and CAN FD frames.
+type Conn struct {
+	iface string
+	file  *os.File
+	buf   []byte
+}
+
+// Dial opens a raw CAN socket bound to the given interface.
+func Dial(iface string) (*Conn, error) {
+	netIface, err := net.InterfaceByName(iface)
+	if err != nil {
+		return nil, fmt.Errorf("interface %s: %w", iface, err)
+	}
+
+	fd, err := syscall.Socket(afCAN, syscall.SOCK_RAW|syscall.SOCK_CLOEXEC, canRaw)
+	if err != nil {
+		return nil, fmt.Errorf("cannot open CAN socket: %w", err)
+	}
+
+	if err := syscall.SetsockoptInt(fd, solCANRaw, canRawFDFrames, 1); err != nil {
+		syscall.Close(fd)
+		return nil, fmt.Errorf("cannot enable CAN FD frames: %w", err)
+	}
+
+	addr := &sockaddrCAN{
+		family:  afCAN,
+		ifindex: int32(netIface.Index),
+	}
+	if _, _, errno := syscall.Syscall(syscall.SYS_BIND, uintptr(fd), uintptr(unsafe.Pointer(addr)), unsafe.Sizeof(*addr)); errno != 0 {
+		syscall.Close(fd)
+		return nil, fmt.Errorf("cannot bind CAN socket to %s: %w", iface, errno)
+	}
+
+	// a non blocking socket is handled by the runtime poller, so Close unblocks Read
+	if err := syscall.SetNonblock(fd, true); err != nil {
+		syscall.Close(fd)
+		return nil, err
+	}
+
+	return &Conn{
+		iface: iface,
+		file:  os.NewFile(uintptr(fd), iface),
+		buf:   make([]byte, canFDMTU),
+	}, nil
+}
+
+// timestamp returns the reception time of the last frame, or the current time if it is not available.
+func (c *Conn) timestamp() time.Time {
+	var tv syscall.Timeval
+	var errno syscall.Errno
+
+	rawConn, err := c.file.SyscallConn()
+	if err != nil {
+		return time.Now()
+	}
+	err = rawConn.Control(func(fd uintptr) {
+		_, _, errno = syscall.Syscall(syscall.SYS_IOCTL, fd, siocGStamp, uintptr(unsafe.Pointer(&tv)))
+	})
+	if err != nil || errno != 0 {
+		return time.Now()
+	}
+
+	return time.Unix(0, tv.Nano())
+}
+
+// Read reads the next frame received by the interface.
+func (c *Conn) Read() (*canlog.Frame, error) {
+	for {
+		n, err := c.file.Read(c.buf)
+		if err != nil {
+			return nil, err
+		}
+		// other frame types (e.g. CAN XL) are skipped
+		if n != canMTU && n != canFDMTU {
+			continue
+		}
+
+		frame := decodeFrame(c.buf[:n])
+		frame.Timestamp = c.timestamp()
+		frame.Interface = c.iface
+		frame.Channel = 1
+		return frame, nil
+	}
+}
+
+// Write sends a frame on the interface.
+func (c *Conn) Write(frame *canlog.Frame) error {
+	_, err := c.file.Write(encodeFrame(frame))
+	if errors.Is(err, syscall.ENOBUFS) {
+		return ErrQueueFull
+	}
+	return err
+}
+
+// Close closes the socket.
+func (c *Conn) Close() error {
+	return c.file.Close()
+}
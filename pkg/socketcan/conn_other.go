@@ -0,0 +1,34 @@
+//go:build !linux
+
+package socketcan
+
+import (
+	"errors"
+
+	"github.com/squadracorsepolito/jsondbc/pkg/canlog"
+)
+
+var errNotSupported = errors.New("SocketCAN is supported only on Linux")
+
+// Conn is a raw CAN socket bound to a network interface, available only on Linux.
+type Conn struct{}
+
+// Dial returns an error, SocketCAN is available only on Linux.
+func Dial(iface string) (*Conn, error) {
+	return nil, errNotSupported
+}
+
+// Read returns an error, SocketCAN is available only on Linux.
+func (c *Conn) Read() (*canlog.Frame, error) {
+	return nil, errNotSupported
+}
+
+// Write returns an error, SocketCAN is available only on Linux.
+func (c *Conn) Write(frame *canlog.Frame) error {
+	return errNotSupported
+}
+
+// Close returns an error, SocketCAN is available only on Linux.
+func (c *Conn) Close() error {
+	return errNotSupported
+}
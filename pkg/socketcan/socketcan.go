@@ -0,0 +1,118 @@
+// Package socketcan contains a connection to the raw CAN sockets of Linux (SocketCAN).
+package socketcan
+
+import (
+	"encoding/binary"
+	"errors"
+
+	"github.com/squadracorsepolito/jsondbc/pkg/canlog"
+)
+
+// ErrQueueFull is returned by Write when the transmit queue of the interface is full,
+// for example when no other node acknowledges the frames.
+var ErrQueueFull = errors.New("transmit queue is full")
+
+// Sizes of the SocketCAN frames.
+const (
+	canMTU   = 16
+	canFDMTU = 72
+)
+
+// Flags used by SocketCAN in the frame id and in the CAN FD frames.
+const (
+	canEFFFlag = 0x80000000
+	canRTRFlag = 0x40000000
+	canERRFlag = 0x20000000
+	canEFFMask = 0x1FFFFFFF
+	canSFFMask = 0x000007FF
+
+	canFDBRSFlag = 0x01
+	canFDESIFlag = 0x02
+	canFDFDFlag  = 0x04
+)
+
+// decodeFrame decodes a struct can_frame or canfd_frame.
+func decodeFrame(buf []byte) *canlog.Frame {
+	rawID := binary.NativeEndian.Uint32(buf)
+	length := int(buf[4])
+
+	frame := &canlog.Frame{
+		Extended: rawID&canEFFFlag != 0,
+		Remote:   rawID&canRTRFlag != 0,
+		Error:    rawID&canERRFlag != 0,
+		Data:     []byte{},
+	}
+
+	if frame.Extended || frame.Error {
+		frame.ID = rawID & canEFFMask
+	} else {
+		frame.ID = rawID & canSFFMask
+	}
+
+	if len(buf) == canFDMTU {
+		flags := buf[5]
+		frame.FD = true
+		frame.BRS = flags&canFDBRSFlag != 0
+		frame.ESI = flags&canFDESIFlag != 0
+		length = min(length, canlog.MaxFDLength)
+		frame.DLC = canlog.LengthToDLC(length)
+	} else {
+		length = min(length, canlog.MaxClassicLength)
+		frame.DLC = uint8(length)
+		// len8_dlc holds the DLC of the classic frames with 8 bytes and a DLC greater than 8
+		if length == canlog.MaxClassicLength && buf[7] > canlog.MaxClassicLength && buf[7] <= 15 {
+			frame.DLC = buf[7]
+		}
+	}
+
+	if frame.Remote {
+		return frame
+	}
+
+	frame.Data = append(frame.Data, buf[8:8+length]...)
+	return frame
+}
+
+// encodeFrame encodes the frame into a struct can_frame or canfd_frame.
+func encodeFrame(frame *canlog.Frame) []byte {
+	fd := frame.FD || len(frame.Data) > canlog.MaxClassicLength
+
+	buf := make([]byte, canMTU)
+	if fd {
+		buf = make([]byte, canFDMTU)
+	}
+
+	rawID := frame.ID
+	if frame.Extended {
+		rawID = rawID&canEFFMask | canEFFFlag
+	}
+	if frame.Remote {
+		rawID |= canRTRFlag
+	}
+	binary.NativeEndian.PutUint32(buf, rawID)
+
+	length := len(frame.Data)
+	if fd {
+		// the payload is padded to the length of the DLC
+		length = canlog.DLCToLength(canlog.LengthToDLC(length), true)
+		flags := byte(canFDFDFlag)
+		if frame.BRS {
+			flags |= canFDBRSFlag
+		}
+		if frame.ESI {
+			flags |= canFDESIFlag
+		}
+		buf[5] = flags
+	} else if frame.Remote {
+		length = int(min(frame.DLC, canlog.MaxClassicLength))
+	} else if length == canlog.MaxClassicLength && frame.DLC > canlog.MaxClassicLength {
+		buf[7] = frame.DLC
+	}
+	buf[4] = byte(length)
+
+	if !frame.Remote {
+		copy(buf[8:], frame.Data)
+	}
+
+	return buf
+}
@@ -14,3 +14,5 @@ var MsgSendTypeValues = []string{"NoMsgSendType", "Cyclic", "IfActive", "CyclicI
 const SigSendType string = "GenSigSendType"
 
 var SigSendTypeValues = []string{"NoSigSendType", "Cyclic", "OnWrite", "OnWriteWithRepetition", "OnChange", "OnChangeWithRepetition", "IfActive", "IfActiveWithRepetition", "NotUsed"}
+
+const SigStartValue string = "GenSigStartValue"
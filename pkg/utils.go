@@ -2,11 +2,8 @@
 package pkg
 
 import (
-	"errors"
 	"fmt"
-	"log"
 	"os"
-	"regexp"
 	"strconv"
 	"strings"
 )
@@ -15,8 +12,11 @@ func formatFloat(val float64) string {
 	return strconv.FormatFloat(val, 'f', -1, 64)
 }
 
+// dbcStringEscaper escapes the quotes and backslashes of a DBC string.
+var dbcStringEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`)
+
 func formatString(val string) string {
-	return "\"" + val + "\""
+	return "\"" + dbcStringEscaper.Replace(val) + "\""
 }
 
 func formatInt(val int) string {
@@ -27,26 +27,6 @@ func formatUint(val uint32) string {
 	return strconv.FormatUint(uint64(val), 10)
 }
 
-func parseUint(val string) (uint32, error) {
-	res, err := strconv.ParseUint(val, 10, 32)
-	if err != nil {
-		return 0, err
-	}
-	return uint32(res), nil
-}
-
-func parseInt(val string) (int, error) {
-	res, err := strconv.ParseInt(val, 10, 32)
-	if err != nil {
-		return 0, err
-	}
-	return int(res), nil
-}
-
-func parseFloat(val string) (float64, error) {
-	return strconv.ParseFloat(val, 64)
-}
-
 type file struct {
 	f *os.File
 }
@@ -62,6 +42,14 @@ func (f *file) newLine() {
 	}
 }
 
+// write writes the string as is.
+func (f *file) write(str string) {
+	_, err := f.f.WriteString(str)
+	if err != nil {
+		panic(err)
+	}
+}
+
 func (f *file) print(str ...string) {
 	tmp := ""
 	for i, s := range str {
@@ -84,26 +72,16 @@ func (f *file) print(str ...string) {
 	}
 }
 
-var errRegexNoMatch = errors.New("no match")
-
-func applyRegex(r *regexp.Regexp, str string) ([]string, error) {
-	matches := r.FindAllStringSubmatch(str, -1)
-	if len(matches) == 0 {
-		return nil, errRegexNoMatch
-	}
-	return matches[0], nil
-}
-
 // Checks if a string is a valid value of a custom enum attribute.
 // It returns the current string if true, otherwise the default value (custom_attribute_values[0])
-func checkCustomEnumAttribute(curr, attName string, values []string, location string) string {
+func checkCustomEnumAttribute(curr, attName string, values []string, loc location, diags *Diagnostics) string {
 	for _, val := range values {
 		if strings.ToLower(curr) == strings.ToLower(val) {
 			return val
 		}
 	}
 
-	log.Printf("WARNING: unknown %s '%s', valid are %v, using default value %s; in %s", attName, curr, values, values[0], location)
+	diags.warnf(loc, "unknown %s '%s', valid are %v, using default value %s", attName, curr, values, values[0])
 
 	return values[0]
 }
@@ -118,3 +96,12 @@ func appendString(str, format string, a ...any) string {
 	}
 	return str
 }
+
+// trimAppendedString removes the string appended by appendString, if the string ends with it.
+func trimAppendedString(str, format string, a ...any) string {
+	appStr := fmt.Sprintf(format, a...)
+	if str == appStr {
+		return ""
+	}
+	return strings.TrimSuffix(str, " "+appStr)
+}
@@ -0,0 +1,83 @@
+package vbus
+
+import (
+	"fmt"
+
+	"github.com/squadracorsepolito/jsondbc/pkg/canlog"
+)
+
+// Node is a node of the model attached to the bus. It transmits the frames queued by Send
+// and it receives all the frames transmitted by the other nodes.
+type Node struct {
+	name string
+	bus  *Bus
+
+	receivers []func(rx *Received)
+}
+
+// Name returns the name of the node.
+func (n *Node) Name() string {
+	return n.name
+}
+
+// OnReceive registers a callback called with every frame transmitted by the other nodes.
+func (n *Node) OnReceive(fn func(rx *Received)) {
+	n.receivers = append(n.receivers, fn)
+}
+
+// OnMessage registers a callback called with every frame of the given message transmitted by the other nodes.
+func (n *Node) OnMessage(msgName string, fn func(rx *Received)) error {
+	msg, ok := n.bus.c.MessageByName(msgName)
+	if !ok {
+		return fmt.Errorf("message [%s] is not defined", msgName)
+	}
+
+	n.OnReceive(func(rx *Received) {
+		if rx.Message == msg {
+			fn(rx)
+		}
+	})
+	return nil
+}
+
+// Send encodes the physical values of the signals into a frame of the message and queues it for transmission,
+// the signals without a value are encoded with their start value.
+// It returns an error if the message is sent by another node of the model or if it cannot be encoded,
+// in which case nothing is queued and the bus keeps running, so the caller can log the error and go on.
+func (n *Node) Send(msgName string, values map[string]float64) error {
+	msg, ok := n.bus.c.MessageByName(msgName)
+	if !ok {
+		return fmt.Errorf("message [%s] is not defined", msgName)
+	}
+
+	if sender := msg.Model.Sender; len(sender) > 0 && sender != n.name {
+		return fmt.Errorf("message [%s] is sent by node [%s], not by [%s]", msgName, sender, n.name)
+	}
+
+	data, err := msg.Encode(values)
+	if err != nil {
+		return err
+	}
+
+	fd := len(data) > canlog.MaxClassicLength
+	n.bus.queue(n, &canlog.Frame{
+		Channel:   1,
+		Direction: canlog.DirectionTx,
+		ID:        msg.FrameID(),
+		Extended:  msg.IsExtended(),
+		FD:        fd,
+		BRS:       fd && n.bus.bitrate.Data != n.bus.bitrate.Nominal,
+		DLC:       canlog.LengthToDLC(len(data)),
+		Data:      data,
+		Name:      msg.Name,
+	})
+
+	return nil
+}
+
+// SendFrame queues a raw frame for transmission, for example a frame that does not belong to the model.
+func (n *Node) SendFrame(frame *canlog.Frame) {
+	f := *frame
+	f.Data = append([]byte{}, frame.Data...)
+	n.bus.queue(n, &f)
+}
@@ -0,0 +1,314 @@
+// Package vbus contains a deterministic in-memory CAN bus with a simulated clock.
+//
+// The nodes of a CAN model are attached to the bus as Go callbacks that send and receive decoded messages.
+// The pending frames are transmitted one at a time after the arbitration by id and each transmission
+// takes the time of the frame bits at the bitrate of the model, so the bus can be used to test
+// the logic of the nodes without any interface, real or virtual.
+package vbus
+
+import (
+	"container/heap"
+	"fmt"
+	"sort"
+	"time"
+
+	"github.com/squadracorsepolito/jsondbc/pkg"
+	"github.com/squadracorsepolito/jsondbc/pkg/canlog"
+	"github.com/squadracorsepolito/jsondbc/pkg/cantime"
+	"github.com/squadracorsepolito/jsondbc/pkg/codec"
+)
+
+// Received is a frame received by a node, decoded with the messages of the model.
+type Received struct {
+	// Time is the time of the end of the frame, since the start of the bus
+	Time time.Duration
+	// Sender is the name of the node that transmitted the frame
+	Sender string
+	Frame  *canlog.Frame
+	// Message is nil if the frame does not belong to the model
+	Message *codec.Message
+	Values  []*codec.SignalValue
+	// Err is the error returned by the decoding of the frame
+	Err error
+}
+
+// Value returns the physical value of a decoded signal.
+func (r *Received) Value(sigName string) (float64, bool) {
+	for _, val := range r.Values {
+		if val.Signal.Name == sigName {
+			return val.Value, true
+		}
+	}
+	return 0, false
+}
+
+// pendingFrame is a frame waiting for the arbitration.
+type pendingFrame struct {
+	node  *Node
+	frame *canlog.Frame
+}
+
+// timer is a callback scheduled at a time of the simulated clock.
+type timer struct {
+	at  time.Duration
+	seq uint64
+	fn  func()
+}
+
+// timerQueue is a heap of timers ordered by time, then by scheduling order.
+type timerQueue []*timer
+
+func (q timerQueue) Len() int { return len(q) }
+func (q timerQueue) Less(i, j int) bool {
+	if q[i].at != q[j].at {
+		return q[i].at < q[j].at
+	}
+	return q[i].seq < q[j].seq
+}
+func (q timerQueue) Swap(i, j int) { q[i], q[j] = q[j], q[i] }
+func (q *timerQueue) Push(x any)   { *q = append(*q, x.(*timer)) }
+func (q *timerQueue) Pop() any {
+	old := *q
+	t := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return t
+}
+
+// Bus is a virtual CAN bus with a simulated clock, which advances only when Run or RunUntil are called.
+// All the callbacks are called by the goroutine running the bus, so a Bus is not safe for concurrent use.
+type Bus struct {
+	c        *codec.Codec
+	nodes    map[string]*Node
+	nodeList []*Node
+	bitrate  cantime.Bitrate
+	start    time.Time
+
+	now     time.Duration
+	seq     uint64
+	timers  timerQueue
+	pending []*pendingFrame
+
+	// the frame being transmitted and the time its transmission ends
+	current    *pendingFrame
+	currentEnd time.Duration
+	busyTime   time.Duration
+
+	taps []func(rx *Received)
+}
+
+// New returns a bus for the nodes and messages of the model, with the nominal bitrate of the model
+// (cantime.DefaultBitrate if it is not defined) and no bitrate switching for the CAN FD frames.
+func New(canModel *pkg.CanModel) (*Bus, error) {
+	c, err := codec.New(canModel)
+	if err != nil {
+		return nil, err
+	}
+
+	b := &Bus{
+		c:       c,
+		nodes:   make(map[string]*Node),
+		bitrate: cantime.NewBitrate(canModel.Baudrate, 0),
+		start:   time.Unix(0, 0),
+
+		timers:  timerQueue{},
+		pending: []*pendingFrame{},
+		taps:    []func(rx *Received){},
+	}
+
+	// the nodes receive the frames in the order of their names
+	nodeNames := make([]string, 0, len(canModel.Nodes))
+	for nodeName := range canModel.Nodes {
+		nodeNames = append(nodeNames, nodeName)
+	}
+	sort.Strings(nodeNames)
+
+	for _, nodeName := range nodeNames {
+		node := &Node{
+			name: nodeName,
+			bus:  b,
+		}
+		b.nodes[nodeName] = node
+		b.nodeList = append(b.nodeList, node)
+	}
+
+	return b, nil
+}
+
+// SetDataBitrate sets the data bitrate of the CAN FD frames sent by the model messages,
+// which switch the bitrate if it differs from the nominal one.
+func (b *Bus) SetDataBitrate(bitrate uint32) {
+	b.bitrate = cantime.NewBitrate(b.bitrate.Nominal, bitrate)
+}
+
+// SetStartTime sets the time corresponding to the start of the bus, used as the base of the frame timestamps.
+func (b *Bus) SetStartTime(start time.Time) {
+	b.start = start
+}
+
+// Codec returns the codec of the messages of the model.
+func (b *Bus) Codec() *codec.Codec {
+	return b.c
+}
+
+// Node returns the node with the given name.
+func (b *Bus) Node(name string) (*Node, error) {
+	node, ok := b.nodes[name]
+	if !ok {
+		return nil, fmt.Errorf("node [%s] is not defined", name)
+	}
+	return node, nil
+}
+
+// Tap registers a callback called with every frame transmitted on the bus, for example to log the traffic.
+func (b *Bus) Tap(fn func(rx *Received)) {
+	b.taps = append(b.taps, fn)
+}
+
+// Now returns the time elapsed since the start of the bus.
+func (b *Bus) Now() time.Duration {
+	return b.now
+}
+
+// BusyTime returns the time spent transmitting frames since the start of the bus.
+func (b *Bus) BusyTime() time.Duration {
+	return b.busyTime
+}
+
+// At schedules a callback at the given time since the start of the bus,
+// the callbacks scheduled at the same time are called in the order of scheduling.
+// A time in the past is replaced by the current time.
+func (b *Bus) At(at time.Duration, fn func()) {
+	b.seq++
+	heap.Push(&b.timers, &timer{at: max(at, b.now), seq: b.seq, fn: fn})
+}
+
+// After schedules a callback after the given delay.
+func (b *Bus) After(delay time.Duration, fn func()) {
+	b.At(b.now+delay, fn)
+}
+
+// Every schedules a callback every period, starting after a period.
+// The callback is not scheduled anymore when it returns false.
+func (b *Bus) Every(period time.Duration, fn func() bool) error {
+	if period <= 0 {
+		return fmt.Errorf("period must be positive")
+	}
+
+	var tick func()
+	next := b.now + period
+	tick = func() {
+		if !fn() {
+			return
+		}
+		next += period
+		b.At(next, tick)
+	}
+	b.At(next, tick)
+
+	return nil
+}
+
+// queue adds a frame to the ones waiting for the arbitration.
+func (b *Bus) queue(node *Node, frame *canlog.Frame) {
+	b.pending = append(b.pending, &pendingFrame{node: node, frame: frame})
+}
+
+// arbitrate starts the transmission of the pending frame with the highest priority.
+// The frames with the same key are transmitted in the order they were queued.
+func (b *Bus) arbitrate() {
+	if len(b.pending) == 0 {
+		return
+	}
+
+	winnerIdx := 0
+	winnerKey := cantime.ArbitrationKey(b.pending[0].frame)
+	for i, p := range b.pending[1:] {
+		if key := cantime.ArbitrationKey(p.frame); key < winnerKey {
+			winnerIdx, winnerKey = i+1, key
+		}
+	}
+
+	b.current = b.pending[winnerIdx]
+	b.pending = append(b.pending[:winnerIdx], b.pending[winnerIdx+1:]...)
+
+	duration := b.bitrate.FrameDuration(b.current.frame)
+	b.currentEnd = b.now + duration
+	b.busyTime += duration
+}
+
+// complete ends the current transmission and delivers the frame to the other nodes and to the taps.
+func (b *Bus) complete() {
+	p := b.current
+	b.current = nil
+
+	frame := *p.frame
+	frame.Timestamp = b.start.Add(b.now)
+
+	rx := &Received{
+		Time:   b.now,
+		Sender: p.node.name,
+		Frame:  &frame,
+	}
+	if msg, ok := b.c.MessageByFrame(frame.ID, frame.Extended); ok && !frame.Remote && !frame.Error {
+		rx.Message = msg
+		rx.Values, rx.Err = msg.Decode(frame.Data)
+	}
+
+	for _, tap := range b.taps {
+		tap(rx)
+	}
+
+	for _, node := range b.nodeList {
+		if node == p.node {
+			continue
+		}
+		for _, fn := range node.receivers {
+			fn(rx)
+		}
+	}
+}
+
+// nextEvent returns the time of the next event: the end of the current transmission or the first timer.
+func (b *Bus) nextEvent() (time.Duration, bool) {
+	next, ok := time.Duration(0), false
+	if b.current != nil {
+		next, ok = b.currentEnd, true
+	}
+	if len(b.timers) > 0 && (!ok || b.timers[0].at < next) {
+		next, ok = b.timers[0].at, true
+	}
+	return next, ok
+}
+
+// RunUntil advances the clock up to the given time since the start of the bus,
+// calling the timers and transmitting the frames in between.
+func (b *Bus) RunUntil(end time.Duration) {
+	for {
+		// the frames queued while the bus is idle are transmitted immediately
+		if b.current == nil {
+			b.arbitrate()
+		}
+
+		next, ok := b.nextEvent()
+		if !ok || next > end {
+			break
+		}
+		b.now = next
+
+		// the transmission ending at the same time of the timers is completed first, then all the timers
+		// are called before the arbitration, so the frames they queue take part to the same arbitration
+		if b.current != nil && b.currentEnd == next {
+			b.complete()
+		}
+		for len(b.timers) > 0 && b.timers[0].at == next {
+			heap.Pop(&b.timers).(*timer).fn()
+		}
+	}
+
+	b.now = max(b.now, end)
+}
+
+// Run advances the clock by the given duration.
+func (b *Bus) Run(d time.Duration) {
+	b.RunUntil(b.now + d)
+}